@@ -0,0 +1,226 @@
+// Command mockidp runs a local OpenID Connect identity provider to try single sign-on without a real one.
+// Any email typed on its sign-in page is accepted and reported as verified.
+//
+// Configure an identity provider of a school with the issuer URL, client ID and client secret below,
+// and set SSO_REDIRECT_URL to the page receiving the code and state.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mockidp"
+
+// authorization is a code issued by the sign-in page, waiting to be exchanged.
+type authorization struct {
+	email         string
+	givenName     string
+	familyName    string
+	nonce         string
+	redirectURI   string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+type mockIdP struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+var signInPage = template.Must(template.New("signin").Parse(`<!DOCTYPE html>
+<html>
+<body>
+	<h1>Mock identity provider</h1>
+	<form method="post">
+		{{range $name, $value := .Query}}<input type="hidden" name="{{$name}}" value="{{index $value 0}}">{{end}}
+		<p><label>Email <input name="email" type="email" value="{{.LoginHint}}" required></label></p>
+		<p><label>First name <input name="given_name" value="Mock"></label></p>
+		<p><label>Last name <input name="family_name" value="User"></label></p>
+		<button type="submit">Sign in</button>
+	</form>
+</body>
+</html>`))
+
+func main() {
+	addr := getEnvOrDefault("MOCK_IDP_ADDR", ":9000")
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("failed to generate signing key: %v", err)
+	}
+
+	idp := &mockIdP{
+		issuer:       getEnvOrDefault("MOCK_IDP_ISSUER", "http://localhost:9000"),
+		clientID:     getEnvOrDefault("MOCK_IDP_CLIENT_ID", "sama"),
+		clientSecret: getEnvOrDefault("MOCK_IDP_CLIENT_SECRET", "secret"),
+		key:          key,
+		codes:        make(map[string]authorization),
+	}
+
+	http.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	http.HandleFunc("/authorize", idp.authorize)
+	http.HandleFunc("/token", idp.token)
+	http.HandleFunc("/jwks", idp.jwks)
+
+	log.Printf("mock identity provider %s listening on %s (client ID %q, client secret %q)", idp.issuer, addr, idp.clientID, idp.clientSecret)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
+
+func (idp *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                idp.issuer,
+		"authorization_endpoint":                idp.issuer + "/authorize",
+		"token_endpoint":                        idp.issuer + "/token",
+		"jwks_uri":                              idp.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize shows the sign-in page, then sends the user back to the client with a code.
+func (idp *mockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("client_id") != idp.clientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		signInPage.Execute(w, map[string]interface{}{"Query": r.URL.Query(), "LoginHint": r.Form.Get("login_hint")})
+		return
+	}
+
+	code := randomHex()
+	idp.mu.Lock()
+	idp.codes[code] = authorization{
+		email:         r.PostForm.Get("email"),
+		givenName:     r.PostForm.Get("given_name"),
+		familyName:    r.PostForm.Get("family_name"),
+		nonce:         r.Form.Get("nonce"),
+		redirectURI:   r.Form.Get("redirect_uri"),
+		codeChallenge: r.Form.Get("code_challenge"),
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	idp.mu.Unlock()
+
+	redirect, err := url.Parse(r.Form.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	query := redirect.Query()
+	query.Set("code", code)
+	query.Set("state", r.Form.Get("state"))
+	redirect.RawQuery = query.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token exchanges a code for a signed ID token.
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if r.PostForm.Get("client_id") != idp.clientID || r.PostForm.Get("client_secret") != idp.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	idp.mu.Lock()
+	auth, ok := idp.codes[code]
+	delete(idp.codes, code)
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || time.Now().After(auth.expiresAt) ||
+		auth.redirectURI != r.PostForm.Get("redirect_uri") ||
+		auth.codeChallenge != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            idp.issuer,
+		"aud":            idp.clientID,
+		"sub":            auth.email,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          auth.nonce,
+		"email":          auth.email,
+		"email_verified": true,
+		"given_name":     auth.givenName,
+		"family_name":    auth.familyName,
+	})
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(idp.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomHex(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (idp *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	publicKey := idp.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": keyID,
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomHex() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
+
+func getEnvOrDefault(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -12,13 +12,22 @@ import (
 )
 
 type Config struct {
-	Database   DatabaseConfig
-	Server     ServerConfig
-	JWT        JWTConfig
-	RefreshJWT RefreshJWTConfig
-	Logging    LoggingConfig
-	S3         S3Config
-	Mailer     MailerConfig
+	Database     DatabaseConfig
+	Server       ServerConfig
+	JWT          JWTConfig
+	RefreshJWT   RefreshJWTConfig
+	Logging      LoggingConfig
+	S3           S3Config
+	Storage      StorageConfig
+	Mailer       MailerConfig
+	School       SchoolConfig
+	Transcript   TranscriptConfig
+	RateLimit    RateLimitConfig
+	Password     PasswordConfig
+	SSO          SSOConfig
+	Avatar       AvatarConfig
+	Notification NotificationConfig
+	Scheduler    SchedulerConfig
 }
 
 type DatabaseConfig struct {
@@ -36,8 +45,9 @@ type ServerConfig struct {
 }
 
 type JWTConfig struct {
-	Secret string
-	Expiry int
+	Secret              string
+	Expiry              int
+	ImpersonationExpiry int // Lifetime of the access tokens minted for SAMA crew acting as a user
 }
 
 type RefreshJWTConfig struct {
@@ -53,14 +63,83 @@ type LoggingConfig struct {
 type S3Config struct {
 	Region                   string
 	Bucket                   string
-	PreSignedLifeTimeMinutes int
+	PreSignedLifeTimeMinutes int // Lifetime of the presigned URLs of every storage backend
+}
+
+type StorageConfig struct {
+	Backend       string // "s3", or "local" to keep files on disk and serve them from the API
+	LocalPath     string // Directory of the local backend
+	PublicURL     string // Base URL the API is reached at, the local backend signs URLs against it
+	SigningSecret string // Key of the URLs signed by the local backend
+}
+
+type SchoolConfig struct {
+	ArchiveRetentionDays int
+}
+
+type TranscriptConfig struct {
+	SigningSecret string
+	FontPath      string // UTF-8 TrueType font, needed to print Thai names
+}
+
+type RateLimitConfig struct {
+	Backend           string // "memory" for a single instance, "database" to share counters between instances
+	WindowMinutes     int    // Window of the per-IP and OTP request limits
+	IPRequestLimit    int    // Requests to the authentication endpoints per IP per window, high since a school often shares one IP
+	OTPRequestLimit   int    // OTP emails per account per window
+	OTPMaxAttempts    int    // Wrong codes before an OTP is discarded
+	LoginMaxAttempts  int    // Failed logins before the account is locked
+	LockoutMinutes    int    // First lockout, doubled on every further lockout
+	MaxLockoutMinutes int
+}
+
+type PasswordConfig struct {
+	Hasher            string // "argon2id", or "bcrypt" to keep hashing like before
+	Argon2MemoryKiB   int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	MinLength         int
+	RequireUpper      bool
+	RequireLower      bool
+	RequireDigit      bool
+	RequireSymbol     bool
+	BreachedListFile  string // One refused password per line, nothing is refused when empty
+}
+
+type SSOConfig struct {
+	RedirectURL string // Page of the frontend the identity providers send users back to, single sign-on is disabled when empty
+}
+
+type AvatarConfig struct {
+	MaxSizeKB int // Largest profile picture accepted by the presigned upload
+}
+
+type NotificationConfig struct {
+	RetentionDays int // Notifications older than this are deleted, read or not
+}
+
+type SchedulerConfig struct {
+	Enabled            bool   // Instances with the scheduler disabled never run background jobs
+	Timezone           string // Time zone of the hours below and of deadline dates
+	PollSeconds        int    // How often due jobs are looked for
+	LeaseMinutes       int    // Longest a job may run before another instance takes it over
+	ReminderHour       int    // Hour of the day deadline reminders are sent
+	ReminderDaysBefore int    // Students are reminded this many days before the deadline of an unfinished activity
+	DigestWeekday      int    // Day of the week teachers receive the digest of records to review, 0 is Sunday
 }
 
 type MailerConfig struct {
+	Backend       string // "ses", "smtp", or "file" to write .eml files for development
 	Key           string
 	SenderEmail   string
 	SenderName    string
 	OTPTemplateID string
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string // No authentication when empty
+	SMTPPassword  string
+	FileDir       string // Directory of the file backend
+	TemplateDir   string // Email templates replacing or adding to the embedded ones, named <template>.<locale>.tmpl
 }
 
 func LoadConfig() *Config {
@@ -83,8 +162,9 @@ func LoadConfig() *Config {
 			Mode: getEnv("SERVER_MODE"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET"),
-			Expiry: getIntEnv("JWT_EXPIRY_MINUTE"),
+			Secret:              getEnv("JWT_SECRET"),
+			Expiry:              getIntEnv("JWT_EXPIRY_MINUTE"),
+			ImpersonationExpiry: getIntEnvOrDefault("JWT_IMPERSONATION_EXPIRY_MINUTE", 15),
 		},
 		RefreshJWT: RefreshJWTConfig{
 			Secret: getEnv("REFRESH_JWT_SECRET"),
@@ -95,15 +175,75 @@ func LoadConfig() *Config {
 			File:  getEnv("LOG_FILE"),
 		},
 		S3: S3Config{
-			Region:                   getEnv("S3_REGION"),
-			Bucket:                   getEnv("S3_BUCKET_NAME"),
-			PreSignedLifeTimeMinutes: getIntEnv("S3_PRESIGNED_LIFETIME_MINUTE"),
+			Region:                   getEnvOrDefault("S3_REGION", ""),
+			Bucket:                   getEnvOrDefault("S3_BUCKET_NAME", ""),
+			PreSignedLifeTimeMinutes: getIntEnvOrDefault("S3_PRESIGNED_LIFETIME_MINUTE", 15),
+		},
+		Storage: StorageConfig{
+			Backend:       getEnvOrDefault("STORAGE_BACKEND", "s3"),
+			LocalPath:     getEnvOrDefault("STORAGE_LOCAL_PATH", "storage"),
+			PublicURL:     getEnvOrDefault("STORAGE_PUBLIC_URL", "http://localhost:8080"),
+			SigningSecret: getEnvOrDefault("STORAGE_SIGNING_SECRET", ""),
 		},
 		Mailer: MailerConfig{
-			Key:           getEnv("MAILER_KEY"),
+			Backend:       getEnvOrDefault("MAILER_BACKEND", "ses"),
+			Key:           getEnvOrDefault("MAILER_KEY", ""),
 			SenderEmail:   getEnv("MAILER_SENDER_EMAIL"),
 			SenderName:    getEnv("MAILER_SENDER_NAME"),
-			OTPTemplateID: getEnv("MAILER_OTP_TEMPLATE_ID"),
+			OTPTemplateID: getEnvOrDefault("MAILER_OTP_TEMPLATE_ID", ""),
+			SMTPHost:      getEnvOrDefault("MAILER_SMTP_HOST", ""),
+			SMTPPort:      getIntEnvOrDefault("MAILER_SMTP_PORT", 587),
+			SMTPUsername:  getEnvOrDefault("MAILER_SMTP_USERNAME", ""),
+			SMTPPassword:  getEnvOrDefault("MAILER_SMTP_PASSWORD", ""),
+			FileDir:       getEnvOrDefault("MAILER_FILE_DIR", "mail"),
+			TemplateDir:   getEnvOrDefault("MAILER_TEMPLATE_DIR", ""),
+		},
+		School: SchoolConfig{
+			ArchiveRetentionDays: getIntEnvOrDefault("SCHOOL_ARCHIVE_RETENTION_DAYS", 90),
+		},
+		Transcript: TranscriptConfig{
+			SigningSecret: getEnvOrDefault("TRANSCRIPT_SIGNING_SECRET", ""),
+			FontPath:      getEnvOrDefault("TRANSCRIPT_FONT_PATH", ""),
+		},
+		RateLimit: RateLimitConfig{
+			Backend:           getEnvOrDefault("RATE_LIMIT_BACKEND", "memory"),
+			WindowMinutes:     getIntEnvOrDefault("RATE_LIMIT_WINDOW_MINUTE", 15),
+			IPRequestLimit:    getIntEnvOrDefault("RATE_LIMIT_IP_REQUESTS", 300),
+			OTPRequestLimit:   getIntEnvOrDefault("RATE_LIMIT_OTP_REQUESTS", 3),
+			OTPMaxAttempts:    getIntEnvOrDefault("RATE_LIMIT_OTP_ATTEMPTS", 5),
+			LoginMaxAttempts:  getIntEnvOrDefault("RATE_LIMIT_LOGIN_ATTEMPTS", 5),
+			LockoutMinutes:    getIntEnvOrDefault("RATE_LIMIT_LOCKOUT_MINUTE", 1),
+			MaxLockoutMinutes: getIntEnvOrDefault("RATE_LIMIT_MAX_LOCKOUT_MINUTE", 60),
+		},
+		Password: PasswordConfig{
+			Hasher:            getEnvOrDefault("PASSWORD_HASHER", "argon2id"),
+			Argon2MemoryKiB:   getIntEnvOrDefault("PASSWORD_ARGON2_MEMORY_KIB", 64*1024),
+			Argon2Iterations:  getIntEnvOrDefault("PASSWORD_ARGON2_ITERATIONS", 3),
+			Argon2Parallelism: getIntEnvOrDefault("PASSWORD_ARGON2_PARALLELISM", 2),
+			MinLength:         getIntEnvOrDefault("PASSWORD_MIN_LENGTH", 8),
+			RequireUpper:      getBoolEnvOrDefault("PASSWORD_REQUIRE_UPPER", false),
+			RequireLower:      getBoolEnvOrDefault("PASSWORD_REQUIRE_LOWER", false),
+			RequireDigit:      getBoolEnvOrDefault("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol:     getBoolEnvOrDefault("PASSWORD_REQUIRE_SYMBOL", false),
+			BreachedListFile:  getEnvOrDefault("PASSWORD_BREACHED_LIST_FILE", ""),
+		},
+		SSO: SSOConfig{
+			RedirectURL: getEnvOrDefault("SSO_REDIRECT_URL", ""),
+		},
+		Avatar: AvatarConfig{
+			MaxSizeKB: getIntEnvOrDefault("AVATAR_MAX_SIZE_KB", 5*1024),
+		},
+		Notification: NotificationConfig{
+			RetentionDays: getIntEnvOrDefault("NOTIFICATION_RETENTION_DAYS", 90),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:            getBoolEnvOrDefault("SCHEDULER_ENABLED", true),
+			Timezone:           getEnvOrDefault("SCHEDULER_TIMEZONE", "Asia/Bangkok"),
+			PollSeconds:        getIntEnvOrDefault("SCHEDULER_POLL_SECONDS", 60),
+			LeaseMinutes:       getIntEnvOrDefault("SCHEDULER_LEASE_MINUTES", 30),
+			ReminderHour:       getIntEnvOrDefault("REMINDER_HOUR", 8),
+			ReminderDaysBefore: getIntEnvOrDefault("REMINDER_DAYS_BEFORE", 3),
+			DigestWeekday:      getIntEnvOrDefault("DIGEST_WEEKDAY", 1),
 		},
 	}
 }
@@ -126,6 +266,13 @@ func getEnv(key string) string {
 	return ""
 }
 
+func getEnvOrDefault(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
 func getIntEnv(key string) int {
 	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
 		return value
@@ -133,3 +280,17 @@ func getIntEnv(key string) int {
 	log.Fatalln("enviroment variable is missing: " + key)
 	return 0
 }
+
+func getIntEnvOrDefault(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getBoolEnvOrDefault(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
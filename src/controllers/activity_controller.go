@@ -30,38 +30,42 @@ func NewActivityController(activityService *services.ActivityService, validate *
 
 // CreateActivityRequest defines the request body for creating a new activity.
 type CreateActivityRequest struct {
-	Name                string                 `json:"name" binding:"required" example:"School Cleanup Drive"`
-	Template            map[string]interface{} `json:"template" binding:"required" swaggertype:"object,string" example:"field:test"`
-	CoverImageUrl       *string                `json:"cover_image_url" example:"test/example"`
-	IsRequired          bool                   `json:"is_required" binding:"required" example:"true"`
-	IsForJunior         bool                   `json:"is_for_junior" validate:"required" example:"true"`
-	IsForSenior         bool                   `json:"is_for_senior" validate:"required" example:"true"`
-	ExclusiveClassrooms []string               `json:"exclusive_classrooms"  binding:"required" example:"1/1"`
-	ExclusiveStudentIDs []uint                 `json:"exclusive_student_ids"  binding:"required" example:"101"`
-	Deadline            *time.Time             `json:"deadline,omitempty" example:"2025-07-28T15:49:03.123Z"`
-	FinishedUnit        string                 `json:"finished_unit" binding:"required,oneof=TIMES HOURS" example:"HOURS"`
-	FinishedAmount      int                    `json:"finished_amount" binding:"required" example:"10"`
-	CanExceedLimit      bool                   `json:"can_exceed_limit" biding:"required" example:"false"`
-	Semester            uint                   `json:"semester,omitempty" example:"1"`
-	SchoolYear          uint                   `json:"school_year,omitempty" example:"2568"`
-	UpdateProtocol      string                 `json:"update_protocol" binding:"required,oneof=RE_EVALUATE_ALL_RECORDS IGNORE_PAST_RECORDS" example:"RE_EVALUATE_ALL_RECORDS"`
+	Name                         string                 `json:"name" binding:"required" example:"School Cleanup Drive"`
+	Template                     map[string]interface{} `json:"template" binding:"required" swaggertype:"object,string" example:"field:test"`
+	CoverImageUrl                *string                `json:"cover_image_url" example:"test/example"`
+	IsRequired                   bool                   `json:"is_required" binding:"required" example:"true"`
+	IsForJunior                  bool                   `json:"is_for_junior" validate:"required" example:"true"`
+	IsForSenior                  bool                   `json:"is_for_senior" validate:"required" example:"true"`
+	ExclusiveClassrooms          []string               `json:"exclusive_classrooms"  binding:"required" example:"1/1"`
+	ExclusiveStudentIDs          []uint                 `json:"exclusive_student_ids"  binding:"required" example:"101"`
+	ExclusiveGroupIDs            []uint                 `json:"exclusive_group_ids" example:"3"` // Student groups owned by the activity owner or shared with the school
+	Deadline                     *time.Time             `json:"deadline,omitempty" example:"2025-07-28T15:49:03.123Z"`
+	FinishedUnit                 string                 `json:"finished_unit" binding:"required,oneof=TIMES HOURS" example:"HOURS"`
+	FinishedAmount               int                    `json:"finished_amount" binding:"required" example:"10"`
+	CanExceedLimit               bool                   `json:"can_exceed_limit" biding:"required" example:"false"`
+	RequiresGuardianConfirmation bool                   `json:"requires_guardian_confirmation" example:"false"`
+	Semester                     uint                   `json:"semester,omitempty" example:"1"`
+	SchoolYear                   uint                   `json:"school_year,omitempty" example:"2568"`
+	UpdateProtocol               string                 `json:"update_protocol" binding:"required,oneof=RE_EVALUATE_ALL_RECORDS IGNORE_PAST_RECORDS" example:"RE_EVALUATE_ALL_RECORDS"`
 }
 
 // UpdateActivityRequest defines the request body for updating an activity.
 type UpdateActivityRequest struct {
-	Name                string                 `json:"name" binding:"required" example:"School Cleanup Drive"`
-	Template            map[string]interface{} `json:"template" binding:"required" swaggertype:"object,string" example:"field:test"`
-	CoverImageUrl       *string                `json:"cover_image_url" example:"test/example"`
-	IsRequired          bool                   `json:"is_required" binding:"required" example:"true"`
-	IsForJunior         bool                   `json:"is_for_junior" validate:"required" example:"true"`
-	IsForSenior         bool                   `json:"is_for_senior" validate:"required" example:"true"`
-	ExclusiveClassrooms []string               `json:"exclusive_classrooms"  binding:"required" example:"1/1"`
-	ExclusiveStudentIDs []uint                 `json:"exclusive_student_ids"  binding:"required" example:"101"`
-	Deadline            *time.Time             `json:"deadline,omitempty" example:"2025-07-28T15:49:03.123Z"`
-	FinishedUnit        string                 `json:"finished_unit" binding:"required,oneof=TIMES HOURS" example:"HOURS"`
-	FinishedAmount      int                    `json:"finished_amount" binding:"required" example:"10"`
-	CanExceedLimit      bool                   `json:"can_exceed_limit" biding:"required" example:"false"`
-	UpdateProtocol      string                 `json:"update_protocol" binding:"required,oneof=RE_EVALUATE_ALL_RECORDS IGNORE_PAST_RECORDS" example:"RE_EVALUATE_ALL_RECORDS"`
+	Name                         string                 `json:"name" binding:"required" example:"School Cleanup Drive"`
+	Template                     map[string]interface{} `json:"template" binding:"required" swaggertype:"object,string" example:"field:test"`
+	CoverImageUrl                *string                `json:"cover_image_url" example:"test/example"`
+	IsRequired                   bool                   `json:"is_required" binding:"required" example:"true"`
+	IsForJunior                  bool                   `json:"is_for_junior" validate:"required" example:"true"`
+	IsForSenior                  bool                   `json:"is_for_senior" validate:"required" example:"true"`
+	ExclusiveClassrooms          []string               `json:"exclusive_classrooms"  binding:"required" example:"1/1"`
+	ExclusiveStudentIDs          []uint                 `json:"exclusive_student_ids"  binding:"required" example:"101"`
+	ExclusiveGroupIDs            []uint                 `json:"exclusive_group_ids" example:"3"` // Student groups owned by the activity owner or shared with the school
+	Deadline                     *time.Time             `json:"deadline,omitempty" example:"2025-07-28T15:49:03.123Z"`
+	FinishedUnit                 string                 `json:"finished_unit" binding:"required,oneof=TIMES HOURS" example:"HOURS"`
+	FinishedAmount               int                    `json:"finished_amount" binding:"required" example:"10"`
+	CanExceedLimit               bool                   `json:"can_exceed_limit" biding:"required" example:"false"`
+	RequiresGuardianConfirmation bool                   `json:"requires_guardian_confirmation" example:"false"`
+	UpdateProtocol               string                 `json:"update_protocol" binding:"required,oneof=RE_EVALUATE_ALL_RECORDS IGNORE_PAST_RECORDS" example:"RE_EVALUATE_ALL_RECORDS"`
 }
 
 // CreateActivity handles creating a new activity.
@@ -86,7 +90,7 @@ func (c *ActivityController) CreateActivity(ctx *gin.Context) {
 	}
 
 	// Authorization: Only Teachers, Admins, or Sama Crew can create activities
-	if claims.Role != "TCH" && claims.Role != "ADMIN" && claims.Role != "SAMA" {
+	if !middlewares.Can(claims, "activity:create", nil) {
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions to create activities"})
 		return
 	}
@@ -98,23 +102,25 @@ func (c *ActivityController) CreateActivity(ctx *gin.Context) {
 	}
 
 	activity := &models.Activity{
-		Name:                req.Name,
-		Template:            req.Template,
-		CoverImageUrl:       req.CoverImageUrl,
-		SchoolID:            claims.SchoolID,
-		IsRequired:          req.IsRequired,
-		IsForJunior:         req.IsForJunior,
-		IsForSenior:         req.IsForSenior,
-		FinishedUnit:        req.FinishedUnit,
-		FinishedAmount:      req.FinishedAmount,
-		ExclusiveClassrooms: req.ExclusiveClassrooms,
-		ExclusiveStudentIDs: req.ExclusiveStudentIDs,
-		Semester:            req.Semester,
-		SchoolYear:          req.SchoolYear,
-		CanExceedLimit:      req.CanExceedLimit,
-		UpdateProtocol:      req.UpdateProtocol,
-		OwnerID:             claims.UserID,
-		IsActive:            true,
+		Name:                         req.Name,
+		Template:                     req.Template,
+		CoverImageUrl:                req.CoverImageUrl,
+		SchoolID:                     claims.SchoolID,
+		IsRequired:                   req.IsRequired,
+		IsForJunior:                  req.IsForJunior,
+		IsForSenior:                  req.IsForSenior,
+		FinishedUnit:                 req.FinishedUnit,
+		FinishedAmount:               req.FinishedAmount,
+		ExclusiveClassrooms:          req.ExclusiveClassrooms,
+		ExclusiveStudentIDs:          req.ExclusiveStudentIDs,
+		ExclusiveGroupIDs:            req.ExclusiveGroupIDs,
+		Semester:                     req.Semester,
+		SchoolYear:                   req.SchoolYear,
+		CanExceedLimit:               req.CanExceedLimit,
+		RequiresGuardianConfirmation: req.RequiresGuardianConfirmation,
+		UpdateProtocol:               req.UpdateProtocol,
+		OwnerID:                      claims.UserID,
+		IsActive:                     true,
 	}
 
 	// Prepare CustomStudentIDs for the service.
@@ -142,7 +148,7 @@ func (c *ActivityController) CreateActivity(ctx *gin.Context) {
 
 // GetActivityByID retrieves an activity by its ID.
 // @Summary Get activity by ID
-// @Description Retrieve details of a specific activity by its ID. Accessible by owner, relevant school ADMIN/TCH, or Sama Crew.
+// @Description Retrieve details of a specific activity by its ID. Accessible by ADMIN/TCH/STD of the activity's school, or Sama Crew. Guardians view activities through their linked students.
 // @Tags Activity
 // @Security BearerAuth
 // @Produce json
@@ -167,7 +173,7 @@ func (c *ActivityController) GetActivityByID(ctx *gin.Context) {
 		return
 	}
 
-	activity, err := c.activityService.GetActivityByID(uint(id))
+	activity, err := c.activityService.GetActivityByID(middlewares.GetTenantScopeFromContext(ctx), uint(id))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("activity with ID %d not found", id) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -177,37 +183,10 @@ func (c *ActivityController) GetActivityByID(ctx *gin.Context) {
 		return
 	}
 
-	// Authorization logic for viewing an activity:
-	// 1. Sama Crew can view any activity.
-	// 2. Owner of the activity can view it.
-	// 3. ADMIN/TCH of the same school as the activity's owner (assuming owner's school is tied to activity)
-	//    or if the activity is school-wide for their school, can view it.
-	if claims.Role != "SAMA" && claims.UserID != activity.OwnerID {
-		// // Need to fetch owner's school ID to compare
-		// owner, err := c.activityService.userRepo.GetUserByID(activity.OwnerID)
-		// if err != nil {
-		// 	ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to verify activity owner's school for authorization"})
-		// 	return
-		// }
-		// if (claims.Role == "ADMIN" || claims.Role == "TCH") && claims.SchoolID != owner.SchoolID {
-		// 	ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to view this activity."})
-		// 	return
-		// }
-		// If the activity is CUSTOM coverage, and the current user is a CustomStudent, they can view it.
-		// This requires checking if claims.UserID is in activity.CustomStudentIDs.
-		// if activity.CoverageType == "CUSTOM" {
-		// 	isCustomStudent := false
-		// 	for _, student := range activity.CustomStudentIDs {
-		// 		if student.ID == claims.UserID {
-		// 			isCustomStudent = true
-		// 			break
-		// 		}
-		// 	}
-		// 	if !isCustomStudent {
-		// 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to view this activity."})
-		// 		return
-		// 	}
-		// }
+	// Guardians follow activities through the students they are linked to
+	if !middlewares.Can(claims, "activity:read", &activity.Activity) {
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to view this activity"})
+		return
 	}
 
 	ctx.JSON(http.StatusOK, activity)
@@ -229,11 +208,11 @@ func (c *ActivityController) GetActivityByID(ctx *gin.Context) {
 // @Success 200 {object} PaginateActivitiesResponse "List of activities retrieved successfully"
 // @Failure 400 {object} ErrorResponse "Invalid query parameters"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
-// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or activities of another school requested)"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /activity [get]
 func (c *ActivityController) GetAllActivities(ctx *gin.Context) {
-	_, ok := middlewares.GetUserClaimsFromContext(ctx)
+	claims, ok := middlewares.GetUserClaimsFromContext(ctx)
 	if !ok {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
 		return
@@ -256,6 +235,16 @@ func (c *ActivityController) GetAllActivities(ctx *gin.Context) {
 	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
 	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
 
+	// Only Sama Crew can list the activities of another school
+	listedSchoolID := uint(schoolID)
+	if listedSchoolID == 0 {
+		listedSchoolID = claims.SchoolID
+	}
+	if !middlewares.Can(claims, "activity:read", middlewares.SchoolResource(listedSchoolID)) {
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions to list activities"})
+		return
+	}
+
 	// // Apply authorization filtering
 	// if claims.Role == "TCH" {
 	// 	// Teacher can only see their own activities
@@ -269,7 +258,7 @@ func (c *ActivityController) GetAllActivities(ctx *gin.Context) {
 	// }
 	// // SAMA has no restrictions on ownerID or schoolID.
 
-	activities, count, err := c.activityService.GetAllActivities(uint(ownerID), uint(schoolID), uint(semester), uint(schoolYear), limit, offset)
+	activities, count, err := c.activityService.GetAllActivities(middlewares.GetTenantScopeFromContext(ctx), uint(ownerID), uint(schoolID), uint(semester), uint(schoolYear), limit, offset)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve activities: " + err.Error()})
 		return
@@ -314,7 +303,8 @@ func (c *ActivityController) UpdateActivity(ctx *gin.Context) {
 		return
 	}
 
-	existingActivity, err := c.activityService.GetActivityByID(uint(id))
+	scope := middlewares.GetTenantScopeFromContext(ctx)
+	existingActivity, err := c.activityService.GetActivityByID(scope, uint(id))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("activity with ID %d not found", id) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -325,7 +315,7 @@ func (c *ActivityController) UpdateActivity(ctx *gin.Context) {
 	}
 
 	// Authorization: Only owner or SAMA can update
-	if claims.Role != "SAMA" && claims.UserID != existingActivity.OwnerID {
+	if !middlewares.Can(claims, "activity:update", existingActivity) {
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: You are not authorized to update this activity"})
 		return
 	}
@@ -337,31 +327,33 @@ func (c *ActivityController) UpdateActivity(ctx *gin.Context) {
 	}
 
 	activity := &models.Activity{
-		ID:                  existingActivity.ID,
-		Name:                req.Name,
-		Template:            req.Template,
-		CoverImageUrl:       req.CoverImageUrl,
-		SchoolID:            existingActivity.SchoolID,
-		IsRequired:          req.IsRequired,
-		IsForJunior:         req.IsForJunior,
-		IsForSenior:         req.IsForSenior,
-		FinishedUnit:        req.FinishedUnit,
-		FinishedAmount:      req.FinishedAmount,
-		ExclusiveClassrooms: req.ExclusiveClassrooms,
-		ExclusiveStudentIDs: req.ExclusiveStudentIDs,
-		CanExceedLimit:      req.CanExceedLimit,
-		UpdateProtocol:      req.UpdateProtocol,
-		OwnerID:             existingActivity.OwnerID,
-		IsActive:            existingActivity.IsActive,
+		ID:                           existingActivity.ID,
+		Name:                         req.Name,
+		Template:                     req.Template,
+		CoverImageUrl:                req.CoverImageUrl,
+		SchoolID:                     existingActivity.SchoolID,
+		IsRequired:                   req.IsRequired,
+		IsForJunior:                  req.IsForJunior,
+		IsForSenior:                  req.IsForSenior,
+		FinishedUnit:                 req.FinishedUnit,
+		FinishedAmount:               req.FinishedAmount,
+		ExclusiveClassrooms:          req.ExclusiveClassrooms,
+		ExclusiveStudentIDs:          req.ExclusiveStudentIDs,
+		ExclusiveGroupIDs:            req.ExclusiveGroupIDs,
+		CanExceedLimit:               req.CanExceedLimit,
+		RequiresGuardianConfirmation: req.RequiresGuardianConfirmation,
+		UpdateProtocol:               req.UpdateProtocol,
+		OwnerID:                      existingActivity.OwnerID,
+		IsActive:                     existingActivity.IsActive,
 	}
 
-	if err := c.activityService.UpdateActivity(activity); err != nil {
+	if err := c.activityService.UpdateActivity(scope, activity); err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to update activity: " + err.Error()})
 		return
 	}
 
 	// Re-fetch to get updated associations
-	updatedActivity, err := c.activityService.GetActivityByID(uint(id))
+	updatedActivity, err := c.activityService.GetActivityByID(scope, uint(id))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve updated activity: " + err.Error()})
 		return
@@ -397,7 +389,8 @@ func (c *ActivityController) DeleteActivity(ctx *gin.Context) {
 		return
 	}
 
-	existingActivity, err := c.activityService.GetActivityByID(uint(id))
+	scope := middlewares.GetTenantScopeFromContext(ctx)
+	existingActivity, err := c.activityService.GetActivityByID(scope, uint(id))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("activity with ID %d not found", id) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -408,12 +401,12 @@ func (c *ActivityController) DeleteActivity(ctx *gin.Context) {
 	}
 
 	// Authorization: Only owner or SAMA can delete
-	if claims.Role != "SAMA" && claims.UserID != existingActivity.OwnerID {
+	if !middlewares.Can(claims, "activity:delete", existingActivity) {
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: You are not authorized to delete this activity"})
 		return
 	}
 
-	if err := c.activityService.DeleteActivity(uint(id)); err != nil {
+	if err := c.activityService.DeleteActivity(scope, uint(id)); err != nil {
 		if err.Error() == fmt.Sprintf("activity with ID %d not found for deletion", id) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
 			return
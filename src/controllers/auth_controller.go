@@ -1,8 +1,13 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"sama/sama-backend-2025/src/middlewares"
 	"sama/sama-backend-2025/src/models"
 	"sama/sama-backend-2025/src/services"
 
@@ -29,11 +34,10 @@ func NewAuthController(authService *services.AuthService, validate *validator.Va
 type RegisterRequest struct {
 	StudentID       *string `json:"student_id,omitempty" example:"10101"` // UserID might be generated by system, or provided for specific roles
 	Email           string  `json:"email" binding:"required,email" validate:"required,email" example:"user@example.com"`
-	Password        string  `json:"password" binding:"required,min=8" validate:"required,min=8,alphanumunderscore" example:"Secure_P@ss1"` // Added alphanumunderscore to validate tag
+	Password        string  `json:"password" binding:"required" validate:"required" example:"Secure_P@ss1"` // Checked against the password policy
 	Firstname       string  `json:"firstname" binding:"required" validate:"required" example:"John"`
 	Lastname        string  `json:"lastname" binding:"required" validate:"required" example:"Doe"`
-	Role            string  `json:"role" binding:"required,oneof=STD TCH ADMIN" validate:"required,oneof=STD TCH ADMIN" example:"STD"` // Validate against roles
-	SchoolID        uint    `json:"school_id" binding:"required,gt=0" validate:"required,gt=0" example:"1"`
+	InvitationCode  string  `json:"invitation_code" binding:"required" example:"K7QX2M9PLA"` // Role, school and classroom are taken from the code
 	Phone           string  `json:"phone,omitempty" example:"+1234567890"`
 	Classroom       *string `json:"classroom,omitempty" example:"1/1"`
 	Number          *uint   `json:"number,omitempty" example:"1"`
@@ -43,8 +47,9 @@ type RegisterRequest struct {
 
 // LoginRequest represents the request body for user login.
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email" validate:"required,email" example:"user@example.com"`
-	Password string `json:"password" binding:"required" validate:"required" example:"Secure_P@ss1"`
+	Email      string `json:"email" binding:"required,email" validate:"required,email" example:"user@example.com"`
+	Password   string `json:"password" binding:"required" validate:"required" example:"Secure_P@ss1"`
+	DeviceName string `json:"device_name,omitempty" binding:"max=100" example:"John's iPhone"` // Detected from the user agent when omitted
 }
 
 // LoginResponse represents the response body for successful login.
@@ -58,16 +63,33 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 }
 
-// ValidateOtpRequest represents the request body for validating an OTP and resetting password.
+// ValidateOtpRequest represents the request body for validating an OTP.
 type ValidateOtpRequest struct {
 	Email string `json:"email" binding:"required,email" validate:"required" example:"user@example.com"`
 	Otp   string `json:"code" binding:"required" validate:"required" example:"123456"` // Assuming 6-digit OTP
 }
 
-// ValidateOtpRequest represents the request body for validating an OTP and resetting password.
+// ResetTokenResponse represents the response body of a validated OTP.
+type ResetTokenResponse struct {
+	ResetToken string    `json:"reset_token" example:"5f2b9c0e8a7d4e1f9b3c6a2d8e4f7a1b5c9d3e6f0a2b4c8d1e5f9a3b7c0d2e4f"`
+	ExpiresAt  time.Time `json:"expires_at" example:"2025-07-28T15:59:03Z"`
+}
+
+// ResetPasswordRequest represents the request body for resetting password with a reset token.
 type ResetPasswordRequest struct {
-	ValidateOtpRequest
-	NewPassword string `json:"new_password" binding:"required,min=8" validate:"required,min=8,alphanumunderscore" example:"NewSecure_P@ss2"`
+	ResetToken  string `json:"reset_token" binding:"required" example:"5f2b9c0e8a7d4e1f9b3c6a2d8e4f7a1b5c9d3e6f0a2b4c8d1e5f9a3b7c0d2e4f"`
+	NewPassword string `json:"new_password" binding:"required" validate:"required" example:"NewSecure_P@ss2"` // Checked against the password policy
+}
+
+// ImpersonationResponse represents the response body when a Sama Crew member starts acting as a user.
+type ImpersonationResponse struct {
+	AccessToken string    `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresAt   time.Time `json:"expires_at" example:"2025-01-01T00:15:00Z"`
+}
+
+// VerifyEmailRequest represents the request body for confirming the email of the current user.
+type VerifyEmailRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
 }
 
 // RequestOtpRequest represents the request body for requesting an OTP.
@@ -77,13 +99,14 @@ type RequestOtpRequest struct {
 
 // RegisterUser handles user registration.
 // @Summary Register a new user
-// @Description Register a new user account (can be STD, TCH, ADMIN). UserID can be system-generated or provided.
+// @Description Register a new user account with an invitation code generated by an ADMIN or Sama Crew. The role, school and classroom (if set) of the user are taken from the code. UserID can be system-generated or provided.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param user body RegisterRequest true "User registration details"
 // @Success 201 {object} models.User "User created successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
+// @Failure 400 {object} ErrorResponse "Invalid request payload, validation error, password refused by the policy, or invalid or expired invitation code"
+// @Failure 403 {object} ErrorResponse "Registration with this role is not allowed by the school"
 // @Failure 409 {object} ErrorResponse "User with this email already exists"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /register [post]
@@ -100,8 +123,6 @@ func (h *AuthController) RegisterUser(c *gin.Context) {
 		Password:        req.Password, // Plain password, will be hashed in service
 		Firstname:       req.Firstname,
 		Lastname:        req.Lastname,
-		Role:            req.Role,
-		SchoolID:        req.SchoolID,
 		Phone:           req.Phone,
 		Classroom:       req.Classroom,
 		Number:          req.Number,
@@ -109,11 +130,19 @@ func (h *AuthController) RegisterUser(c *gin.Context) {
 		BookmarkUserIDs: req.BookmarkUserIDs,
 	}
 
-	if err := h.authService.RegisterUser(user); err != nil {
+	if err := h.authService.RegisterUser(user, req.InvitationCode); err != nil {
+		if err.Error() == "invitation code is invalid or expired" || strings.HasPrefix(err.Error(), "password ") {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
 		if err.Error() == "user with this email already exists" {
 			c.JSON(http.StatusConflict, ErrorResponse{Message: err.Error()})
 			return
 		}
+		if err.Error() == fmt.Sprintf("registration as %s is not allowed in this school", user.Role) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Message: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to register user: " + err.Error()})
 		return
 	}
@@ -125,7 +154,7 @@ func (h *AuthController) RegisterUser(c *gin.Context) {
 
 // Login handles user login and returns a JWT token.
 // @Summary Log in a user
-// @Description Authenticate user credentials and return a JWT token.
+// @Description Authenticate user credentials and return a JWT token. A session is opened for the device, named from the user agent unless device_name is given.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -133,6 +162,7 @@ func (h *AuthController) RegisterUser(c *gin.Context) {
 // @Success 200 {object} LoginResponse "Successful login with JWT token"
 // @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
 // @Failure 401 {object} ErrorResponse "Invalid credentials or account deactivated"
+// @Failure 429 {object} ErrorResponse "Too many failed attempts, the account is temporarily locked"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /login [post]
 func (h *AuthController) Login(c *gin.Context) {
@@ -142,12 +172,22 @@ func (h *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	token, refreshToken, err := h.authService.Login(req.Email, req.Password)
+	session := &models.Session{
+		DeviceName: req.DeviceName,
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+	}
+
+	token, refreshToken, err := h.authService.Login(req.Email, req.Password, session)
 	if err != nil {
 		if err.Error() == "invalid credentials" || err.Error() == "user account is deactivated" {
 			c.JSON(http.StatusUnauthorized, ErrorResponse{Message: err.Error()})
 			return
 		}
+		if err.Error() == "too many attempts, please try again later" {
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{Message: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to login: " + err.Error()})
 		return
 	}
@@ -157,14 +197,14 @@ func (h *AuthController) Login(c *gin.Context) {
 
 // RequestOtp handles requesting an OTP for password reset.
 // @Summary Request OTP for password reset
-// @Description Sends a One-Time Password (OTP) to the user's registered email address to initiate a password reset.
+// @Description Sends a One-Time Password (OTP) to the user's registered email address to initiate a password reset. The response is the same whether the email is registered or not.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param user_otp_request body RequestOtpRequest true "User id to send OTP"
-// @Success 200 {object} SuccessfulResponse "OTP sended"
+// @Success 200 {object} SuccessfulResponse "OTP sent if the email is registered"
 // @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
-// @Failure 404 {object} ErrorResponse "User with this email not found"
+// @Failure 429 {object} ErrorResponse "Too many OTP requests for this email"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /password-reset/request-otp [post]
 func (h *AuthController) RequestOtp(c *gin.Context) {
@@ -176,23 +216,28 @@ func (h *AuthController) RequestOtp(c *gin.Context) {
 
 	err := h.authService.RequestOtp(req.Email)
 	if err != nil {
+		if err.Error() == "too many attempts, please try again later" {
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{Message: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to request OTP: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessfulResponse{"OTP sent to your email"})
+	c.JSON(http.StatusOK, SuccessfulResponse{"If the email is registered, an OTP has been sent to it"})
 }
 
-// ValidateOtp handles validating an OTP and resetting the user's password.
-// @Summary Validate OTP and reset password
-// @Description Validates the provided OTP and email, then allows the user to set a new password. Returns a reset token or success message.
+// ValidateOtp handles validating an OTP and exchanging it for a reset token.
+// @Summary Validate OTP
+// @Description Validates the provided OTP and email, and returns a short-lived single-use reset token needed to set a new password. The OTP cannot be used again.
 // @Tags Auth
 // @Accept json
 // @Produce json
-// @Param otp_validation body ValidateOtpRequest true "OTP validation and new password details"
-// @Success 200 {object} SuccessfulResponse "OTP validated successfully"
+// @Param otp_validation body ValidateOtpRequest true "Email and OTP"
+// @Success 200 {object} ResetTokenResponse "OTP validated successfully"
 // @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
 // @Failure 401 {object} ErrorResponse "Invalid OTP or email"
+// @Failure 429 {object} ErrorResponse "Too many requests from this IP"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /password-reset/validate-otp [post]
 func (h *AuthController) ValidateOtp(c *gin.Context) {
@@ -202,32 +247,30 @@ func (h *AuthController) ValidateOtp(c *gin.Context) {
 		return
 	}
 
-	// TODO: Implement actual OTP validation and password reset logic in the service layer
-	// Example:
-	status, err := h.authService.VerifyOTP(req.Email, req.Otp)
+	resetToken, expiresAt, err := h.authService.VerifyOTP(req.Email, req.Otp)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to validate OTP and reset password: " + err.Error()})
-		return
-	}
-
-	if !status {
-		c.JSON(http.StatusBadGateway, ErrorResponse{Message: "Invalid or expried otp"})
+		if err.Error() == "invalid or expired otp" {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Message: "Invalid or expired otp"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to validate OTP: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessfulResponse{"OTP validated successfully"})
+	c.JSON(http.StatusOK, ResetTokenResponse{ResetToken: resetToken, ExpiresAt: *expiresAt})
 }
 
 // ResetPassword handles resetting the user's password.
 // @Summary reset password
-// @Description Validates the provided OTP and email, then allows the user to set a new password. Returns a reset token or success message.
+// @Description Sets a new password with the reset token returned by the OTP validation. The token can only be used once, and the user is logged out of every device.
 // @Tags Auth
 // @Accept json
 // @Produce json
-// @Param otp_validation body ResetPasswordRequest true "OTP validation and new password details"
-// @Success 200 {object} SuccessfulResponse "OTP validated and password reset successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
-// @Failure 401 {object} ErrorResponse "Invalid OTP or email"
+// @Param reset_password body ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} SuccessfulResponse "Password reset successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload, validation error or password refused by the policy"
+// @Failure 401 {object} ErrorResponse "Invalid or expired reset token"
+// @Failure 429 {object} ErrorResponse "Too many requests from this IP"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /password-reset/change-password [post]
 func (h *AuthController) ResetPassword(c *gin.Context) {
@@ -237,40 +280,32 @@ func (h *AuthController) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	// TODO: Implement actual OTP validation and password reset logic in the service layer
-	// Example:
-	status, err := h.authService.VerifyOTP(req.Email, req.Otp)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to validate OTP and reset password: " + err.Error()})
-		return
-	}
-
-	if !status {
-		c.JSON(http.StatusBadGateway, ErrorResponse{Message: "Invalid or expried otp"})
-		return
-	}
-
-	err = h.authService.UpdateUserPassword(req.Email, req.NewPassword)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to update user password: " + err.Error()})
+	if err := h.authService.ResetPassword(req.ResetToken, req.NewPassword); err != nil {
+		if err.Error() == "invalid or expired reset token" {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Message: err.Error()})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "password ") {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to reset password: " + err.Error()})
 		return
 	}
 
-	err = h.authService.DeleteOTP(req.Email)
-
-	c.JSON(http.StatusOK, SuccessfulResponse{"OTP validated and password reset successfully"})
+	c.JSON(http.StatusOK, SuccessfulResponse{"Password reset successfully"})
 }
 
 // RefreshToken handles refreshing a JWT access token using a refresh token.
 // @Summary Refresh access token
-// @Description Exchanges a valid refresh token for a new access token and refresh token.
+// @Description Exchanges a valid refresh token for a new access token and refresh token. A refresh token can only be used once; reusing it revokes every token of the same login.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param refresh_token_request body RefreshTokenRequest true "Refresh token"
 // @Success 200 {object} LoginResponse "New access and refresh tokens"
 // @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
-// @Failure 401 {object} ErrorResponse "Invalid or expired refresh token"
+// @Failure 401 {object} ErrorResponse "Invalid, expired or reused refresh token, or account deactivated"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /refresh-token [post]
 func (h *AuthController) RefreshToken(c *gin.Context) {
@@ -280,15 +315,10 @@ func (h *AuthController) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// TODO: Call a service method to handle refresh token logic
-	// This service method would:
-	// 1. Validate the refresh token (e.g., check against a database of valid refresh tokens)
-	// 2. If valid, generate a new access token and a new refresh token
-	// 3. Invalidate the old refresh token (optional, but recommended for security)
-	// Example:
-	newAccessToken, newRefreshToken, err := h.authService.RefreshToken(req.RefreshToken)
+	newAccessToken, newRefreshToken, err := h.authService.RefreshToken(req.RefreshToken, c.ClientIP())
 	if err != nil {
-		if err.Error() == "invalid or expired refresh token" {
+		if err.Error() == "invalid or expired refresh token" || err.Error() == "refresh token reuse detected" ||
+			err.Error() == "invalid credentials" || err.Error() == "user account is deactivated" {
 			c.JSON(http.StatusUnauthorized, ErrorResponse{Message: err.Error()})
 			return
 		}
@@ -301,3 +331,192 @@ func (h *AuthController) RefreshToken(c *gin.Context) {
 		RefreshToken: newRefreshToken,
 	})
 }
+
+// Logout handles ending the session of a refresh token.
+// @Summary Log out
+// @Description Revoke the given refresh token and every token rotated from the same login. The access token stays valid until it expires.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param refresh_token_request body RefreshTokenRequest true "Refresh token of the session"
+// @Success 200 {object} SuccessfulResponse "Logged out successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Invalid or expired refresh token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /logout [post]
+func (h *AuthController) Logout(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	if err := h.authService.Logout(claims.UserID, req.RefreshToken); err != nil {
+		if err.Error() == "invalid or expired refresh token" {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to logout: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessfulResponse{"Logged out successfully"})
+}
+
+// LogoutAll handles ending every session of the current user.
+// @Summary Log out of all devices
+// @Description Revoke every refresh token of the current user. Access tokens stay valid until they expire.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} SuccessfulResponse "Logged out of all devices successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /logout-all [post]
+func (h *AuthController) LogoutAll(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	if err := h.authService.LogoutAll(claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to logout of all devices: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessfulResponse{"Logged out of all devices successfully"})
+}
+
+// ResendEmailVerification handles sending a new email verification code to the current user.
+// @Summary Send an email verification code
+// @Description Email a new verification code to the pending email of the current user, or to their current email when it isn't verified yet. Any previous code stops working.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} SuccessfulResponse "Verification code sent successfully"
+// @Failure 400 {object} ErrorResponse "Email is already verified"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (impersonating a user)"
+// @Failure 429 {object} ErrorResponse "Too many verification emails requested"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/me/email-verification [post]
+func (h *AuthController) ResendEmailVerification(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	if err := h.authService.ResendEmailVerification(claims.UserID); err != nil {
+		switch err.Error() {
+		case "email is already verified":
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		case "too many attempts, please try again later":
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to send verification code: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessfulResponse{"Verification code sent successfully"})
+}
+
+// VerifyEmail handles confirming the email of the current user.
+// @Summary Verify email
+// @Description Confirm the code sent by email. A pending email becomes the email of the user once confirmed.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param verify_email body VerifyEmailRequest true "Verification code"
+// @Success 200 {object} SuccessfulResponse "Email verified successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload or invalid verification code"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (impersonating a user)"
+// @Failure 409 {object} ErrorResponse "Email already used by another user"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/me/email-verification/confirm [post]
+func (h *AuthController) VerifyEmail(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	if err := h.authService.VerifyEmail(claims.UserID, req.Code); err != nil {
+		switch err.Error() {
+		case "invalid or expired verification code":
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		case "user with this email already exists":
+			c.JSON(http.StatusConflict, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to verify email: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessfulResponse{"Email verified successfully"})
+}
+
+// Impersonate handles a Sama Crew member starting to act as a user.
+// @Summary Act as a user
+// @Description Issue a short-lived access token carrying the claims of the user and the ID of the Sama Crew member. No refresh token is issued. Every write made with the token is audited, and the token cannot change the password, email or sessions of the user. Requires Sama Crew role.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID of the user to act as"
+// @Success 200 {object} ImpersonationResponse "Impersonation token issued successfully"
+// @Failure 400 {object} ErrorResponse "Invalid user ID, or the user is a Sama Crew member"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or already impersonating)"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/impersonate [post]
+func (h *AuthController) Impersonate(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	if !middlewares.Can(claims, "user:impersonate", nil) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	token, expiresAt, err := h.authService.Impersonate(claims.UserID, uint(id), c.ClientIP())
+	if err != nil {
+		switch err.Error() {
+		case fmt.Sprintf("user with ID %d not found", id):
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+		case "cannot impersonate yourself", "cannot impersonate a SAMA user":
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to impersonate user: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ImpersonationResponse{AccessToken: token, ExpiresAt: *expiresAt})
+}
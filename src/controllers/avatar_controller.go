@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AvatarController manages HTTP requests for the profile picture of the current user.
+type AvatarController struct {
+	avatarService *services.AvatarService
+}
+
+// NewAvatarController creates a new AvatarController.
+func NewAvatarController(avatarService *services.AvatarService) *AvatarController {
+	return &AvatarController{
+		avatarService: avatarService,
+	}
+}
+
+// AvatarUploadRequest represents the request body for an avatar upload.
+type AvatarUploadRequest struct {
+	ContentType string `json:"content_type" binding:"required" example:"image/png"`
+}
+
+// ConfirmAvatarRequest represents the request body for confirming an uploaded avatar.
+type ConfirmAvatarRequest struct {
+	ObjectKey string `json:"object_key" binding:"required" example:"avatars/1/pending/e3c4e512-421e-45a2-921d-a9f3c7e0c4f8"`
+}
+
+// RequestAvatarUpload handles the request for an avatar upload presigned URL.
+// @Summary Get presigned URL for avatar upload
+// @Description Generates a presigned POST URL and form fields to upload a new avatar. The policy only accepts the declared content type (image/jpeg, image/png or image/webp) up to the configured size. The upload must be confirmed afterwards.
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param upload body AvatarUploadRequest true "Content type of the image to be uploaded"
+// @Success 200 {object} UploadResponse "Presigned URL and form data for upload"
+// @Failure 400 {object} ErrorResponse "Invalid request payload or content type not allowed"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/me/avatar/upload-url [post]
+func (h *AvatarController) RequestAvatarUpload(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	var req AvatarUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	presignedPostRequest, err := h.avatarService.RequestAvatarUpload(c.Request.Context(), claims.UserID, req.ContentType)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "content type ") {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to get presigned URL: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, UploadResponse{
+		URL:    presignedPostRequest.URL,
+		Fields: presignedPostRequest.Values,
+	})
+}
+
+// ConfirmAvatar sets an uploaded image as the avatar of the current user.
+// @Summary Confirm avatar upload
+// @Description Verifies the uploaded image, stores it with thumbnails of 64, 128 and 256 pixels and sets it as the profile picture. The previous avatar is deleted.
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param avatar body ConfirmAvatarRequest true "Object key returned in the upload form fields"
+// @Success 200 {object} models.User "Avatar updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload, object key or image"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Uploaded avatar not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/me/avatar [put]
+func (h *AvatarController) ConfirmAvatar(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	var req ConfirmAvatarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	user, err := h.avatarService.ConfirmAvatar(c.Request.Context(), claims.UserID, req.ObjectKey)
+	if err != nil {
+		switch {
+		case err.Error() == "invalid avatar object key" || strings.HasPrefix(err.Error(), "invalid avatar: "):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		case err.Error() == "uploaded avatar not found or too large":
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to update avatar: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteAvatar removes the avatar of the current user.
+// @Summary Delete avatar
+// @Description Removes the profile picture of the current user along with its thumbnails.
+// @Tags User
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} SuccessfulResponse "Avatar deleted successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/me/avatar [delete]
+func (h *AvatarController) DeleteAvatar(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	if err := h.avatarService.DeleteAvatar(c.Request.Context(), claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to delete avatar: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessfulResponse{Message: "Avatar deleted successfully"})
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailTemplateController manages HTTP requests for reviewing email templates.
+type EmailTemplateController struct {
+	emailTemplateService *services.EmailTemplateService
+}
+
+// NewEmailTemplateController creates a new EmailTemplateController.
+func NewEmailTemplateController(emailTemplateService *services.EmailTemplateService) *EmailTemplateController {
+	return &EmailTemplateController{
+		emailTemplateService: emailTemplateService,
+	}
+}
+
+// EmailTemplateResponse describes an email template and the languages it is translated to.
+type EmailTemplateResponse struct {
+	Name    string   `json:"name" example:"otp"`
+	Locales []string `json:"locales" example:"en,th"`
+}
+
+// EmailPreviewResponse represents an email template rendered with sample data.
+type EmailPreviewResponse struct {
+	Name     string `json:"name" example:"otp"`
+	Locale   string `json:"locale" example:"th"` // Language actually rendered, English when the template isn't translated to the requested one
+	Subject  string `json:"subject" example:"Your One-Time Password (OTP)"`
+	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body"`
+}
+
+// GetEmailTemplates handles listing the email templates.
+// @Summary Get email templates
+// @Description List the email templates with the languages they are translated to. Requires ADMIN or Sama Crew role.
+// @Tags Email template
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} EmailTemplateResponse "Email templates retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Router /email-template [get]
+func (h *EmailTemplateController) GetEmailTemplates(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	if !middlewares.Can(claims, "email-template:preview", nil) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	templates := h.emailTemplateService.GetEmailTemplates()
+	response := make([]EmailTemplateResponse, 0, len(templates))
+	for name, locales := range templates {
+		response = append(response, EmailTemplateResponse{Name: name, Locales: locales})
+	}
+	slices.SortFunc(response, func(a, b EmailTemplateResponse) int { return strings.Compare(a.Name, b.Name) })
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PreviewEmailTemplate handles rendering an email template with sample data.
+// @Summary Preview an email template
+// @Description Render an email template with sample data in the given language, falling back to English when it isn't translated. Requires ADMIN or Sama Crew role.
+// @Tags Email template
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Template name"
+// @Param language query string false "Language to render, defaults to English" example(th)
+// @Success 200 {object} EmailPreviewResponse "Email template rendered successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "Email template not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /email-template/{name}/preview [get]
+func (h *EmailTemplateController) PreviewEmailTemplate(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	if !middlewares.Can(claims, "email-template:preview", nil) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	name := c.Param("name")
+	message, err := h.emailTemplateService.PreviewEmailTemplate(name, c.Query("language"))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("email template %s not found", name) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to render email template: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, EmailPreviewResponse{
+		Name:     name,
+		Locale:   message.Locale,
+		Subject:  message.Subject,
+		HTMLBody: message.HTMLBody,
+		TextBody: message.TextBody,
+	})
+}
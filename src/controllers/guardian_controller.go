@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GuardianController manages HTTP requests for the links between guardians and students.
+type GuardianController struct {
+	guardianService *services.GuardianService
+	userService     *services.UserService
+}
+
+// NewGuardianController creates a new GuardianController.
+func NewGuardianController(guardianService *services.GuardianService, userService *services.UserService) *GuardianController {
+	return &GuardianController{
+		guardianService: guardianService,
+		userService:     userService,
+	}
+}
+
+// LinkStudentRequest defines the request body for linking a guardian to a student.
+type LinkStudentRequest struct {
+	StudentID uint `json:"student_id" binding:"required" example:"101"`
+}
+
+// handleGuardianError maps the errors of the guardian service to HTTP responses.
+func (h *GuardianController) handleGuardianError(c *gin.Context, err error, guardianID, studentID uint, prefix string) {
+	switch err.Error() {
+	case fmt.Sprintf("user with ID %d not found", guardianID),
+		fmt.Sprintf("user with ID %d not found", studentID),
+		fmt.Sprintf("guardian is not linked to student %d", studentID):
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+	case "user is not a guardian",
+		"user is not a student",
+		"guardian and student must belong to the same school":
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+	case fmt.Sprintf("guardian is already linked to student %d", studentID):
+		c.JSON(http.StatusConflict, ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: prefix + err.Error()})
+	}
+}
+
+// GetLinkedStudents retrieves the students a guardian is linked to.
+// @Summary Get linked students of a guardian
+// @Description Retrieve the students a guardian can follow. Accessible by the guardian, ADMIN (for their school) or Sama Crew.
+// @Tags Guardian
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Guardian user ID"
+// @Success 200 {array} models.GuardianLink "Linked students retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid user ID or user is not a guardian"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/linked-student [get]
+func (h *GuardianController) GetLinkedStudents(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	guardian, err := h.userService.GetUserByID(scope, uint(id))
+	if err != nil {
+		h.handleGuardianError(c, err, uint(id), 0, "Failed to retrieve user: ")
+		return
+	}
+
+	// ADMIN can only view the links of guardians in their school
+	if !middlewares.Can(claims, "guardian:list-students", guardian) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	links, err := h.guardianService.GetLinkedStudents(scope, guardian.ID)
+	if err != nil {
+		h.handleGuardianError(c, err, uint(id), 0, "Failed to retrieve linked students: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// LinkStudent links a guardian to a student.
+// @Summary Link a guardian to a student
+// @Description Give a guardian read access to the activities, records and statistics of a student of the same school. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags Guardian
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Guardian user ID"
+// @Param link body LinkStudentRequest true "Student to link"
+// @Success 201 {object} models.GuardianLink "Guardian linked successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload, user is not a guardian or not a student"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "Guardian is already linked to the student"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/linked-student [post]
+func (h *GuardianController) LinkStudent(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	var req LinkStudentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	guardian, err := h.userService.GetUserByID(scope, uint(id))
+	if err != nil {
+		h.handleGuardianError(c, err, uint(id), req.StudentID, "Failed to retrieve user: ")
+		return
+	}
+
+	// ADMIN can only link guardians of their school, the student must belong to the same school as the guardian
+	if !middlewares.Can(claims, "guardian:link", guardian) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	link, err := h.guardianService.LinkStudent(scope, guardian.ID, req.StudentID, claims.UserID)
+	if err != nil {
+		h.handleGuardianError(c, err, uint(id), req.StudentID, "Failed to link student: ")
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// UnlinkStudent removes the link between a guardian and a student.
+// @Summary Unlink a guardian from a student
+// @Description Remove the access of a guardian to a student. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags Guardian
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Guardian user ID"
+// @Param student_id path int true "Student user ID"
+// @Success 204 {object} SuccessfulResponse "Guardian unlinked successfully"
+// @Failure 400 {object} ErrorResponse "Invalid user ID or user is not a guardian"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "User not found or guardian not linked to the student"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/linked-student/{student_id} [delete]
+func (h *GuardianController) UnlinkStudent(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+	studentID, err := strconv.ParseUint(c.Param("student_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid student ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	guardian, err := h.userService.GetUserByID(scope, uint(id))
+	if err != nil {
+		h.handleGuardianError(c, err, uint(id), uint(studentID), "Failed to retrieve user: ")
+		return
+	}
+
+	// ADMIN can only unlink guardians of their school
+	if !middlewares.Can(claims, "guardian:link", guardian) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	if err := h.guardianService.UnlinkStudent(scope, guardian.ID, uint(studentID)); err != nil {
+		h.handleGuardianError(c, err, uint(id), uint(studentID), "Failed to unlink student: ")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"sama/sama-backend-2025/src/middlewares"
 	"sama/sama-backend-2025/src/services"
@@ -23,7 +25,7 @@ func NewImageController(imageService *services.ImageService) *ImageController {
 
 // UploadRequest represents the request body for an image upload.
 type UploadRequest struct {
-	FileExtension string `json:"file_extension" binding:"required,oneof=jpg jpeg png gif webp" example:"png"`
+	FileExtension string `json:"file_extension" binding:"required,alphanum" example:"png"`
 }
 
 // UploadResponse represents the response for a successful upload request.
@@ -46,7 +48,7 @@ type DownloadRequest struct {
 // @Produce json
 // @Param upload body UploadRequest true "File extension of the image to be uploaded"
 // @Success 200 {object} UploadResponse "Presigned URL and form data for upload"
-// @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
+// @Failure 400 {object} ErrorResponse "Invalid request payload, validation error or file type not allowed by the school"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /images/upload-url [post]
@@ -65,8 +67,12 @@ func (h *ImageController) RequestUploadPresignedURL(c *gin.Context) {
 		return
 	}
 
-	presignedPostRequest, err := h.imageService.RequestUploadPresignedURL(c.Request.Context(), userID, req.FileExtension)
+	presignedPostRequest, err := h.imageService.RequestUploadPresignedURL(c.Request.Context(), userID, claims.SchoolID, req.FileExtension)
 	if err != nil {
+		if err.Error() == fmt.Sprintf("file type %s is not allowed", strings.ToLower(req.FileExtension)) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to get presigned URL: " + err.Error()})
 		return
 	}
@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvitationController manages HTTP requests for invitation codes.
+type InvitationController struct {
+	invitationService *services.InvitationService
+}
+
+// NewInvitationController creates a new InvitationController.
+func NewInvitationController(invitationService *services.InvitationService) *InvitationController {
+	return &InvitationController{
+		invitationService: invitationService,
+	}
+}
+
+// CreateInvitationCodeRequest represents the request body for generating an invitation code.
+type CreateInvitationCodeRequest struct {
+	Role      string     `json:"role" binding:"required,oneof=STD TCH ADMIN GRD" example:"STD"`
+	Classroom *string    `json:"classroom,omitempty" example:"1/1"`                   // Users registering with the code are put in this classroom
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2025-08-31T23:59:59Z"` // The code never expires when omitted
+	MaxUses   int        `json:"max_uses" binding:"gte=0" example:"40"`               // 0 means unlimited
+}
+
+// EmailInvitationCodeRequest represents the request body for sending an invitation code by email.
+type EmailInvitationCodeRequest struct {
+	Emails   []string `json:"emails" binding:"required,min=1,max=100,dive,email" example:"new.student@example.com"`
+	Language string   `json:"language,omitempty" binding:"omitempty,oneof=th en" example:"th"` // Defaults to the default language of the school
+}
+
+// CreateInvitationCode handles generating an invitation code for a school.
+// @Summary Generate an invitation code
+// @Description Generate a code that lets users register into the school with the given role (and classroom, if set). Requires ADMIN (for their school) or Sama Crew role.
+// @Tags Invitation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "School ID"
+// @Param invitation body CreateInvitationCodeRequest true "Invitation code details"
+// @Success 201 {object} models.InvitationCode "Invitation code generated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid school ID, request payload or validation error"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions, not authorized for this school, or role not allowed by the school)"
+// @Failure 404 {object} ErrorResponse "School not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/invitation [post]
+func (h *InvitationController) CreateInvitationCode(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	// Authorization:
+	// SAMA can generate codes for any school.
+	// ADMIN can only generate codes for their own school.
+	if !middlewares.Can(claims, "invitation:create", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only generate invitation codes for their own school"})
+		return
+	}
+
+	var req CreateInvitationCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	invitation := &models.InvitationCode{
+		SchoolID:    uint(id),
+		Role:        req.Role,
+		Classroom:   req.Classroom,
+		ExpiresAt:   req.ExpiresAt,
+		MaxUses:     req.MaxUses,
+		CreatedByID: claims.UserID,
+	}
+
+	if err := h.invitationService.CreateInvitationCode(middlewares.GetTenantScopeFromContext(c), invitation); err != nil {
+		switch {
+		case err.Error() == fmt.Sprintf("school with ID %d not found", id):
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+		case err.Error() == fmt.Sprintf("registration as %s is not allowed in this school", req.Role):
+			c.JSON(http.StatusForbidden, ErrorResponse{Message: err.Error()})
+		case strings.HasPrefix(err.Error(), "validation failed"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to generate invitation code: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// GetInvitationCodesBySchoolID handles retrieving the invitation codes of a school.
+// @Summary Get invitation codes by school ID
+// @Description Retrieve the invitation codes of a school with their usage, newest first. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags Invitation
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "School ID"
+// @Param limit query int false "Limit for pagination" default(10)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} PaginateInvitationCodesResponse "List of invitation codes retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid school ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or not authorized for this school)"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/invitation [get]
+func (h *InvitationController) GetInvitationCodesBySchoolID(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	if !middlewares.Can(claims, "invitation:list", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only view invitation codes of their own school"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	invitations, count, err := h.invitationService.GetInvitationCodesBySchoolID(middlewares.GetTenantScopeFromContext(c), uint(id), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve invitation codes: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginateInvitationCodesResponse{
+		InvitationCodes: invitations,
+		Limit:           limit,
+		Offset:          offset,
+		Total:           count,
+	})
+}
+
+// GetInvitationCodeByID handles retrieving an invitation code with its redemptions.
+// @Summary Get invitation code by ID
+// @Description Retrieve an invitation code with the users who registered with it. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags Invitation
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Invitation code ID"
+// @Success 200 {object} models.InvitationCode "Invitation code retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid invitation code ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "Invitation code not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /invitation/{id} [get]
+func (h *InvitationController) GetInvitationCodeByID(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid invitation code ID"})
+		return
+	}
+
+	invitation, err := h.invitationService.GetInvitationCodeByID(middlewares.GetTenantScopeFromContext(c), uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("invitation code with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve invitation code: " + err.Error()})
+		return
+	}
+
+	if !middlewares.Can(claims, "invitation:read", invitation) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation)
+}
+
+// RevokeInvitationCode handles revoking an invitation code.
+// @Summary Revoke an invitation code
+// @Description Stop an invitation code from being used to register. Users who already registered with it are kept. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags Invitation
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Invitation code ID"
+// @Success 200 {object} SuccessfulResponse "Invitation code revoked successfully"
+// @Failure 400 {object} ErrorResponse "Invalid invitation code ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "Invitation code not found or already revoked"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /invitation/{id}/revoke [patch]
+func (h *InvitationController) RevokeInvitationCode(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid invitation code ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	invitation, err := h.invitationService.GetInvitationCodeByID(scope, uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("invitation code with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve invitation code for revocation: " + err.Error()})
+		return
+	}
+
+	if !middlewares.Can(claims, "invitation:revoke", invitation) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitationCode(scope, uint(id)); err != nil {
+		if err.Error() == fmt.Sprintf("invitation code with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to revoke invitation code: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessfulResponse{Message: "Invitation code revoked successfully"})
+}
+
+// EmailInvitationCode handles sending an invitation code by email.
+// @Summary Email an invitation code
+// @Description Send an invitation code to up to 100 email addresses, each recipient receiving their own email. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags Invitation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Invitation code ID"
+// @Param invitation body EmailInvitationCodeRequest true "Recipients of the invitation"
+// @Success 200 {object} SuccessfulResponse "Invitation code sent successfully"
+// @Failure 400 {object} ErrorResponse "Invalid invitation code ID, request payload, or code no longer usable"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "Invitation code not found"
+// @Failure 500 {object} ErrorResponse "Internal server error or some emails could not be sent"
+// @Router /invitation/{id}/email [post]
+func (h *InvitationController) EmailInvitationCode(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid invitation code ID"})
+		return
+	}
+
+	var req EmailInvitationCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	invitation, err := h.invitationService.GetInvitationCodeByID(scope, uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("invitation code with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve invitation code: " + err.Error()})
+		return
+	}
+
+	if !middlewares.Can(claims, "invitation:email", invitation) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	if err := h.invitationService.EmailInvitationCode(c.Request.Context(), scope, uint(id), req.Emails, req.Language); err != nil {
+		if err.Error() == "invitation code is no longer usable" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to send invitation code: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessfulResponse{Message: "Invitation code sent successfully"})
+}
@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationController manages HTTP requests for the notifications of the current user.
+type NotificationController struct {
+	notificationService *services.NotificationService
+}
+
+// NewNotificationController creates a new NotificationController.
+func NewNotificationController(notificationService *services.NotificationService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+	}
+}
+
+// UnreadNotificationCountResponse represents the number of notifications the current user hasn't read.
+type UnreadNotificationCountResponse struct {
+	Count int `json:"count" example:"3"`
+}
+
+// MarkAllNotificationsReadResponse represents the number of notifications marked as read.
+type MarkAllNotificationsReadResponse struct {
+	Count int `json:"count" example:"3"`
+}
+
+// NotificationPreferenceRequest defines whether one event is also sent by email.
+type NotificationPreferenceRequest struct {
+	Type  string `json:"type" binding:"required,oneof=RECORD_SENT RECORD_APPROVED RECORD_REJECTED DEADLINE_APPROACHING ACTIVITY_PUBLISHED" example:"RECORD_REJECTED"`
+	Email *bool  `json:"email" binding:"required" example:"true"`
+}
+
+// UpdateNotificationPreferencesRequest defines the request body for updating notification preferences.
+// Events left out keep their current preference.
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceRequest `json:"preferences" binding:"required,min=1,dive"`
+}
+
+// GetMyNotifications retrieves the notifications of the current user.
+// @Summary Get my notifications
+// @Description Retrieve the notifications of the current user, newest first. Notifications are kept for the retention period, read or not.
+// @Tags Notification
+// @Security BearerAuth
+// @Produce json
+// @Param unread query bool false "Only return notifications that weren't read"
+// @Param limit query int false "Limit for pagination" default(10)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} PaginateNotificationsResponse "Notifications retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /notification [get]
+func (h *NotificationController) GetMyNotifications(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	unreadOnly, _ := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	notifications, total, err := h.notificationService.GetNotifications(claims.UserID, unreadOnly, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve notifications: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginateNotificationsResponse{
+		Notifications: notifications,
+		Limit:         limit,
+		Offset:        offset,
+		Total:         total,
+	})
+}
+
+// GetUnreadNotificationCount counts the notifications the current user hasn't read.
+// @Summary Get my unread notification count
+// @Description Count the notifications of the current user that weren't read, for the badge of the notification center.
+// @Tags Notification
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} UnreadNotificationCountResponse "Unread notifications counted successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /notification/unread-count [get]
+func (h *NotificationController) GetUnreadNotificationCount(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	count, err := h.notificationService.CountUnreadNotifications(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to count unread notifications: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, UnreadNotificationCountResponse{Count: count})
+}
+
+// MarkNotificationRead marks a notification of the current user as read.
+// @Summary Mark a notification as read
+// @Description Mark a notification of the current user as read. Notifications already read keep the time they were first read.
+// @Tags Notification
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Success 200 {object} models.Notification "Notification marked as read successfully"
+// @Failure 400 {object} ErrorResponse "Invalid notification ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Notification not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /notification/{id}/read [patch]
+func (h *NotificationController) MarkNotificationRead(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid notification ID"})
+		return
+	}
+
+	notification, err := h.notificationService.MarkNotificationRead(claims.UserID, uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("notification with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to mark notification as read: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead marks every notification of the current user as read.
+// @Summary Mark all notifications as read
+// @Description Mark every unread notification of the current user as read.
+// @Tags Notification
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} MarkAllNotificationsReadResponse "Notifications marked as read successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /notification/read-all [post]
+func (h *NotificationController) MarkAllNotificationsRead(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	count, err := h.notificationService.MarkAllNotificationsRead(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to mark notifications as read: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, MarkAllNotificationsReadResponse{Count: count})
+}
+
+// GetMyNotificationPreferences retrieves which events the current user also receives by email.
+// @Summary Get my notification preferences
+// @Description Retrieve whether the current user receives every event by email. Rejected records and approaching deadlines are sent by email unless turned off.
+// @Tags Notification
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.NotificationPreference "Notification preferences retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/me/notification-preference [get]
+func (h *NotificationController) GetMyNotificationPreferences(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	preferences, err := h.notificationService.GetNotificationPreferences(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve notification preferences: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdateMyNotificationPreferences sets which events the current user also receives by email.
+// @Summary Update my notification preferences
+// @Description Set whether the current user receives the given events by email. Events left out keep their current preference.
+// @Tags Notification
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param preferences body UpdateNotificationPreferencesRequest true "Notification preferences"
+// @Success 200 {array} models.NotificationPreference "Notification preferences updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/me/notification-preference [put]
+func (h *NotificationController) UpdateMyNotificationPreferences(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(req.Preferences))
+	for _, preference := range req.Preferences {
+		preferences = append(preferences, models.NotificationPreference{Type: preference.Type, Email: *preference.Email})
+	}
+
+	updated, err := h.notificationService.UpdateNotificationPreferences(claims.UserID, preferences)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid notification type") {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to update notification preferences: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
@@ -67,7 +67,7 @@ type UnsendRecordRequest struct {
 // @Success 201 {object} models.Record "Record created successfully"
 // @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
-// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or student not active)"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /record [post]
 func (c *RecordController) CreateRecord(ctx *gin.Context) {
@@ -77,13 +77,6 @@ func (c *RecordController) CreateRecord(ctx *gin.Context) {
 		return
 	}
 
-	// // Authorization: Example - only teachers can create records for now.
-	// // You'll need to refine this based on your business logic (e.g., students creating their own records, etc.)
-	// if claims.Role != "TCH" && claims.Role != "ADMIN" && claims.Role != "SAMA" {
-	// 	ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions to create records"})
-	// 	return
-	// }
-
 	var req CreateRecordRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
@@ -99,7 +92,15 @@ func (c *RecordController) CreateRecord(ctx *gin.Context) {
 	}
 
 	// Pass the authenticated user's ID for status log
-	if err := c.recordService.CreateRecord(record, claims.SchoolID, claims.UserID); err != nil {
+	if err := c.recordService.CreateRecord(middlewares.GetTenantScopeFromContext(ctx), record, claims.SchoolID, claims.UserID); err != nil {
+		if err.Error() == "activity is no longer accepting records" {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
+		if err.Error() == "only active students can create records" {
+			ctx.JSON(http.StatusForbidden, ErrorResponse{Message: err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to create record: " + err.Error()})
 		return
 	}
@@ -122,7 +123,7 @@ func (c *RecordController) CreateRecord(ctx *gin.Context) {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /record/{id} [get]
 func (c *RecordController) GetRecordByID(ctx *gin.Context) {
-	_, ok := middlewares.GetUserClaimsFromContext(ctx)
+	claims, ok := middlewares.GetUserClaimsFromContext(ctx)
 	if !ok {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
 		return
@@ -134,7 +135,7 @@ func (c *RecordController) GetRecordByID(ctx *gin.Context) {
 		return
 	}
 
-	record, err := c.recordService.GetRecordByID(uint(id))
+	record, err := c.recordService.GetRecordByID(middlewares.GetTenantScopeFromContext(ctx), uint(id))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("record with ID %d not found", id) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -144,28 +145,10 @@ func (c *RecordController) GetRecordByID(ctx *gin.Context) {
 		return
 	}
 
-	// Authorization logic for viewing a record:
-	// 1. Sama Crew can view any record.
-	// 2. Student can view their own records.
-	// 3. Teacher can view records where they are the assigned teacher, or records for students in their school.
-	// 4. Admin can view records for their school.
-	// if claims.Role != "SAMA" {
-	// 	isAuthorized := false
-	// 	// if claims.Role == "STD" && claims.UserID == record.StudentID {
-	// 	// 	isAuthorized = true
-	// 	// } else if claims.Role == "TCH" && (claims.UserID == record.TeacherID || claims.SchoolID == record.SchoolID) {
-	// 	// 	// Teacher can see records they are assigned to, or records for students in their school
-	// 	// 	isAuthorized = true
-	// 	// }
-	// 	// } else if claims.Role == "ADMIN" && claims.SchoolID == record.SchoolID {
-	// 	// 	isAuthorized = true
-	// 	// }
-
-	// 	if !isAuthorized {
-	// 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to view this record."})
-	// 		return
-	// 	}
-	// }
+	if !middlewares.Can(claims, "record:read", record) {
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to view this record."})
+		return
+	}
 
 	ctx.JSON(http.StatusOK, record)
 }
@@ -223,6 +206,7 @@ func (c *RecordController) GetAllRecords(ctx *gin.Context) {
 	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
 
 	// Apply authorization filtering
+	scope := middlewares.GetTenantScopeFromContext(ctx)
 	switch claims.Role {
 	case "STD":
 		// Student can only see their own records
@@ -253,14 +237,24 @@ func (c *RecordController) GetAllRecords(ctx *gin.Context) {
 			return
 		}
 		filterSchoolID = claims.SchoolID // Always filter by admin's school
+	case "GRD":
+		// Guardian can only see the records of a student they are linked to
+		if filterStudentID == 0 || !middlewares.Can(claims, "user:read", middlewares.UserResource(filterStudentID)) {
+			ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Guardians can only view records of their linked students."})
+			return
+		}
 	case "SAMA":
-		// Sama Crew can see all records, no additional filtering needed based on their claims
+		// Sama Crew can see all records, optionally narrowed down to a single school
+		if filterSchoolID != 0 {
+			scope = scope.ForSchool(filterSchoolID)
+		}
 	default:
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions to list records"})
 		return
 	}
 
 	records, count, err := c.recordService.GetAllRecords(
+		scope,
 		filterStudentID, filterTeacherID, filterActivityID,
 		filterStatus,
 		limit, offset,
@@ -292,7 +286,7 @@ func (c *RecordController) GetAllRecords(ctx *gin.Context) {
 // @Success 200 {object} models.Record "Record updated successfully"
 // @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
-// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or not authorized for this record)"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions, not authorized for this record or not editable under the school's record edit policy)"
 // @Failure 404 {object} ErrorResponse "Record not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /record/{id} [put]
@@ -316,7 +310,8 @@ func (c *RecordController) UpdateRecord(ctx *gin.Context) {
 	}
 
 	// Fetch existing record for authorization and update
-	existingRecord, err := c.recordService.GetRecordByID(uint(recordID))
+	scope := middlewares.GetTenantScopeFromContext(ctx)
+	existingRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("record with ID %d not found", recordID) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -326,20 +321,8 @@ func (c *RecordController) UpdateRecord(ctx *gin.Context) {
 		return
 	}
 
-	// Authorization logic for updating a record:
-	// Example: Student can only update their own records if status is CREATED.
-	// Teacher can update records for students in their school if status is CREATED/SENDED.
-	// Admin/SAMA can update any record.
-	isAuthorized := true
-	// if claims.Role == "SAMA" || claims.Role == "ADMIN" { // Admins/Sama Crew can edit any record
-	// 	isAuthorized = true
-	// } else if claims.Role == "STD" && claims.UserID == existingRecord.StudentID && existingRecord.Status == "CREATED" {
-	// 	isAuthorized = true
-	// } else if claims.Role == "TCH" && claims.SchoolID == existingRecord.SchoolID && (existingRecord.Status == "CREATED" || existingRecord.Status == "SENDED") {
-	// 	isAuthorized = true
-	// }
-
-	if !isAuthorized {
+	// The statuses a student can still edit depend on the school settings and are checked by the service
+	if !middlewares.Can(claims, "record:update", existingRecord) {
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to update this record."})
 		return
 	}
@@ -349,7 +332,11 @@ func (c *RecordController) UpdateRecord(ctx *gin.Context) {
 	existingRecord.Amount = req.Amount
 
 	// Pass the authenticated user's ID for status log
-	if err := c.recordService.UpdateRecord(existingRecord, claims.UserID); err != nil {
+	if err := c.recordService.UpdateRecord(scope, existingRecord, claims.UserID); err != nil {
+		if err.Error() == "record can only be edited by its owner" || err.Error() == fmt.Sprintf("record with status %s cannot be edited", existingRecord.Status) {
+			ctx.JSON(http.StatusForbidden, ErrorResponse{Message: err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to update record: " + err.Error()})
 		return
 	}
@@ -372,7 +359,7 @@ func (c *RecordController) UpdateRecord(ctx *gin.Context) {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /record/{id} [delete]
 func (c *RecordController) DeleteRecord(ctx *gin.Context) {
-	_, ok := middlewares.GetUserClaimsFromContext(ctx)
+	claims, ok := middlewares.GetUserClaimsFromContext(ctx)
 	if !ok {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
 		return
@@ -385,7 +372,8 @@ func (c *RecordController) DeleteRecord(ctx *gin.Context) {
 	}
 
 	// Fetch existing record for authorization
-	_, err = c.recordService.GetRecordByID(uint(id))
+	scope := middlewares.GetTenantScopeFromContext(ctx)
+	recordToDelete, err := c.recordService.GetRecordByID(scope, uint(id))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("record with ID %d not found", id) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -395,26 +383,12 @@ func (c *RecordController) DeleteRecord(ctx *gin.Context) {
 		return
 	}
 
-	// Authorization:
-	// SAMA can delete any record.
-	// ADMIN can delete records in their school.
-	// TCH can delete records they are assigned to or for students in their school.
-	// Students typically cannot delete records.
-	isAuthorized := true
-	// if claims.Role == "SAMA" {
-	// 	isAuthorized = true
-	// } else if claims.Role == "TCH" && (claims.UserID == recordToDelete.TeacherID || claims.SchoolID == recordToDelete.SchoolID) {
-	// 	isAuthorized = true
-	// } else if claims.Role == "ADMIN" && claims.SchoolID == recordToDelete.SchoolID {
-	// 	isAuthorized = true
-	// }
-
-	if !isAuthorized {
+	if !middlewares.Can(claims, "record:delete", recordToDelete) {
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to delete this record."})
 		return
 	}
 
-	if err := c.recordService.DeleteRecord(uint(id)); err != nil {
+	if err := c.recordService.DeleteRecord(scope, uint(id)); err != nil {
 		if err.Error() == fmt.Sprintf("record with ID %d not found for deletion", id) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
 			return
@@ -438,7 +412,7 @@ func (c *RecordController) DeleteRecord(ctx *gin.Context) {
 // @Success 200 {object} models.Record "Record sent successfully"
 // @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
-// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or record not in creatable status)"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions, record not in creatable status or unverified email of the student)"
 // @Failure 404 {object} ErrorResponse "Record not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /record/{id}/send [patch]
@@ -462,7 +436,8 @@ func (c *RecordController) SendRecord(ctx *gin.Context) {
 	}
 
 	// Fetch existing record for authorization and status check
-	existingRecord, err := c.recordService.GetRecordByID(uint(recordID))
+	scope := middlewares.GetTenantScopeFromContext(ctx)
+	existingRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("record with ID %d not found", recordID) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -472,33 +447,33 @@ func (c *RecordController) SendRecord(ctx *gin.Context) {
 		return
 	}
 
-	// Authorization & Status Check:
-	// Only the student who owns the record, if status is 'CREATED', can send it.
-	// Or ADMIN/SAMA can send any record.
-	isAuthorized := false
-	if claims.Role == "SAMA" || claims.Role == "ADMIN" {
-		isAuthorized = true
-	} else if claims.Role == "STD" && claims.UserID == existingRecord.StudentID && existingRecord.Status == "CREATED" {
-		isAuthorized = true
-	}
-
-	if !isAuthorized {
+	if !middlewares.Can(claims, "record:send", existingRecord) {
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to send this record, or record is not in 'CREATED' status."})
 		return
 	}
 
 	// Call service method to change status to SENDED
-	if err := c.recordService.SendRecord(uint(recordID), req.TeacherID, claims.UserID); err != nil {
+	if err := c.recordService.SendRecord(scope, uint(recordID), req.TeacherID, claims.UserID); err != nil {
 		if err.Error() == fmt.Sprintf("record %d cannot be sent: invalid status", recordID) { // Example of a specific service error
 			ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
 			return
 		}
+		switch err.Error() {
+		case "activity is no longer accepting records",
+			"record can only be sent to the owner of the activity",
+			"record can only be sent to a bookmarked teacher":
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		case "email must be verified before sending records":
+			ctx.JSON(http.StatusForbidden, ErrorResponse{Message: err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to send record: " + err.Error()})
 		return
 	}
 
 	// Retrieve the updated record to return
-	updatedRecord, err := c.recordService.GetRecordByID(uint(recordID))
+	updatedRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve updated record: " + err.Error()})
 		return
@@ -543,7 +518,8 @@ func (c *RecordController) ApproveRecord(ctx *gin.Context) {
 	}
 
 	// Fetch existing record for authorization and status check
-	existingRecord, err := c.recordService.GetRecordByID(uint(recordID))
+	scope := middlewares.GetTenantScopeFromContext(ctx)
+	existingRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("record with ID %d not found", recordID) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -553,23 +529,13 @@ func (c *RecordController) ApproveRecord(ctx *gin.Context) {
 		return
 	}
 
-	// Authorization & Status Check:
-	// Only the assigned teacher or admin/SAMA can approve.
-	// Record must be in 'SENDED' status.
-	isAuthorized := false
-	if claims.Role == "SAMA" || claims.Role == "ADMIN" {
-		isAuthorized = true
-	} else if claims.Role == "TCH" && claims.UserID == *existingRecord.TeacherID && existingRecord.Status == "SENDED" {
-		isAuthorized = true
-	}
-
-	if !isAuthorized {
+	if !middlewares.Can(claims, "record:approve", existingRecord) {
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to approve this record, or record is not in 'SENDED' status."})
 		return
 	}
 
 	// Call service method to change status to APPROVED
-	if err := c.recordService.ApproveRecord(uint(recordID), req.Advice, claims.UserID); err != nil {
+	if err := c.recordService.ApproveRecord(scope, uint(recordID), req.Advice, claims.UserID); err != nil {
 		if err.Error() == fmt.Sprintf("record %d cannot be approved: invalid status", recordID) { // Example of a specific service error
 			ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
 			return
@@ -579,7 +545,7 @@ func (c *RecordController) ApproveRecord(ctx *gin.Context) {
 	}
 
 	// Retrieve the updated record to return
-	updatedRecord, err := c.recordService.GetRecordByID(uint(recordID))
+	updatedRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve updated record: " + err.Error()})
 		return
@@ -624,7 +590,8 @@ func (c *RecordController) RejectRecord(ctx *gin.Context) {
 	}
 
 	// Fetch existing record for authorization and status check
-	existingRecord, err := c.recordService.GetRecordByID(uint(recordID))
+	scope := middlewares.GetTenantScopeFromContext(ctx)
+	existingRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("record with ID %d not found", recordID) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -634,23 +601,13 @@ func (c *RecordController) RejectRecord(ctx *gin.Context) {
 		return
 	}
 
-	// Authorization & Status Check:
-	// Only the assigned teacher or admin/SAMA can reject.
-	// Record must be in 'SENDED' status.
-	isAuthorized := false
-	if claims.Role == "SAMA" || claims.Role == "ADMIN" {
-		isAuthorized = true
-	} else if claims.Role == "TCH" && claims.UserID == *existingRecord.TeacherID && existingRecord.Status == "SENDED" {
-		isAuthorized = true
-	}
-
-	if !isAuthorized {
+	if !middlewares.Can(claims, "record:reject", existingRecord) {
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to reject this record, or record is not in 'SENDED' status."})
 		return
 	}
 
 	// Call service method to change status to REJECTED
-	if err := c.recordService.RejectRecord(uint(recordID), req.Advice, claims.UserID); err != nil {
+	if err := c.recordService.RejectRecord(scope, uint(recordID), req.Advice, claims.UserID); err != nil {
 		if err.Error() == fmt.Sprintf("record %d cannot be rejected: invalid status", recordID) { // Example of a specific service error
 			ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
 			return
@@ -660,7 +617,7 @@ func (c *RecordController) RejectRecord(ctx *gin.Context) {
 	}
 
 	// Retrieve the updated record to return
-	updatedRecord, err := c.recordService.GetRecordByID(uint(recordID))
+	updatedRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve updated record: " + err.Error()})
 		return
@@ -712,7 +669,8 @@ func (c *RecordController) UnsendRecord(ctx *gin.Context) {
 	// }
 
 	// Fetch existing record for authorization and status check
-	existingRecord, err := c.recordService.GetRecordByID(uint(recordID))
+	scope := middlewares.GetTenantScopeFromContext(ctx)
+	existingRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("record with ID %d not found", recordID) {
 			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -722,22 +680,13 @@ func (c *RecordController) UnsendRecord(ctx *gin.Context) {
 		return
 	}
 
-	// Authorization & Status Check:
-	// Only the student who sent the record (if status is 'SENDED'), or ADMIN/SAMA can unsend it.
-	isAuthorized := false
-	if claims.Role == "SAMA" || claims.Role == "ADMIN" {
-		isAuthorized = true
-	} else if claims.Role == "STD" && claims.UserID == existingRecord.StudentID && existingRecord.Status == "SENDED" {
-		isAuthorized = true
-	}
-
-	if !isAuthorized {
+	if !middlewares.Can(claims, "record:unsend", existingRecord) {
 		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to unsend this record, or record is not in 'SENDED' status."})
 		return
 	}
 
 	// // Call service method to change status to CREATED
-	if err := c.recordService.UnsendRecord(uint(recordID), claims.UserID); err != nil {
+	if err := c.recordService.UnsendRecord(scope, uint(recordID), claims.UserID); err != nil {
 		if err.Error() == fmt.Sprintf("record %d cannot be unsent: invalid status", recordID) { // Example of a specific service error
 			ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
 			return
@@ -747,7 +696,72 @@ func (c *RecordController) UnsendRecord(ctx *gin.Context) {
 	}
 
 	// Retrieve the updated record to return
-	updatedRecord, err := c.recordService.GetRecordByID(uint(recordID))
+	updatedRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve updated record: " + err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updatedRecord)
+}
+
+// AcknowledgeRecord handles a guardian confirming a record.
+// @Summary Acknowledge a record
+// @Description Confirm a record as a guardian of its student. Only records of activities requiring parental confirmation can be acknowledged, and editing the record clears the acknowledgement.
+// @Tags Record
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Record ID"
+// @Success 200 {object} models.Record "Record acknowledged successfully"
+// @Failure 400 {object} ErrorResponse "Record cannot be acknowledged"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not a guardian of the student)"
+// @Failure 404 {object} ErrorResponse "Record not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /record/{id}/acknowledge [patch]
+func (c *RecordController) AcknowledgeRecord(ctx *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	recordID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid record ID in path"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(ctx)
+	existingRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("record with ID %d not found", recordID) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve record for acknowledgement: " + err.Error()})
+		return
+	}
+
+	if !middlewares.Can(claims, "record:acknowledge", existingRecord) {
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Only a guardian of the student can acknowledge this record."})
+		return
+	}
+
+	if err := c.recordService.AcknowledgeRecord(scope, uint(recordID), claims.UserID); err != nil {
+		switch err.Error() {
+		case "activity does not require guardian confirmation",
+			"record is already acknowledged",
+			fmt.Sprintf("record with status %s cannot be acknowledged", existingRecord.Status):
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to acknowledge record: " + err.Error()})
+		return
+	}
+
+	// Retrieve the updated record to return
+	updatedRecord, err := c.recordService.GetRecordByID(scope, uint(recordID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve updated record: " + err.Error()})
 		return
@@ -3,8 +3,8 @@ package controllers
 import (
 	"fmt"
 	"net/http"
-	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"sama/sama-backend-2025/src/middlewares"
@@ -18,24 +18,61 @@ import (
 
 // SchoolController manages HTTP requests for schools.
 type SchoolController struct {
-	schoolService *services.SchoolService
-	userService   *services.UserService
-	validate      *validator.Validate
+	schoolService       *services.SchoolService
+	userService         *services.UserService
+	studentGroupService *services.StudentGroupService
+	validate            *validator.Validate
 }
 
 // NewSchoolController creates a new SchoolController.
 func NewSchoolController(
 	schoolService *services.SchoolService,
 	userService *services.UserService,
+	studentGroupService *services.StudentGroupService,
 	validate *validator.Validate,
 ) *SchoolController {
 	return &SchoolController{
-		schoolService: schoolService,
-		userService:   userService,
-		validate:      validate,
+		schoolService:       schoolService,
+		userService:         userService,
+		studentGroupService: studentGroupService,
+		validate:            validate,
 	}
 }
 
+// studentGroupFilter reads the group_id query used to filter the students of a school, 0 when absent.
+// It writes the error response and returns false when the group can't be used by the caller.
+func (h *SchoolController) studentGroupFilter(c *gin.Context, claims *utils.Claims, schoolID uint) (uint, bool) {
+	if c.Query("group_id") == "" {
+		return 0, true
+	}
+
+	groupID, err := strconv.ParseUint(c.Query("group_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid student group ID"})
+		return 0, false
+	}
+
+	group, err := h.studentGroupService.GetStudentGroupByID(middlewares.GetTenantScopeFromContext(c), uint(groupID))
+	if err == nil && group.SchoolID != schoolID {
+		err = fmt.Errorf("student group with ID %d not found", groupID)
+	}
+	if err != nil {
+		if err.Error() == fmt.Sprintf("student group with ID %d not found", groupID) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return 0, false
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve student group: " + err.Error()})
+		return 0, false
+	}
+
+	// Teachers can only filter by their own groups and the shared ones
+	if !middlewares.Can(claims, "group:read", group) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only use your own or shared student groups"})
+		return 0, false
+	}
+	return group.ID, true
+}
+
 // CreateSchoolRequest represents the request body for creating a new school.
 type CreateSchoolRequest struct {
 	ThaiName                string    `json:"thai_name" binding:"required" example:"โรงเรียนสามัคคีวิทยา"`
@@ -72,7 +109,7 @@ type SchoolStatisticResponse struct {
 
 // CreateSchool handles the creation of a new school.
 // @Summary Create a new school
-// @Description Create a new school record. Requires ADMIN or Sama Crew role.
+// @Description Create a new school record. Requires Sama Crew role.
 // @Tags School
 // @Security BearerAuth
 // @Accept json
@@ -86,17 +123,6 @@ type SchoolStatisticResponse struct {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /school [post]
 func (h *SchoolController) CreateSchool(c *gin.Context) {
-	// claims, ok := middlewares.GetUserClaimsFromContext(c)
-	// if !ok {
-	// 	c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
-	// 	return
-	// }
-	// // Authorization: Only ADMIN or SAMA can create schools
-	// if claims.Role != "ADMIN" && claims.Role != "SAMA" {
-	// 	c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
-	// 	return
-	// }
-
 	var req CreateSchoolRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
@@ -170,7 +196,7 @@ func (h *SchoolController) GetSchoolByID(c *gin.Context) {
 	// Authorization:
 	// SAMA can access any school.
 	// ADMIN/TCH/STD can access their own school's data.
-	if claims.Role != "SAMA" && claims.SchoolID != uint(id) {
+	if !middlewares.Can(claims, "school:read", middlewares.SchoolResource(uint(id))) {
 		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to access this school's data"})
 		return
 	}
@@ -202,17 +228,6 @@ func (h *SchoolController) GetSchoolByID(c *gin.Context) {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /school [get]
 func (h *SchoolController) GetAllSchools(c *gin.Context) {
-	// claims, ok := middlewares.GetUserClaimsFromContext(c)
-	// if !ok {
-	// 	c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
-	// 	return
-	// }
-	// // Authorization: Only SAMA can get all schools
-	// if claims.Role != "SAMA" {
-	// 	c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
-	// 	return
-	// }
-
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
@@ -265,11 +280,7 @@ func (h *SchoolController) UpdateSchool(c *gin.Context) {
 	// Authorization:
 	// SAMA can update any school.
 	// ADMIN can only update their own school.
-	if claims.Role != "SAMA" && claims.Role != "ADMIN" {
-		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
-		return
-	}
-	if claims.Role == "ADMIN" && claims.SchoolID != uint(id) {
+	if !middlewares.Can(claims, "school:update", middlewares.SchoolResource(uint(id))) {
 		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only update their own school"})
 		return
 	}
@@ -316,14 +327,151 @@ func (h *SchoolController) UpdateSchool(c *gin.Context) {
 	c.JSON(http.StatusOK, schoolToUpdate)
 }
 
+// UpdateSchoolSettingsRequest represents the request body for updating the settings of a school.
+type UpdateSchoolSettingsRequest struct {
+	OTPLifetimeMinutes       int      `json:"otp_lifetime_minutes" binding:"required" example:"5"`
+	AllowedRegistrationRoles []string `json:"allowed_registration_roles" example:"STD,TCH"`
+	TeacherSelection         string   `json:"teacher_selection" binding:"required" example:"ANY"`            // ANY, BOOKMARKED or ACTIVITY_OWNER
+	RecordEditPolicy         string   `json:"record_edit_policy" binding:"required" example:"UNSENT"`        // NEVER, UNSENT or UNAPPROVED
+	GracePeriodDays          int      `json:"grace_period_days" example:"3"`                                 // Days after an activity deadline where records are still accepted
+	CompletionThreshold      uint     `json:"completion_threshold" binding:"required" example:"100"`         // Percent at which a student counts as finished
+	DefaultLanguage          string   `json:"default_language" binding:"required" example:"th"`              // th or en
+	AllowedAttachmentTypes   []string `json:"allowed_attachment_types" binding:"required" example:"jpg,png"` // File extensions allowed for uploads
+	RequireVerifiedEmail     bool     `json:"require_verified_email" example:"false"`                        // Students must verify their email before sending records
+}
+
+// GetSchoolSettings handles retrieving the settings of a school.
+// @Summary Get settings of a school
+// @Description Retrieve the policies of a school. Schools that never changed them get the default settings. Accessible by users of the school or Sama Crew.
+// @Tags School
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "School ID"
+// @Success 200 {object} models.SchoolSettings "School settings retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid school ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not authorized to access this school's data)"
+// @Failure 404 {object} ErrorResponse "School not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/settings [get]
+func (h *SchoolController) GetSchoolSettings(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	if !middlewares.Can(claims, "school:settings:read", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to access this school's data"})
+		return
+	}
+
+	settings, err := h.schoolService.GetSchoolSettings(middlewares.GetTenantScopeFromContext(c), uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("school with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve school settings: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSchoolSettings handles updating the settings of a school.
+// @Summary Update settings of a school
+// @Description Replace the policies of a school. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags School
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "School ID"
+// @Param settings body UpdateSchoolSettingsRequest true "School settings"
+// @Success 200 {object} models.SchoolSettings "School settings updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "School not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/settings [put]
+func (h *SchoolController) UpdateSchoolSettings(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	// Authorization:
+	// SAMA can update settings of any school.
+	// ADMIN can only update settings of their own school.
+	if !middlewares.Can(claims, "school:settings:write", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only update settings of their own school"})
+		return
+	}
+
+	var req UpdateSchoolSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	settings := &models.SchoolSettings{
+		SchoolID:                 uint(id),
+		OTPLifetimeMinutes:       req.OTPLifetimeMinutes,
+		AllowedRegistrationRoles: req.AllowedRegistrationRoles,
+		TeacherSelection:         req.TeacherSelection,
+		RecordEditPolicy:         req.RecordEditPolicy,
+		GracePeriodDays:          req.GracePeriodDays,
+		CompletionThreshold:      req.CompletionThreshold,
+		DefaultLanguage:          req.DefaultLanguage,
+		AllowedAttachmentTypes:   req.AllowedAttachmentTypes,
+		RequireVerifiedEmail:     req.RequireVerifiedEmail,
+	}
+
+	if err := h.schoolService.UpdateSchoolSettings(middlewares.GetTenantScopeFromContext(c), settings); err != nil {
+		if err.Error() == fmt.Sprintf("school with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "validation failed") {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to update school settings: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// SchoolArchiveResponse represents an archive of a school with download URLs of its export.
+type SchoolArchiveResponse struct {
+	Archive models.SchoolArchive `json:"archive"`
+	JSONURL string               `json:"json_url" example:"https://your-s3-bucket.s3.amazonaws.com/archives/SMK/20250728T154903Z/export.json?X-Amz-..."`
+	XLSXURL string               `json:"xlsx_url" example:"https://your-s3-bucket.s3.amazonaws.com/archives/SMK/20250728T154903Z/export.xlsx?X-Amz-..."`
+}
+
 // DeleteSchool handles deleting a school.
 // @Summary Delete a school
-// @Description Delete a school record by ID. Requires ADMIN (for their school) or Sama Crew role.
+// @Description Archive a school by ID after exporting all of its data (JSON and XLSX). The school can be restored until the retention window ends. Requires ADMIN (for their school) or Sama Crew role.
 // @Tags School
 // @Security BearerAuth
 // @Produce json
 // @Param id path int true "School ID to delete"
-// @Success 204 {object} SuccessfulResponse "School deleted successfully"
+// @Success 200 {object} models.SchoolArchive "School archived successfully"
 // @Failure 400 {object} ErrorResponse "Invalid school ID"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or not authorized for this school)"
@@ -346,25 +494,119 @@ func (h *SchoolController) DeleteSchool(c *gin.Context) {
 	// Authorization:
 	// SAMA can delete any school.
 	// ADMIN can only delete their own school.
-	if claims.Role != "SAMA" && claims.Role != "ADMIN" {
+	if !middlewares.Can(claims, "school:delete", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only delete their own school"})
+		return
+	}
+
+	archive, err := h.schoolService.DeleteSchool(c.Request.Context(), uint(id), claims.UserID)
+	if err != nil {
+		if err.Error() == fmt.Sprintf("school with ID %d not found", id) || err.Error() == fmt.Sprintf("school with ID %d not found for deletion", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to delete school: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, archive)
+}
+
+// GetSchoolArchive handles retrieving the latest archive of a school.
+// @Summary Get archive of a school
+// @Description Retrieve the latest archive of a deleted school with presigned URLs of its exported data. Requires Sama Crew role.
+// @Tags School
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "School ID"
+// @Success 200 {object} SchoolArchiveResponse "Archive with download URLs"
+// @Failure 400 {object} ErrorResponse "Invalid school ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "Archive not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/archive [get]
+func (h *SchoolController) GetSchoolArchive(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	if !middlewares.Can(claims, "school:archive", nil) {
 		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
 		return
 	}
-	if claims.Role == "ADMIN" && claims.SchoolID != uint(id) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only delete their own school"})
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
 		return
 	}
 
-	if err := h.schoolService.DeleteSchool(uint(id)); err != nil {
-		if err.Error() == fmt.Sprintf("school with ID %d not found for deletion", id) {
+	archive, jsonRequest, xlsxRequest, err := h.schoolService.GetSchoolArchive(c.Request.Context(), uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("archive of school with ID %d not found", id) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to delete school: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve school archive: " + err.Error()})
 		return
 	}
 
-	c.Status(http.StatusNoContent) // 204 No Content for successful deletion
+	c.JSON(http.StatusOK, SchoolArchiveResponse{
+		Archive: *archive,
+		JSONURL: jsonRequest.URL,
+		XLSXURL: xlsxRequest.URL,
+	})
+}
+
+// RestoreSchool handles restoring an archived school.
+// @Summary Restore an archived school
+// @Description Restore a deleted school and all of its data, as long as the retention window has not ended. Requires Sama Crew role.
+// @Tags School
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "School ID"
+// @Success 200 {object} models.SchoolArchive "School restored successfully"
+// @Failure 400 {object} ErrorResponse "Invalid school ID, school not archived or retention window ended"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "Archive not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/restore [post]
+func (h *SchoolController) RestoreSchool(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	if !middlewares.Can(claims, "school:restore", nil) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	archive, err := h.schoolService.RestoreSchool(uint(id))
+	if err != nil {
+		switch err.Error() {
+		case fmt.Sprintf("archive of school with ID %d not found", id):
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+		case "school is not archived", "retention window of this archive has ended":
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to restore school: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, archive)
 }
 
 // SemesterTransitionRequest represents the request body for semester transition operations.
@@ -399,20 +641,14 @@ func (h *SchoolController) AdvanceSemester(c *gin.Context) {
 		return
 	}
 
-	// Authorization: Only ADMINs (for their school) or SAMA can perform this
-	if claims.Role != "ADMIN" && claims.Role != "SAMA" {
-		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
-		return
-	}
-
 	var req SemesterTransitionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
 		return
 	}
 
-	// If ADMIN, ensure they are operating on their own school
-	if claims.Role == "ADMIN" && claims.SchoolID != req.SchoolID {
+	// Authorization: Only ADMINs (for their school) or SAMA can perform this
+	if !middlewares.Can(claims, "school:semester", middlewares.SchoolResource(req.SchoolID)) {
 		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only move their own school's semester"})
 		return
 	}
@@ -454,20 +690,14 @@ func (h *SchoolController) RevertSemester(c *gin.Context) {
 		return
 	}
 
-	// Authorization: Only ADMINs (for their school) or SAMA can perform this
-	if claims.Role != "ADMIN" && claims.Role != "SAMA" {
-		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
-		return
-	}
-
 	var req SemesterTransitionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
 		return
 	}
 
-	// If ADMIN, ensure they are operating on their own school
-	if claims.Role == "ADMIN" && claims.SchoolID != req.SchoolID {
+	// Authorization: Only ADMINs (for their school) or SAMA can perform this
+	if !middlewares.Can(claims, "school:semester", middlewares.SchoolResource(req.SchoolID)) {
 		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only revert their own school's semester"})
 		return
 	}
@@ -497,12 +727,15 @@ func (h *SchoolController) RevertSemester(c *gin.Context) {
 // @Param name query string false "Filtered by name"
 // @Param role query string false "Filtered by role"
 // @Param classroom query string false "Filtered by classroom"
+// @Param status query string false "Filtered by lifecycle status, ALL for every status" Enums(ACTIVE, SUSPENDED, GRADUATED, TRANSFERRED, ALL) default(ACTIVE)
+// @Param group_id query int false "Filtered by student group"
 // @Param limit query int false "Limit for pagination" default(10)
 // @Param offset query int false "Offset for pagination" default(0)
 // @Success 200 {object} PaginateUsersResponse "List of users retrieved successfully"
 // @Failure 400 {object} ErrorResponse "Invalid school ID or pagination parameters"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "Student group not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /school/{id}/user [get]
 func (h *SchoolController) GetUsersBySchoolID(c *gin.Context) {
@@ -512,21 +745,15 @@ func (h *SchoolController) GetUsersBySchoolID(c *gin.Context) {
 		return
 	}
 
-	// // Authorization: Only ADMINs (for their school) or SAMA can access this
-	// if claims.Role != "ADMIN" && claims.Role != "SAMA" {
-	// 	c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
-	// 	return
-	// }
-
 	schoolID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID: " + err.Error()})
 		return
 	}
 
-	// If ADMIN, ensure they are requesting users from their own school
-	if claims.Role == "ADMIN" && claims.SchoolID != uint(schoolID) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only view users from their own school"})
+	// Users can only list the members of their own school, except for SAMA
+	if !middlewares.Can(claims, "school:list-users", middlewares.SchoolResource(uint(schoolID))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only view users from your own school"})
 		return
 	}
 
@@ -536,7 +763,18 @@ func (h *SchoolController) GetUsersBySchoolID(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	users, count, err := h.userService.GetUsersBySchoolID(uint(schoolID), claims.UserID, name, role, classroom, limit, offset)
+	// Rosters only show active members unless another status is asked for
+	status := c.DefaultQuery("status", "ACTIVE")
+	if status == "ALL" {
+		status = ""
+	}
+
+	groupID, ok := h.studentGroupFilter(c, claims, uint(schoolID))
+	if !ok {
+		return
+	}
+
+	users, count, err := h.userService.GetUsersBySchoolID(middlewares.GetTenantScopeFromContext(c), uint(schoolID), claims.UserID, groupID, name, role, classroom, status, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve users: " + err.Error()})
 		return
@@ -566,33 +804,35 @@ func (h *SchoolController) GetUsersBySchoolID(c *gin.Context) {
 // @Param id path int true "School ID"
 // @Param classroom query string true "Classroom string to query"
 // @Param activity_id query string true "Activity id list seperate by |"
+// @Param group_id query int false "Filter by student group"
 // @Param semester query int false "Filter by Semester"
 // @Param school_year query int false "Filter by School Year"
 // @Success 200 {object} SchoolStatisticResponse "List of users statistic retrieve successfully"
 // @Failure 400 {object} ErrorResponse "Invalid school ID or Activity id"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "Student group not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /school/{id}/statistic [get]
 func (h *SchoolController) GetSchoolStatisticByID(c *gin.Context) {
-	_, ok := middlewares.GetUserClaimsFromContext(c)
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
 		return
 	}
 
-	// // Authorization: Only ADMINs (for their school) or SAMA can access this
-	// if claims.Role != "ADMIN" && claims.Role != "SAMA" {
-	// 	c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
-	// 	return
-	// }
-
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
 		return
 	}
 
+	// Authorization: Only TCH and ADMIN (for their school) or SAMA can access this
+	if !middlewares.Can(claims, "school:statistic", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to view statistics of this school"})
+		return
+	}
+
 	classroom := c.Query("classroom")
 	activityIDs, err := utils.SplitQueryUint(c.Query("activity_id"))
 	if err != nil {
@@ -602,17 +842,12 @@ func (h *SchoolController) GetSchoolStatisticByID(c *gin.Context) {
 	semester, _ := strconv.ParseUint(c.DefaultQuery("semester", "0"), 10, 64)
 	schoolYear, _ := strconv.ParseUint(c.DefaultQuery("school_year", "0"), 10, 64)
 
-	// Sort activity ids assending
-	sort.Slice(activityIDs, func(i, j int) bool {
-		return activityIDs[i] > activityIDs[j]
-	})
-	// // If ADMIN, ensure they are requesting users from their own school
-	// if claims.Role == "ADMIN" && claims.SchoolID != uint(schoolID) {
-	// 	c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only view users from their own school"})
-	// 	return
-	// }
+	groupID, ok := h.studentGroupFilter(c, claims, uint(id))
+	if !ok {
+		return
+	}
 
-	usersWithStat, finished, unfinished, err := h.schoolService.GetSchoolStatisticByID(uint(id), classroom, activityIDs, uint(semester), uint(schoolYear))
+	usersWithStat, finished, unfinished, err := h.schoolService.GetSchoolStatisticByID(middlewares.GetTenantScopeFromContext(c), uint(id), groupID, classroom, activityIDs, uint(semester), uint(schoolYear))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve statistic: " + err.Error()})
 		return
@@ -629,40 +864,42 @@ func (h *SchoolController) GetSchoolStatisticByID(c *gin.Context) {
 
 // GetSchoolStatisticFileByID get statistic file based on activity_id and classroom
 // @Summary Get statistic file by school_id
-// @Description Retrieve a presigned url of statistic file of specific school.
+// @Description Generate a statistic workbook (summary sheet and one sheet per classroom) of specific school and retrieve its presigned url.
 // @Tags School
 // @Security BearerAuth
 // @Produce json
 // @Param id path int true "School ID"
 // @Param classroom query string false "Classroom string to query"
 // @Param activity_id query string true "Activity id list seperate by |"
+// @Param group_id query int false "Filter by student group"
 // @Param semester query int false "Filter by Semester"
 // @Param school_year query int false "Filter by School Year"
 // @Success 200 {object} DownloadResponse "Presigned URL for download"
 // @Failure 400 {object} ErrorResponse "Invalid school ID or Activity id"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "School or student group not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /school/{id}/statistic-file [POST]
 func (h *SchoolController) GetSchoolStatisticFileByID(c *gin.Context) {
-	_, ok := middlewares.GetUserClaimsFromContext(c)
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
 		return
 	}
 
-	// // Authorization: Only ADMINs (for their school) or SAMA can access this
-	// if claims.Role != "ADMIN" && claims.Role != "SAMA" {
-	// 	c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
-	// 	return
-	// }
-
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
 		return
 	}
 
+	// Authorization: Only TCH and ADMIN (for their school) or SAMA can access this
+	if !middlewares.Can(claims, "school:statistic", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not authorized to view statistics of this school"})
+		return
+	}
+
 	classroom := c.Query("classroom")
 	activityIDs, err := utils.SplitQueryUint(c.Query("activity_id"))
 	if err != nil {
@@ -672,14 +909,17 @@ func (h *SchoolController) GetSchoolStatisticFileByID(c *gin.Context) {
 	semester, _ := strconv.ParseUint(c.DefaultQuery("semester", "0"), 10, 64)
 	schoolYear, _ := strconv.ParseUint(c.DefaultQuery("school_year", "0"), 10, 64)
 
-	// // If ADMIN, ensure they are requesting users from their own school
-	// if claims.Role == "ADMIN" && claims.SchoolID != uint(schoolID) {
-	// 	c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only view users from their own school"})
-	// 	return
-	// }
+	groupID, ok := h.studentGroupFilter(c, claims, uint(id))
+	if !ok {
+		return
+	}
 
-	presignedHTTPRequest, err := h.schoolService.GetSchoolStatisticFileByID(c.Request.Context(), uint(id), classroom, activityIDs, uint(semester), uint(schoolYear))
+	presignedHTTPRequest, err := h.schoolService.GetSchoolStatisticFileByID(c.Request.Context(), middlewares.GetTenantScopeFromContext(c), uint(id), groupID, classroom, activityIDs, uint(semester), uint(schoolYear))
 	if err != nil {
+		if err.Error() == fmt.Sprintf("school with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to get presigned download URL: " + err.Error()})
 		return
 	}
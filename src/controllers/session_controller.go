@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionController manages HTTP requests for login sessions.
+type SessionController struct {
+	sessionService *services.SessionService
+	userService    *services.UserService
+}
+
+// NewSessionController creates a new SessionController.
+func NewSessionController(sessionService *services.SessionService, userService *services.UserService) *SessionController {
+	return &SessionController{
+		sessionService: sessionService,
+		userService:    userService,
+	}
+}
+
+// GetMySessions retrieves the sessions of the current user.
+// @Summary Get my sessions
+// @Description Retrieve the devices the current user is logged in on, most recently used first.
+// @Tags Session
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Session "Sessions retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/me/sessions [get]
+func (h *SessionController) GetMySessions(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	sessions, err := h.sessionService.GetSessions(middlewares.GetTenantScopeFromContext(c), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve sessions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeMySession ends one session of the current user.
+// @Summary Revoke my session
+// @Description Log the current user out of one device. The access token of that device stays valid until it expires.
+// @Tags Session
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} SuccessfulResponse "Session revoked successfully"
+// @Failure 400 {object} ErrorResponse "Invalid session ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Session not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/me/sessions/{id} [delete]
+func (h *SessionController) RevokeMySession(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid session ID"})
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(claims.UserID, uint(id)); err != nil {
+		if err.Error() == fmt.Sprintf("session with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to revoke session: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessfulResponse{"Session revoked successfully"})
+}
+
+// GetUserSessions retrieves the sessions of a user.
+// @Summary Get sessions of a user
+// @Description Retrieve the devices a user is logged in on. Requires ADMIN (for users of their school) or Sama Crew role.
+// @Tags Session
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {array} models.Session "Sessions retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/sessions [get]
+func (h *SessionController) GetUserSessions(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	user, err := h.userService.GetUserByID(scope, uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("user with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve user: " + err.Error()})
+		return
+	}
+
+	// ADMIN can only view sessions of users in their school
+	if !middlewares.Can(claims, "user:sessions", user) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	sessions, err := h.sessionService.GetSessions(scope, user.ID)
+	if err != nil {
+		if err.Error() == fmt.Sprintf("user with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve sessions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
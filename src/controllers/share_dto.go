@@ -47,3 +47,19 @@ type PaginateSchoolsResponse struct {
 type DownloadResponse struct {
 	URL string `json:"url" example:"https://your-s3-bucket.s3.amazonaws.com/user_id/image.png?X-Amz-..."`
 }
+
+// PaginateInvitationCodesResponse represents the response body for retrieve invitation codes with paginate
+type PaginateInvitationCodesResponse struct {
+	InvitationCodes []models.InvitationCode `json:"data"`
+	Offset          int                     `json:"offset" example:"0"`
+	Limit           int                     `json:"limit" example:"10"`
+	Total           int                     `json:"total" example:"20"`
+}
+
+// PaginateNotificationsResponse represents the response body for retrieve notifications with paginate
+type PaginateNotificationsResponse struct {
+	Notifications []models.Notification `json:"data"`
+	Offset        int                   `json:"offset" example:"0"`
+	Limit         int                   `json:"limit" example:"10"`
+	Total         int                   `json:"total" example:"20"`
+}
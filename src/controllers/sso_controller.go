@@ -0,0 +1,399 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSOController manages HTTP requests for the identity providers of schools and single sign-on.
+type SSOController struct {
+	ssoService *services.SSOService
+}
+
+// NewSSOController creates a new SSOController.
+func NewSSOController(ssoService *services.SSOService) *SSOController {
+	return &SSOController{
+		ssoService: ssoService,
+	}
+}
+
+// IdentityProviderRequest represents the request body for creating or updating an identity provider.
+type IdentityProviderRequest struct {
+	Name            string   `json:"name" binding:"required" example:"Google Workspace"`
+	IssuerURL       string   `json:"issuer_url" binding:"required" example:"https://accounts.google.com"`
+	ClientID        string   `json:"client_id" binding:"required" example:"1234567890-abc.apps.googleusercontent.com"`
+	ClientSecret    string   `json:"client_secret,omitempty" example:"GOCSPX-secret"`                 // Required on creation, kept when omitted on update
+	AllowedDomains  []string `json:"allowed_domains" example:"school.ac.th"`                          // Email domains accepted from the provider, any when empty
+	Enabled         bool     `json:"enabled" example:"true"`                                          // Users can only sign in with enabled providers
+	JITProvisioning bool     `json:"jit_provisioning" example:"true"`                                 // Create unknown users on their first sign-in
+	DefaultRole     string   `json:"default_role" binding:"required,oneof=STD TCH GRD" example:"STD"` // Role of the users created on sign-in
+}
+
+// SSOProviderResponse is an identity provider as shown on the sign-in page.
+type SSOProviderResponse struct {
+	ID   uint   `json:"id" example:"1"`
+	Name string `json:"name" example:"Google Workspace"`
+}
+
+// SSOAuthorizationResponse represents the response body when a sign-in is started.
+type SSOAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.google.com/o/oauth2/v2/auth?client_id=..."`
+}
+
+// SSOCallbackRequest represents the request body for completing a sign-in.
+type SSOCallbackRequest struct {
+	State      string `json:"state" binding:"required" example:"5f2b9c0e8a7d4e1f9b3c6a2d8e4f7a1b"`
+	Code       string `json:"code" binding:"required" example:"4/0AX4XfWh..."`
+	DeviceName string `json:"device_name,omitempty" binding:"max=100" example:"John's iPhone"` // Detected from the user agent when omitted
+}
+
+// handleIdentityProviderError maps the errors of the identity provider management to HTTP responses.
+func (h *SSOController) handleIdentityProviderError(c *gin.Context, err error, schoolID, providerID uint, prefix string) {
+	switch {
+	case err.Error() == fmt.Sprintf("school with ID %d not found", schoolID),
+		err.Error() == fmt.Sprintf("identity provider with ID %d not found", providerID):
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+	case strings.HasPrefix(err.Error(), "validation failed"):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: prefix + err.Error()})
+	}
+}
+
+// GetIdentityProviders handles retrieving the identity providers of a school.
+// @Summary Get identity providers of a school
+// @Description Retrieve the single sign-on configuration of a school. Client secrets are never returned. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags SSO
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "School ID"
+// @Success 200 {array} models.IdentityProvider "Identity providers retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid school ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "School not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/identity-provider [get]
+func (h *SSOController) GetIdentityProviders(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	if !middlewares.Can(claims, "school:sso", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only manage single sign-on of their own school"})
+		return
+	}
+
+	providers, err := h.ssoService.GetIdentityProviders(middlewares.GetTenantScopeFromContext(c), uint(id))
+	if err != nil {
+		h.handleIdentityProviderError(c, err, uint(id), 0, "Failed to retrieve identity providers: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, providers)
+}
+
+// CreateIdentityProvider handles adding an identity provider to a school.
+// @Summary Add an identity provider to a school
+// @Description Let users of the school sign in with an OpenID Connect identity provider, e.g. Google Workspace or Microsoft Entra ID. Register the SSO redirect URL of the frontend at the provider. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags SSO
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "School ID"
+// @Param provider body IdentityProviderRequest true "Identity provider configuration"
+// @Success 201 {object} models.IdentityProvider "Identity provider created successfully"
+// @Failure 400 {object} ErrorResponse "Invalid school ID, request payload or validation error"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "School not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/identity-provider [post]
+func (h *SSOController) CreateIdentityProvider(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	if !middlewares.Can(claims, "school:sso", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only manage single sign-on of their own school"})
+		return
+	}
+
+	var req IdentityProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	provider := &models.IdentityProvider{
+		SchoolID:        uint(id),
+		Name:            req.Name,
+		IssuerURL:       req.IssuerURL,
+		ClientID:        req.ClientID,
+		ClientSecret:    req.ClientSecret,
+		AllowedDomains:  req.AllowedDomains,
+		Enabled:         req.Enabled,
+		JITProvisioning: req.JITProvisioning,
+		DefaultRole:     req.DefaultRole,
+	}
+
+	if err := h.ssoService.CreateIdentityProvider(middlewares.GetTenantScopeFromContext(c), provider); err != nil {
+		h.handleIdentityProviderError(c, err, uint(id), 0, "Failed to create identity provider: ")
+		return
+	}
+
+	c.JSON(http.StatusCreated, provider)
+}
+
+// UpdateIdentityProvider handles updating an identity provider.
+// @Summary Update an identity provider
+// @Description Replace the configuration of an identity provider. The client secret is kept when omitted. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags SSO
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Identity provider ID"
+// @Param provider body IdentityProviderRequest true "Identity provider configuration"
+// @Success 200 {object} models.IdentityProvider "Identity provider updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid identity provider ID, request payload or validation error"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "Identity provider not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /identity-provider/{id} [put]
+func (h *SSOController) UpdateIdentityProvider(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid identity provider ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	provider, err := h.ssoService.GetIdentityProviderByID(scope, uint(id))
+	if err != nil {
+		h.handleIdentityProviderError(c, err, 0, uint(id), "Failed to retrieve identity provider for update: ")
+		return
+	}
+
+	if !middlewares.Can(claims, "school:sso", middlewares.SchoolResource(provider.SchoolID)) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only manage single sign-on of their own school"})
+		return
+	}
+
+	var req IdentityProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	provider.Name = req.Name
+	provider.IssuerURL = req.IssuerURL
+	provider.ClientID = req.ClientID
+	if req.ClientSecret != "" {
+		provider.ClientSecret = req.ClientSecret
+	}
+	provider.AllowedDomains = req.AllowedDomains
+	provider.Enabled = req.Enabled
+	provider.JITProvisioning = req.JITProvisioning
+	provider.DefaultRole = req.DefaultRole
+
+	if err := h.ssoService.UpdateIdentityProvider(scope, provider); err != nil {
+		h.handleIdentityProviderError(c, err, 0, uint(id), "Failed to update identity provider: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, provider)
+}
+
+// DeleteIdentityProvider handles removing an identity provider.
+// @Summary Delete an identity provider
+// @Description Stop users from signing in with an identity provider. Their accounts are kept. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags SSO
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Identity provider ID"
+// @Success 204 {object} SuccessfulResponse "Identity provider deleted successfully"
+// @Failure 400 {object} ErrorResponse "Invalid identity provider ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "Identity provider not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /identity-provider/{id} [delete]
+func (h *SSOController) DeleteIdentityProvider(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid identity provider ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	provider, err := h.ssoService.GetIdentityProviderByID(scope, uint(id))
+	if err != nil {
+		h.handleIdentityProviderError(c, err, 0, uint(id), "Failed to retrieve identity provider for deletion: ")
+		return
+	}
+
+	if !middlewares.Can(claims, "school:sso", middlewares.SchoolResource(provider.SchoolID)) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only manage single sign-on of their own school"})
+		return
+	}
+
+	if err := h.ssoService.DeleteIdentityProvider(scope, uint(id)); err != nil {
+		h.handleIdentityProviderError(c, err, 0, uint(id), "Failed to delete identity provider: ")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// FindSSOProviders handles looking up the identity providers a user can sign in with.
+// @Summary Find single sign-on providers for an email
+// @Description Retrieve the enabled identity providers restricted to the domain of the email, to offer them on the sign-in page.
+// @Tags SSO
+// @Produce json
+// @Param email query string true "Email typed by the user"
+// @Success 200 {array} SSOProviderResponse "Identity providers retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Missing email"
+// @Failure 429 {object} ErrorResponse "Too many requests from this IP"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /sso/provider [get]
+func (h *SSOController) FindSSOProviders(c *gin.Context) {
+	email := c.Query("email")
+	if email == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Missing email"})
+		return
+	}
+
+	providers, err := h.ssoService.FindIdentityProviders(email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve identity providers: " + err.Error()})
+		return
+	}
+
+	response := make([]SSOProviderResponse, len(providers))
+	for i, provider := range providers {
+		response[i] = SSOProviderResponse{ID: provider.ID, Name: provider.Name}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// StartSSOLogin handles starting a sign-in at an identity provider.
+// @Summary Start single sign-on
+// @Description Return the URL of the identity provider to send the user to. The provider sends the user back to the SSO redirect URL of the frontend with a code and a state, valid for 10 minutes.
+// @Tags SSO
+// @Produce json
+// @Param id path int true "Identity provider ID"
+// @Success 200 {object} SSOAuthorizationResponse "Sign-in started successfully"
+// @Failure 400 {object} ErrorResponse "Invalid identity provider ID"
+// @Failure 404 {object} ErrorResponse "Identity provider not found or disabled"
+// @Failure 429 {object} ErrorResponse "Too many requests from this IP"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Single sign-on is not configured"
+// @Router /sso/{id}/authorize [post]
+func (h *SSOController) StartSSOLogin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid identity provider ID"})
+		return
+	}
+
+	authorizationURL, err := h.ssoService.StartLogin(uint(id))
+	if err != nil {
+		switch err.Error() {
+		case fmt.Sprintf("identity provider with ID %d not found", id):
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+		case "single sign-on is not configured":
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to start single sign-on: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SSOAuthorizationResponse{AuthorizationURL: authorizationURL})
+}
+
+// FinishSSOLogin handles completing a sign-in at an identity provider.
+// @Summary Complete single sign-on
+// @Description Exchange the code and state returned by the identity provider for a JWT token and refresh token, like a password login. The email of the identity is mapped to a user of the provider's school, or a user is created when the provider allows it.
+// @Tags SSO
+// @Accept json
+// @Produce json
+// @Param callback body SSOCallbackRequest true "Code and state returned by the identity provider"
+// @Success 200 {object} LoginResponse "Login successful, returns JWT token"
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Invalid or expired state, sign-in refused by the identity provider, or account deactivated"
+// @Failure 403 {object} ErrorResponse "Email not verified, domain not allowed, or no account for the email in the school"
+// @Failure 429 {object} ErrorResponse "Too many requests from this IP"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /sso/callback [post]
+func (h *SSOController) FinishSSOLogin(c *gin.Context) {
+	var req SSOCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	session := &models.Session{
+		DeviceName: req.DeviceName,
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+	}
+
+	token, refreshToken, err := h.ssoService.FinishLogin(req.State, req.Code, session)
+	if err != nil {
+		switch {
+		case err.Error() == "invalid or expired sso state",
+			err.Error() == "user account is deactivated",
+			strings.HasPrefix(err.Error(), "sign-in at the identity provider failed"):
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Message: err.Error()})
+		case err.Error() == "identity provider did not share the email",
+			err.Error() == "email domain is not allowed by the identity provider",
+			err.Error() == "email is not verified by the identity provider",
+			err.Error() == "no account exists for this email",
+			err.Error() == "account cannot sign in with this identity provider":
+			c.JSON(http.StatusForbidden, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to login: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, LoginResponse{Token: token, RefreshToken: refreshToken})
+}
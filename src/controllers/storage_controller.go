@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"sama/sama-backend-2025/src/pkg"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageController serves the presigned URLs of the local storage backend.
+// The routes are only registered when files are kept on disk instead of S3.
+type StorageController struct {
+	store *pkg.LocalBlobStore
+}
+
+// NewStorageController creates a new StorageController.
+func NewStorageController(store *pkg.LocalBlobStore) *StorageController {
+	return &StorageController{
+		store: store,
+	}
+}
+
+// DownloadObject serves a stored file through a presigned download URL.
+// @Summary Download a stored file
+// @Description Serves a file of the local storage backend. The URL comes from an endpoint returning a presigned download URL.
+// @Tags Storage
+// @Produce octet-stream
+// @Param key path string true "Object key"
+// @Param expires query int true "Expiry of the URL as a Unix timestamp"
+// @Param signature query string true "Signature of the URL"
+// @Success 200 {file} file "Stored file"
+// @Failure 403 {object} ErrorResponse "Invalid or expired signature"
+// @Failure 404 {object} ErrorResponse "Object not found"
+// @Router /storage/object/{key} [get]
+func (h *StorageController) DownloadObject(c *gin.Context) {
+	objectKey := strings.TrimPrefix(c.Param("key"), "/")
+
+	filename, err := h.store.OpenDownload(objectKey, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		if errors.Is(err, pkg.ErrInvalidSignature) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: pkg.ErrObjectNotFound.Error()})
+		return
+	}
+
+	c.File(filename)
+}
+
+// UploadObject stores a file posted with a presigned upload form.
+// @Summary Upload a file
+// @Description Stores a file in the local storage backend. The form fields come from an endpoint returning a presigned upload URL and the file is sent last in the "file" field.
+// @Tags Storage
+// @Accept multipart/form-data
+// @Param file formData file true "File to upload"
+// @Success 204 "File stored"
+// @Failure 400 {object} ErrorResponse "Missing file or upload violating the policy"
+// @Failure 403 {object} ErrorResponse "Invalid or expired signature"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /storage/upload [post]
+func (h *StorageController) UploadObject(c *gin.Context) {
+	// Leave room for the form fields around the largest file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, pkg.LocalMaxUploadBytes+1<<20)
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	fields := make(map[string]string, len(c.Request.MultipartForm.Value))
+	for name, values := range c.Request.MultipartForm.Value {
+		fields[name] = values[0]
+	}
+
+	if err := h.store.AcceptUpload(fields, file); err != nil {
+		switch {
+		case errors.Is(err, pkg.ErrInvalidSignature):
+			c.JSON(http.StatusForbidden, ErrorResponse{Message: err.Error()})
+		case strings.HasPrefix(err.Error(), "upload violates the policy"), strings.HasPrefix(err.Error(), "invalid object key"):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to store file: " + err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
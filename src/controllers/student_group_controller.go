@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StudentGroupController manages HTTP requests for student groups.
+type StudentGroupController struct {
+	studentGroupService *services.StudentGroupService
+}
+
+// NewStudentGroupController creates a new StudentGroupController.
+func NewStudentGroupController(studentGroupService *services.StudentGroupService) *StudentGroupController {
+	return &StudentGroupController{
+		studentGroupService: studentGroupService,
+	}
+}
+
+// StudentGroupRequest represents the request body for creating or updating a student group.
+type StudentGroupRequest struct {
+	Name        string `json:"name" binding:"required,max=100" example:"Robotics club"`
+	Description string `json:"description" example:"Meets on Friday afternoons"`
+	IsShared    bool   `json:"is_shared" example:"true"` // Let the whole staff of the school see the group and target it with activities
+	MemberIDs   []uint `json:"member_ids" example:"101"` // Active students of the school
+}
+
+// handleStudentGroupError maps the errors of the student group management to HTTP responses.
+func (h *StudentGroupController) handleStudentGroupError(c *gin.Context, err error, schoolID, groupID uint, prefix string) {
+	switch {
+	case err.Error() == fmt.Sprintf("school with ID %d not found", schoolID),
+		err.Error() == fmt.Sprintf("student group with ID %d not found", groupID):
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+	case strings.HasPrefix(err.Error(), "validation failed"),
+		strings.HasPrefix(err.Error(), "failed to find active student"):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: prefix + err.Error()})
+	}
+}
+
+// GetStudentGroups handles retrieving the student groups of a school.
+// @Summary Get student groups of a school
+// @Description Retrieve the student groups of a school. Teachers see their own groups and the shared ones, ADMIN (for their school) and Sama Crew see every group.
+// @Tags StudentGroup
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "School ID"
+// @Success 200 {array} models.StudentGroup "Student groups retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid school ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "School not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/student-group [get]
+func (h *StudentGroupController) GetStudentGroups(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	if !middlewares.Can(claims, "group:list", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only view student groups of your own school"})
+		return
+	}
+
+	// Private groups of other teachers are only listed for ADMIN and SAMA
+	visibleTo := claims.UserID
+	if middlewares.Can(claims, "group:list-all", middlewares.SchoolResource(uint(id))) {
+		visibleTo = 0
+	}
+
+	groups, err := h.studentGroupService.GetStudentGroups(middlewares.GetTenantScopeFromContext(c), uint(id), visibleTo)
+	if err != nil {
+		h.handleStudentGroupError(c, err, uint(id), 0, "Failed to retrieve student groups: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// CreateStudentGroup handles creating a student group in a school.
+// @Summary Create a student group
+// @Description Gather active students of a school in a named group owned by the caller, e.g. a club. Groups can be used as an activity target and as a filter of the user listing and statistics. Requires TCH or ADMIN (for their school) or Sama Crew role.
+// @Tags StudentGroup
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "School ID"
+// @Param group body StudentGroupRequest true "Student group details"
+// @Success 201 {object} models.StudentGroup "Student group created successfully"
+// @Failure 400 {object} ErrorResponse "Invalid school ID, request payload, validation error or member not an active student of the school"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "School not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/student-group [post]
+func (h *StudentGroupController) CreateStudentGroup(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	if !middlewares.Can(claims, "group:create", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only create student groups in your own school"})
+		return
+	}
+
+	var req StudentGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	group := &models.StudentGroup{
+		SchoolID:    uint(id),
+		OwnerID:     claims.UserID,
+		Name:        req.Name,
+		Description: req.Description,
+		IsShared:    req.IsShared,
+		MemberIDs:   req.MemberIDs,
+	}
+
+	if err := h.studentGroupService.CreateStudentGroup(middlewares.GetTenantScopeFromContext(c), group); err != nil {
+		h.handleStudentGroupError(c, err, uint(id), 0, "Failed to create student group: ")
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// GetStudentGroupByID handles retrieving a student group.
+// @Summary Get a student group
+// @Description Retrieve a student group with the IDs of its members. Teachers can view their own groups and the shared ones.
+// @Tags StudentGroup
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Student group ID"
+// @Success 200 {object} models.StudentGroup "Student group retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid student group ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (private group of another teacher)"
+// @Failure 404 {object} ErrorResponse "Student group not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /student-group/{id} [get]
+func (h *StudentGroupController) GetStudentGroupByID(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid student group ID"})
+		return
+	}
+
+	group, err := h.studentGroupService.GetStudentGroupByID(middlewares.GetTenantScopeFromContext(c), uint(id))
+	if err != nil {
+		h.handleStudentGroupError(c, err, 0, uint(id), "Failed to retrieve student group: ")
+		return
+	}
+
+	if !middlewares.Can(claims, "group:read", group) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only view your own or shared student groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// UpdateStudentGroup handles updating a student group.
+// @Summary Update a student group
+// @Description Replace the details and members of a student group. Members that are no longer active can stay, new members must be active students of the school. Requires the owner, ADMIN (for their school) or Sama Crew role.
+// @Tags StudentGroup
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Student group ID"
+// @Param group body StudentGroupRequest true "Student group details"
+// @Success 200 {object} models.StudentGroup "Student group updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid student group ID, request payload, validation error or member not an active student of the school"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not the owner of the group)"
+// @Failure 404 {object} ErrorResponse "Student group not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /student-group/{id} [put]
+func (h *StudentGroupController) UpdateStudentGroup(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid student group ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	group, err := h.studentGroupService.GetStudentGroupByID(scope, uint(id))
+	if err != nil {
+		h.handleStudentGroupError(c, err, 0, uint(id), "Failed to retrieve student group for update: ")
+		return
+	}
+
+	if !middlewares.Can(claims, "group:update", group) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only update your own student groups"})
+		return
+	}
+
+	var req StudentGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	group.Name = req.Name
+	group.Description = req.Description
+	group.IsShared = req.IsShared
+	group.MemberIDs = req.MemberIDs
+
+	if err := h.studentGroupService.UpdateStudentGroup(scope, group); err != nil {
+		h.handleStudentGroupError(c, err, 0, uint(id), "Failed to update student group: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteStudentGroup handles deleting a student group.
+// @Summary Delete a student group
+// @Description Delete a student group. Activities targeting the group stay assigned to its members. Requires the owner, ADMIN (for their school) or Sama Crew role.
+// @Tags StudentGroup
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Student group ID"
+// @Success 204 {object} SuccessfulResponse "Student group deleted successfully"
+// @Failure 400 {object} ErrorResponse "Invalid student group ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not the owner of the group)"
+// @Failure 404 {object} ErrorResponse "Student group not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /student-group/{id} [delete]
+func (h *StudentGroupController) DeleteStudentGroup(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid student group ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	group, err := h.studentGroupService.GetStudentGroupByID(scope, uint(id))
+	if err != nil {
+		h.handleStudentGroupError(c, err, 0, uint(id), "Failed to retrieve student group for deletion: ")
+		return
+	}
+
+	if !middlewares.Can(claims, "group:delete", group) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only delete your own student groups"})
+		return
+	}
+
+	if err := h.studentGroupService.DeleteStudentGroup(scope, uint(id)); err != nil {
+		h.handleStudentGroupError(c, err, 0, uint(id), "Failed to delete student group: ")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
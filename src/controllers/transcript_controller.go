@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TranscriptController manages HTTP requests for student transcripts.
+type TranscriptController struct {
+	transcriptService *services.TranscriptService
+	userService       *services.UserService
+}
+
+// NewTranscriptController creates a new TranscriptController.
+func NewTranscriptController(transcriptService *services.TranscriptService, userService *services.UserService) *TranscriptController {
+	return &TranscriptController{
+		transcriptService: transcriptService,
+		userService:       userService,
+	}
+}
+
+// TranscriptFileResponse represents the response of an issued transcript file.
+type TranscriptFileResponse struct {
+	URL       string    `json:"url" example:"https://your-s3-bucket.s3.amazonaws.com/transcripts/1/e3c4e512-421e-45a2-921d-a9f3c7e0c4f8.pdf?X-Amz-..."`
+	Code      string    `json:"code" example:"e3c4e512-421e-45a2-921d-a9f3c7e0c4f8"`
+	Signature string    `json:"signature" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	IssuedAt  time.Time `json:"issued_at" example:"2025-07-28T15:49:03Z"`
+}
+
+// GetTranscript retrieves the multi-semester transcript of a student.
+// @Summary Get transcript of a student
+// @Description Retrieve activities, approved amounts, completion and required-activity results of a student for every semester of the school. ADMIN and TCH can view transcripts of students in their school, students only their own.
+// @Tags Transcript
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Student user ID"
+// @Success 200 {object} models.Transcript "Transcript retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid user ID or user is not a student"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not authorized to view this transcript)"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/transcript [get]
+func (h *TranscriptController) GetTranscript(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	student, err := h.userService.GetUserByID(scope, uint(id))
+	if err != nil {
+		h.handleTranscriptError(c, err, uint(id), "Failed to retrieve user: ")
+		return
+	}
+
+	// ADMIN and TCH can only view transcripts of students in their school
+	if !middlewares.Can(claims, "transcript:read", student) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Students can only view their own transcript"})
+		return
+	}
+
+	transcript, err := h.transcriptService.GetTranscript(scope, student.ID)
+	if err != nil {
+		h.handleTranscriptError(c, err, uint(id), "Failed to retrieve transcript: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, transcript)
+}
+
+// IssueTranscriptFile generates a signed PDF transcript of a student.
+// @Summary Issue signed transcript file
+// @Description Generate a signed PDF transcript of a student and retrieve a presigned URL to download it. The verification code and signature printed on the document can be checked with the verify endpoint. ADMIN and TCH can issue transcripts of students in their school, students only their own.
+// @Tags Transcript
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Student user ID"
+// @Success 200 {object} TranscriptFileResponse "Presigned URL and verification data of the issued transcript"
+// @Failure 400 {object} ErrorResponse "Invalid user ID or user is not a student"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (not authorized to issue this transcript)"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/transcript-file [post]
+func (h *TranscriptController) IssueTranscriptFile(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	scope := middlewares.GetTenantScopeFromContext(c)
+	student, err := h.userService.GetUserByID(scope, uint(id))
+	if err != nil {
+		h.handleTranscriptError(c, err, uint(id), "Failed to retrieve user: ")
+		return
+	}
+
+	// ADMIN and TCH can only issue transcripts of students in their school
+	if !middlewares.Can(claims, "transcript:issue", student) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Students can only issue their own transcript"})
+		return
+	}
+
+	issue, presignedHTTPRequest, err := h.transcriptService.IssueTranscriptFile(c.Request.Context(), scope, student.ID, claims.UserID)
+	if err != nil {
+		h.handleTranscriptError(c, err, uint(id), "Failed to issue transcript file: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, TranscriptFileResponse{
+		URL:       presignedHTTPRequest.URL,
+		Code:      issue.Code,
+		Signature: issue.Signature,
+		IssuedAt:  issue.IssuedAt,
+	})
+}
+
+// VerifyTranscript checks a signed transcript.
+// @Summary Verify a signed transcript
+// @Description Check the verification code and signature printed on a transcript file, and retrieve the content that was issued.
+// @Tags Transcript
+// @Produce json
+// @Param code path string true "Verification code"
+// @Param signature query string true "Signature printed on the document"
+// @Success 200 {object} models.TranscriptIssue "Transcript is authentic"
+// @Failure 400 {object} ErrorResponse "Invalid signature"
+// @Failure 404 {object} ErrorResponse "Transcript not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /transcript/verify/{code} [get]
+func (h *TranscriptController) VerifyTranscript(c *gin.Context) {
+	code := c.Param("code")
+	signature := c.Query("signature")
+	if signature == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Signature is required"})
+		return
+	}
+
+	issue, err := h.transcriptService.VerifyTranscript(code, signature)
+	if err != nil {
+		if err.Error() == fmt.Sprintf("transcript with code %s not found", code) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		if err.Error() == "invalid transcript signature" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to verify transcript: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, issue)
+}
+
+// handleTranscriptError maps errors from building a transcript to responses.
+func (h *TranscriptController) handleTranscriptError(c *gin.Context, err error, id uint, prefix string) {
+	switch err.Error() {
+	case fmt.Sprintf("user with ID %d not found", id):
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+	case fmt.Sprintf("user with ID %d is not a student", id):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: prefix + err.Error()})
+	}
+}
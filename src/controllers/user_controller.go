@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"sama/sama-backend-2025/src/middlewares"
 	"sama/sama-backend-2025/src/models"
@@ -40,16 +41,29 @@ func NewUserController(
 // UpdateUserProfileRequest represents the request body for updating a user's profile.
 // Use a separate struct for update requests to control what fields can be updated.
 type UpdateUserProfileRequest struct {
-	StudentID         *string `json:"student_id,omitempty" example:"10101"`
-	Email             string  `json:"email" binding:"omitempty,email" example:"new_email@example.com"`
-	Phone             string  `json:"phone" example:"+1987654321"`
-	Firstname         string  `json:"firstname" example:"Jane"`
-	Lastname          string  `json:"lastname" example:"Doe"`
-	ProfilePictureURL *string `json:"profile_picture_url,omitempty" example:"http://example.com/pic.jpg"`
-	Classroom         *string `json:"classroom,omitempty" example:"1/1" validate:"classroomregex"`
-	Number            *uint   `json:"number,omitempty" binding:"omitempty,number" example:"2"` // Pointer for optional int update
-	Language          string  `json:"language" example:"th"`
-	BookmarkUserIDs   []uint  `json:"bookmark_user_ids" example:"1"`
+	StudentID       *string `json:"student_id,omitempty" example:"10101"`
+	Email           string  `json:"email" binding:"omitempty,email" example:"new_email@example.com"`
+	Phone           string  `json:"phone" example:"+1987654321"`
+	Firstname       string  `json:"firstname" example:"Jane"`
+	Lastname        string  `json:"lastname" example:"Doe"`
+	Classroom       *string `json:"classroom,omitempty" example:"1/1" validate:"classroomregex"`
+	Number          *uint   `json:"number,omitempty" binding:"omitempty,number" example:"2"` // Pointer for optional int update
+	Language        string  `json:"language" example:"th"`
+	BookmarkUserIDs []uint  `json:"bookmark_user_ids" example:"1"`
+}
+
+// UpdateUserStatusRequest represents the request body for changing the lifecycle status of a user.
+type UpdateUserStatusRequest struct {
+	Status      string     `json:"status" binding:"required,oneof=ACTIVE SUSPENDED GRADUATED TRANSFERRED" example:"GRADUATED"`
+	EffectiveAt *time.Time `json:"effective_at,omitempty" example:"2025-03-31T00:00:00Z"` // Defaults to now
+	Reason      string     `json:"reason" example:"Graduated in 2025"`
+}
+
+// TransferUserRequest represents the request body for transferring a student to another school.
+type TransferUserRequest struct {
+	SchoolID    uint       `json:"school_id" binding:"required" example:"2"`
+	EffectiveAt *time.Time `json:"effective_at,omitempty" example:"2025-05-16T00:00:00Z"` // Defaults to now
+	Reason      string     `json:"reason" example:"Moved to another province"`
 }
 
 type UserStatistic struct {
@@ -63,7 +77,7 @@ type UserStatistic struct {
 
 // GetMyProfile retrieves the profile of the authenticated user.
 // @Summary Get authenticated user's profile
-// @Description Retrieve the profile details of the currently authenticated user.
+// @Description Retrieve the profile details of the currently authenticated user. When a Sama Crew member is acting as the user, impersonated_by_id holds their ID.
 // @Tags User
 // @Security BearerAuth
 // @Produce json
@@ -78,11 +92,14 @@ func (h *UserController) GetMyProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(claims.UserID)
+	user, err := h.userService.GetUserByID(middlewares.GetTenantScopeFromContext(c), claims.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve user profile: " + err.Error()})
 		return
 	}
+	if claims.IsImpersonated() {
+		user.ImpersonatedByID = &claims.ActorID
+	}
 
 	c.JSON(http.StatusOK, user)
 }
@@ -108,19 +125,20 @@ func (h *UserController) GetUserByID(c *gin.Context) {
 		return
 	}
 
-	// STD are only allow for getMyProfile
-	// if claims.Role == "STD" {
-	// 	c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
-	// 	return
-	// }
-
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
 		return
 	}
 
-	user, err := h.userService.GetUserByID(uint(id))
+	// Guardians can only view themselves and their linked students
+	if !middlewares.Can(claims, "user:read", middlewares.UserResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	// Users outside of the caller's school are filtered out by the tenant scope, except for SAMA
+	user, err := h.userService.GetUserByID(middlewares.GetTenantScopeFromContext(c), uint(id))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("user with ID %d not found", id) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -130,18 +148,12 @@ func (h *UserController) GetUserByID(c *gin.Context) {
 		return
 	}
 
-	// Can get user outside their school only if they are SAMA
-	if claims.Role != "SAMA" && claims.SchoolID != user.SchoolID {
-		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions (user not in your school)"})
-		return
-	}
-
 	c.JSON(http.StatusOK, user)
 }
 
 // UpdateUserProfile handles updating a user's profile.
 // @Summary Update user profile
-// @Description Update an authenticated user's profile.
+// @Description Update an authenticated user's profile. A new email is kept in pending_email and a verification code is sent to it; the current email stays in use until the code is confirmed.
 // @Tags User
 // @Security BearerAuth
 // @Accept json
@@ -151,8 +163,9 @@ func (h *UserController) GetUserByID(c *gin.Context) {
 // @Success 200 {object} models.User "User profile updated successfully"
 // @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
-// @Failure 403 {object} ErrorResponse "Forbidden (cannot update other users or insufficient permissions)"
+// @Failure 403 {object} ErrorResponse "Forbidden (cannot update other users, insufficient permissions or email change while impersonating)"
 // @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "Email already used by another user"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /user/{id} [put]
 func (h *UserController) UpdateUserProfile(c *gin.Context) {
@@ -168,7 +181,8 @@ func (h *UserController) UpdateUserProfile(c *gin.Context) {
 		return
 	}
 
-	userToUpdate, err := h.userService.GetUserByID(uint(id))
+	scope := middlewares.GetTenantScopeFromContext(c)
+	userToUpdate, err := h.userService.GetUserByID(scope, uint(id))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("user with ID %d not found", id) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -178,14 +192,8 @@ func (h *UserController) UpdateUserProfile(c *gin.Context) {
 		return
 	}
 
-	// For STD and TCH, do not allow to update other user
-	if (claims.Role == "STD" || claims.Role == "TCH") && claims.UserID != userToUpdate.ID {
-		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only update your profile"})
-		return
-	}
-
-	// For ADMIN, allow only their profile and other non-admin in the same school
-	if claims.Role == "ADMIN" && userToUpdate.SchoolID != claims.SchoolID && !(userToUpdate.ID == claims.UserID || userToUpdate.Role == "STD" || userToUpdate.Role == "TCH") {
+	// STD and TCH can only update their own profile, ADMIN can also update any non-admin in their school
+	if !middlewares.Can(claims, "user:update", userToUpdate) {
 		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only update your profile or anyone not ADMIN in your school"})
 		return
 	}
@@ -196,18 +204,27 @@ func (h *UserController) UpdateUserProfile(c *gin.Context) {
 		return
 	}
 
+	// The email receives the password reset OTPs, so it can't be changed on behalf of the user
+	if claims.IsImpersonated() && req.Email != userToUpdate.Email {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Not allowed while impersonating a user"})
+		return
+	}
+
 	userToUpdate.StudentUniqueID = req.StudentID
 	userToUpdate.Email = req.Email
 	userToUpdate.Phone = req.Phone
 	userToUpdate.Firstname = req.Firstname
 	userToUpdate.Lastname = req.Lastname
-	userToUpdate.ProfilePictureURL = req.ProfilePictureURL
 	userToUpdate.Classroom = req.Classroom
 	userToUpdate.Number = req.Number
 	userToUpdate.Language = req.Language
 	userToUpdate.BookmarkUserIDs = req.BookmarkUserIDs
 
-	if err := h.userService.UpdateUserProfile(userToUpdate); err != nil {
+	if err := h.userService.UpdateUserProfile(scope, userToUpdate); err != nil {
+		if err.Error() == "user with this email already exists" {
+			c.JSON(http.StatusConflict, ErrorResponse{Message: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to update user profile: " + err.Error()})
 		return
 	}
@@ -242,7 +259,8 @@ func (h *UserController) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(uint(id))
+	scope := middlewares.GetTenantScopeFromContext(c)
+	user, err := h.userService.GetUserByID(scope, uint(id))
 	if err != nil {
 		if err.Error() == fmt.Sprintf("user with ID %d not found", id) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
@@ -252,19 +270,13 @@ func (h *UserController) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	// For STD and TCH, do not allow to update other user
-	if (claims.Role == "STD" || claims.Role == "TCH") && claims.UserID != user.ID {
-		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only delete your profile"})
-		return
-	}
-
-	// For ADMIN, allow only their profile and other non-admin in the same school
-	if claims.Role == "ADMIN" && user.SchoolID != claims.SchoolID && !(user.ID == claims.UserID || user.Role == "STD" || user.Role == "TCH") {
+	// STD and TCH can only delete their own profile, ADMIN can also delete any non-admin in their school
+	if !middlewares.Can(claims, "user:delete", user) {
 		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only delete your profile or anyone not ADMIN in your school"})
 		return
 	}
 
-	if err := h.userService.DeleteUser(uint(id)); err != nil {
+	if err := h.userService.DeleteUser(scope, uint(id)); err != nil {
 		if err.Error() == fmt.Sprintf("user with ID %d not found", id) { // Check for specific not found error
 			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
 			return
@@ -276,6 +288,189 @@ func (h *UserController) DeleteUser(c *gin.Context) {
 	c.Status(http.StatusNoContent) // 204 No Content for successful deletion
 }
 
+// UpdateUserStatus handles changing the lifecycle status of a user.
+// @Summary Change the lifecycle status of a user
+// @Description Set a user to ACTIVE, SUSPENDED, GRADUATED or TRANSFERRED (left for a school outside the platform) from an effective date, which defaults to now and can't be in the future. Users that aren't ACTIVE are left out of rosters, statistics and new assignments but keep their records and transcripts; SUSPENDED users can't sign in. Requires ADMIN of the user's school or Sama Crew role.
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param status body UpdateUserStatusRequest true "New status"
+// @Success 200 {object} models.User "User status changed successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload, same status or future effective date"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or own status)"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/status [patch]
+func (h *UserController) UpdateUserStatus(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	user, err := h.userService.GetUserByID(middlewares.GetTenantScopeFromContext(c), uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("user with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve user: " + err.Error()})
+		return
+	}
+
+	// ADMIN can only change the status of students and teachers of their school
+	if !middlewares.Can(claims, "user:status", user) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only change the status of students and teachers in your school"})
+		return
+	}
+
+	var req UpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	if err := h.userService.UpdateUserStatus(user, req.Status, req.EffectiveAt, req.Reason, claims.UserID); err != nil {
+		switch err.Error() {
+		case "cannot change your own status":
+			c.JSON(http.StatusForbidden, ErrorResponse{Message: err.Error()})
+		case fmt.Sprintf("user already has status %s", req.Status), "effective date cannot be in the future":
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to change user status: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// TransferUser handles transferring a student to another school.
+// @Summary Transfer a student to another school
+// @Description Move a student to another school of the platform, where they are ACTIVE from the effective date. Their records and transcript history move along, while their classroom and number are cleared for the new school to assign. The student is logged out of every device. Requires ADMIN of the student's current school or Sama Crew role.
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param transfer body TransferUserRequest true "Destination school"
+// @Success 200 {object} models.User "Student transferred successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload, not a student, same school or future effective date"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "User or school not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/transfer [post]
+func (h *UserController) TransferUser(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	user, err := h.userService.GetUserByID(middlewares.GetTenantScopeFromContext(c), uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("user with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve user: " + err.Error()})
+		return
+	}
+
+	// ADMIN can only transfer students out of their own school
+	if !middlewares.Can(claims, "user:transfer", user) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Can only transfer students of your school"})
+		return
+	}
+
+	var req TransferUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	if err := h.userService.TransferUser(user, req.SchoolID, req.EffectiveAt, req.Reason, claims.UserID); err != nil {
+		switch err.Error() {
+		case fmt.Sprintf("school with ID %d not found", req.SchoolID):
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+		case "only students can be transferred", "user already belongs to this school", "effective date cannot be in the future":
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to transfer user: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// GetUserStatusLogs retrieves the lifecycle history of a user.
+// @Summary Get the lifecycle history of a user
+// @Description Retrieve every status change and transfer of a user, oldest first. Requires ADMIN of the user's school or Sama Crew role.
+// @Tags User
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {array} models.UserStatusLog "Lifecycle history retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /user/{id}/status-history [get]
+func (h *UserController) GetUserStatusLogs(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid user ID"})
+		return
+	}
+
+	user, err := h.userService.GetUserByID(middlewares.GetTenantScopeFromContext(c), uint(id))
+	if err != nil {
+		if err.Error() == fmt.Sprintf("user with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve user: " + err.Error()})
+		return
+	}
+
+	if !middlewares.Can(claims, "user:status", user) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
+	logs, err := h.userService.GetUserStatusLogs(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve status history: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
 // GetAssignedActivity retrieves a list of activities related to the authenticated user.
 // This includes activities where the user is the owner, or part of exclusive classrooms/students.
 // @Summary Get activities related to the user
@@ -288,6 +483,7 @@ func (h *UserController) DeleteUser(c *gin.Context) {
 // @Produce json
 // @Success 200 {array} models.ActivityWithStatistic "List of related activities retrieved successfully"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /user/{id}/activity [get]
 func (c *UserController) GetAssignedActivities(ctx *gin.Context) {
@@ -303,6 +499,12 @@ func (c *UserController) GetAssignedActivities(ctx *gin.Context) {
 		return
 	}
 
+	// Guardians can only view their linked students
+	if !middlewares.Can(claims, "user:read", middlewares.UserResource(uint(id))) {
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
 	semester, _ := strconv.ParseUint(ctx.DefaultQuery("semester", "0"), 10, 64)
 	schoolYear, _ := strconv.ParseUint(ctx.DefaultQuery("school_year", "0"), 10, 64)
 
@@ -315,7 +517,7 @@ func (c *UserController) GetAssignedActivities(ctx *gin.Context) {
 	// This will be a more complex query in the repository.
 
 	// Example placeholder for activities:
-	activities, err := c.activityService.GetAssignedActivitiesByUserID(uint(id), claims.SchoolID, uint(semester), uint(schoolYear))
+	activities, err := c.activityService.GetAssignedActivitiesByUserID(middlewares.GetTenantScopeFromContext(ctx), uint(id), uint(semester), uint(schoolYear))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve related activities: " + err.Error()})
 		return
@@ -337,6 +539,7 @@ func (c *UserController) GetAssignedActivities(ctx *gin.Context) {
 // @Produce json
 // @Success 200 {object} UserStatistic "List of related activities retrieved successfully"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions)"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /user/{id}/statistic [get]
 func (c *UserController) GetUserStatisticByID(ctx *gin.Context) {
@@ -352,6 +555,12 @@ func (c *UserController) GetUserStatisticByID(ctx *gin.Context) {
 		return
 	}
 
+	// Guardians can only view their linked students
+	if !middlewares.Can(claims, "user:read", middlewares.UserResource(uint(id))) {
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: Insufficient permissions"})
+		return
+	}
+
 	activityIDs, err := utils.SplitQueryUint(ctx.Query("activity_id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{Message: "Failed to read activity_ids query: " + err.Error()})
@@ -367,7 +576,7 @@ func (c *UserController) GetUserStatisticByID(ctx *gin.Context) {
 		totalSended,
 		totalApproved,
 		totalRejected,
-		err := c.userService.GetUserStatistic(uint(id), claims.SchoolID, activityIDs, uint(semester), uint(schoolYear))
+		err := c.userService.GetUserStatistic(middlewares.GetTenantScopeFromContext(ctx), uint(id), activityIDs, uint(semester), uint(schoolYear))
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to retrieve statistic: " + err.Error()})
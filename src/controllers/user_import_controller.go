@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sama/sama-backend-2025/src/middlewares"
+	"sama/sama-backend-2025/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRosterFileSize is the largest roster file accepted, in bytes.
+const maxRosterFileSize = 5 << 20
+
+// UserImportController manages HTTP requests for bulk user imports.
+type UserImportController struct {
+	userImportService *services.UserImportService
+}
+
+// NewUserImportController creates a new UserImportController.
+func NewUserImportController(userImportService *services.UserImportService) *UserImportController {
+	return &UserImportController{
+		userImportService: userImportService,
+	}
+}
+
+// ImportUsers handles importing users of a school from a roster file.
+// @Summary Import users from a roster file
+// @Description Create users of a school from a CSV or XLSX roster with the columns student_id, firstname, lastname, email, classroom, number and role. Every row is validated first; users are only created when no row is rejected and dry_run is not set. Each created user receives a generated initial password by email. Requires ADMIN (for their school) or Sama Crew role.
+// @Tags School
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "School ID"
+// @Param file formData file true "Roster file (.csv or .xlsx)"
+// @Param dry_run query bool false "Only validate the roster without creating users"
+// @Success 200 {object} models.UserImportReport "Roster validated (dry run) or users created"
+// @Failure 400 {object} ErrorResponse "Invalid school ID or unreadable roster file"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden (insufficient permissions or not authorized for this school)"
+// @Failure 404 {object} ErrorResponse "School not found"
+// @Failure 422 {object} models.UserImportReport "Some rows were rejected, no user was created"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /school/{id}/user/import [post]
+func (h *UserImportController) ImportUsers(c *gin.Context) {
+	claims, ok := middlewares.GetUserClaimsFromContext(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "User claims not found in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid school ID"})
+		return
+	}
+
+	// Authorization:
+	// SAMA can import users to any school.
+	// ADMIN can only import users to their own school.
+	if !middlewares.Can(claims, "school:import-users", middlewares.SchoolResource(uint(id))) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Message: "Forbidden: ADMIN can only import users to their own school"})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Roster file is required: " + err.Error()})
+		return
+	}
+	if fileHeader.Size > maxRosterFileSize {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: fmt.Sprintf("Roster file must not be larger than %d MB", maxRosterFileSize>>20)})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Failed to read roster file: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	report, err := h.userImportService.ImportUsers(c.Request.Context(), middlewares.GetTenantScopeFromContext(c), uint(id), fileHeader.Filename, file, dryRun)
+	if err != nil {
+		if err.Error() == fmt.Sprintf("school with ID %d not found", id) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: err.Error()})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "invalid roster file") {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to import users: " + err.Error()})
+		return
+	}
+
+	if len(report.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"log"
+	"net/http"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditImpersonation records every write request made with an impersonation token, with both the SAMA crew member and the user.
+// It must run after Authmiddlewares.
+func AuditImpersonation() gin.HandlerFunc {
+	auditRepo := repository.NewImpersonationAuditRepository()
+	return func(c *gin.Context) {
+		c.Next()
+
+		claims, ok := GetUserClaimsFromContext(c)
+		if !ok || !claims.IsImpersonated() {
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+
+		audit := &models.ImpersonationAudit{
+			ActorID:    claims.ActorID,
+			UserID:     claims.UserID,
+			Action:     "WRITE",
+			Method:     c.Request.Method,
+			Path:       c.FullPath(),
+			StatusCode: c.Writer.Status(),
+			IPAddress:  c.ClientIP(),
+		}
+		if err := auditRepo.CreateImpersonationAudit(audit); err != nil {
+			// The request has already been handled, keep a trace of it in the logs at least
+			log.Printf("impersonation: actor %d as user %d: %s %s (%d): %v", audit.ActorID, audit.UserID, audit.Method, audit.Path, audit.StatusCode, err)
+		}
+	}
+}
+
+// RejectImpersonation rejects requests made with an impersonation token, for operations only the user themselves may perform.
+// It must run after Authmiddlewares.
+func RejectImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetUserClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authorization header required"})
+			return
+		}
+		if claims.IsImpersonated() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Forbidden: Not allowed while impersonating a user"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"sama/sama-backend-2025/src/repository"
 	"sama/sama-backend-2025/src/utils" // Adjust import path
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +13,8 @@ import (
 const (
 	// UserContextKey is the key to store user claims in the Gin context.
 	UserContextKey = "userClaims"
+	// TenantScopeContextKey is the key to store the tenant scope of the caller in the Gin context.
+	TenantScopeContextKey = "tenantScope"
 )
 
 // Authmiddlewares validates JWT tokens and injects user claims into the Gin context.
@@ -36,8 +39,9 @@ func Authmiddlewares(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		// Store claims in Gin context
+		// Store claims in Gin context, with the scope limiting the queries to the caller's school
 		c.Set(UserContextKey, claims)
+		c.Set(TenantScopeContextKey, repository.NewTenantScope(claims).AllSchools())
 		c.Next() // Proceed to the next handler
 	}
 }
@@ -51,3 +55,14 @@ func GetUserClaimsFromContext(c *gin.Context) (*utils.Claims, bool) {
 	userClaims, ok := claims.(*utils.Claims)
 	return userClaims, ok
 }
+
+// GetTenantScopeFromContext retrieves the tenant scope of the caller from the Gin context.
+// Requests that weren't authenticated get the zero scope, which matches no school.
+func GetTenantScopeFromContext(c *gin.Context) repository.TenantScope {
+	scope, ok := c.Get(TenantScopeContextKey)
+	if !ok {
+		return repository.TenantScope{}
+	}
+	tenantScope, _ := scope.(repository.TenantScope)
+	return tenantScope
+}
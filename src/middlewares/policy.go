@@ -0,0 +1,268 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/repository"
+	"sama/sama-backend-2025/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Resource holds the attributes of a model that the authorization rules look at.
+type Resource struct {
+	SchoolID  uint
+	OwnerID   uint
+	TeacherID uint
+	Role      string
+	Status    string
+	Shared    bool
+}
+
+// SchoolResource describes a school addressed only by its ID, e.g. from a path parameter.
+func SchoolResource(schoolID uint) Resource {
+	return Resource{SchoolID: schoolID}
+}
+
+// UserResource describes a user addressed only by its ID, e.g. from a path parameter.
+func UserResource(userID uint) Resource {
+	return Resource{OwnerID: userID}
+}
+
+// condition is a predicate on the caller and the resource an action is performed on.
+type condition func(claims *utils.Claims, res Resource) bool
+
+// rule grants an action to the given roles when all of its conditions hold.
+type rule struct {
+	roles []string
+	when  []condition
+}
+
+func sameSchool(claims *utils.Claims, res Resource) bool {
+	return res.SchoolID != 0 && claims.SchoolID == res.SchoolID
+}
+
+func isOwner(claims *utils.Claims, res Resource) bool {
+	return res.OwnerID != 0 && claims.UserID == res.OwnerID
+}
+
+func isAssignedTeacher(claims *utils.Claims, res Resource) bool {
+	return res.TeacherID != 0 && claims.UserID == res.TeacherID
+}
+
+// guardianOf reports whether a guardian is linked to a student. Tests replace it to run the rules without a database.
+var guardianOf = func(guardianID, studentID uint) (bool, error) {
+	return repository.NewGuardianRepository().IsGuardianOf(guardianID, studentID)
+}
+
+// isGuardianOfOwner looks up whether the caller is a guardian linked to the student owning the resource.
+func isGuardianOfOwner(claims *utils.Claims, res Resource) bool {
+	if res.OwnerID == 0 {
+		return false
+	}
+	linked, err := guardianOf(claims.UserID, res.OwnerID)
+	return err == nil && linked
+}
+
+func isShared(claims *utils.Claims, res Resource) bool {
+	return res.Shared
+}
+
+func statusIn(statuses ...string) condition {
+	return func(claims *utils.Claims, res Resource) bool {
+		return slices.Contains(statuses, res.Status)
+	}
+}
+
+func targetRoleIn(roles ...string) condition {
+	return func(claims *utils.Claims, res Resource) bool {
+		return slices.Contains(roles, res.Role)
+	}
+}
+
+// samaOnly is the rule letting the Sama Crew perform an action on any resource.
+var samaOnly = rule{roles: []string{"SAMA"}}
+
+// adminOfSchool is the rule letting an ADMIN perform an action within their own school.
+var adminOfSchool = rule{roles: []string{"ADMIN"}, when: []condition{sameSchool}}
+
+// policies maps every action to the rules granting it. An action is allowed as soon as one rule matches,
+// and an action missing from the table is always denied.
+var policies = map[string][]rule{
+	// Schools
+	"school:create":         {samaOnly},
+	"school:list":           {samaOnly},
+	"school:read":           {samaOnly, {roles: []string{"ADMIN", "TCH", "STD"}, when: []condition{sameSchool}}},
+	"school:update":         {samaOnly, adminOfSchool},
+	"school:delete":         {samaOnly, adminOfSchool},
+	"school:archive":        {samaOnly},
+	"school:restore":        {samaOnly},
+	"school:semester":       {samaOnly, adminOfSchool},
+	"school:list-users":     {samaOnly, {roles: []string{"ADMIN", "TCH", "STD"}, when: []condition{sameSchool}}},
+	"school:import-users":   {samaOnly, adminOfSchool},
+	"school:statistic":      {samaOnly, {roles: []string{"ADMIN", "TCH"}, when: []condition{sameSchool}}},
+	"school:settings:read":  {samaOnly, {roles: []string{"ADMIN", "TCH", "STD"}, when: []condition{sameSchool}}},
+	"school:settings:write": {samaOnly, adminOfSchool},
+	"school:sso":            {samaOnly, adminOfSchool},
+
+	// Invitation codes
+	"invitation:create": {samaOnly, adminOfSchool},
+	"invitation:list":   {samaOnly, adminOfSchool},
+	"invitation:read":   {samaOnly, adminOfSchool},
+	"invitation:revoke": {samaOnly, adminOfSchool},
+	"invitation:email":  {samaOnly, adminOfSchool},
+
+	// Users
+	"user:update": {
+		samaOnly,
+		{roles: []string{"STD", "TCH", "ADMIN", "GRD"}, when: []condition{isOwner}},
+		{roles: []string{"ADMIN"}, when: []condition{sameSchool, targetRoleIn("STD", "TCH")}},
+	},
+	"user:delete": {
+		samaOnly,
+		{roles: []string{"STD", "TCH", "ADMIN", "GRD"}, when: []condition{isOwner}},
+		{roles: []string{"ADMIN"}, when: []condition{sameSchool, targetRoleIn("STD", "TCH")}},
+	},
+	"user:read": {
+		{roles: []string{"SAMA", "ADMIN", "TCH", "STD"}},
+		{roles: []string{"GRD"}, when: []condition{isOwner}},
+		{roles: []string{"GRD"}, when: []condition{isGuardianOfOwner}},
+	},
+	"user:sessions":    {samaOnly, adminOfSchool},
+	"user:impersonate": {samaOnly},
+	"user:status":      {samaOnly, {roles: []string{"ADMIN"}, when: []condition{sameSchool, targetRoleIn("STD", "TCH")}}},
+	"user:transfer":    {samaOnly, {roles: []string{"ADMIN"}, when: []condition{sameSchool, targetRoleIn("STD")}}},
+
+	// Guardians
+	"guardian:link":          {samaOnly, adminOfSchool},
+	"guardian:list-students": {samaOnly, adminOfSchool, {roles: []string{"GRD"}, when: []condition{isOwner}}},
+
+	// Student groups
+	"group:create":   {samaOnly, {roles: []string{"ADMIN", "TCH"}, when: []condition{sameSchool}}},
+	"group:list":     {samaOnly, {roles: []string{"ADMIN", "TCH"}, when: []condition{sameSchool}}},
+	"group:list-all": {samaOnly, adminOfSchool},
+	"group:read": {
+		samaOnly,
+		adminOfSchool,
+		{roles: []string{"TCH"}, when: []condition{sameSchool, isOwner}},
+		{roles: []string{"TCH"}, when: []condition{sameSchool, isShared}},
+	},
+	"group:update": {samaOnly, adminOfSchool, {roles: []string{"TCH"}, when: []condition{sameSchool, isOwner}}},
+	"group:delete": {samaOnly, adminOfSchool, {roles: []string{"TCH"}, when: []condition{sameSchool, isOwner}}},
+
+	// Transcripts
+	"transcript:read":  {samaOnly, {roles: []string{"ADMIN", "TCH"}, when: []condition{sameSchool}}, {roles: []string{"STD"}, when: []condition{isOwner}}},
+	"transcript:issue": {samaOnly, {roles: []string{"ADMIN", "TCH"}, when: []condition{sameSchool}}, {roles: []string{"STD"}, when: []condition{isOwner}}},
+
+	// Activities
+	"activity:create": {{roles: []string{"SAMA", "ADMIN", "TCH"}}},
+	"activity:list":   {{roles: []string{"SAMA", "ADMIN", "TCH", "STD"}}},
+	"activity:read":   {samaOnly, {roles: []string{"ADMIN", "TCH", "STD"}, when: []condition{sameSchool}}},
+	"activity:update": {samaOnly, {roles: []string{"ADMIN", "TCH"}, when: []condition{isOwner}}},
+	"activity:delete": {samaOnly, {roles: []string{"ADMIN", "TCH"}, when: []condition{isOwner}}},
+
+	// Records
+	"record:create": {{roles: []string{"STD"}}},
+	"record:list":   {{roles: []string{"SAMA", "ADMIN", "TCH", "STD", "GRD"}}},
+	"record:read": {
+		samaOnly,
+		{roles: []string{"ADMIN", "TCH"}, when: []condition{sameSchool}},
+		{roles: []string{"STD"}, when: []condition{isOwner}},
+		{roles: []string{"GRD"}, when: []condition{isGuardianOfOwner}},
+	},
+	"record:update": {
+		samaOnly,
+		adminOfSchool,
+		{roles: []string{"TCH"}, when: []condition{sameSchool, statusIn("CREATED", "SENDED")}},
+		{roles: []string{"STD"}, when: []condition{isOwner}},
+	},
+	"record:delete":      {samaOnly, {roles: []string{"ADMIN", "TCH"}, when: []condition{sameSchool}}},
+	"record:send":        {samaOnly, adminOfSchool, {roles: []string{"STD"}, when: []condition{isOwner, statusIn("CREATED")}}},
+	"record:unsend":      {samaOnly, adminOfSchool, {roles: []string{"STD"}, when: []condition{isOwner, statusIn("SENDED")}}},
+	"record:approve":     {samaOnly, adminOfSchool, {roles: []string{"TCH"}, when: []condition{isAssignedTeacher, statusIn("SENDED")}}},
+	"record:reject":      {samaOnly, adminOfSchool, {roles: []string{"TCH"}, when: []condition{isAssignedTeacher, statusIn("SENDED")}}},
+	"record:acknowledge": {{roles: []string{"GRD"}, when: []condition{isGuardianOfOwner}}},
+
+	// Email templates
+	"email-template:preview": {{roles: []string{"SAMA", "ADMIN"}}},
+
+	// Images
+	"image:upload": {{roles: []string{"SAMA", "ADMIN", "TCH", "STD"}}},
+}
+
+// Can reports whether the caller may perform the action on the resource.
+// The resource is either a Resource, a pointer to a supported model, or nil for actions that are granted by role only.
+func Can(claims *utils.Claims, action string, resource interface{}) bool {
+	if claims == nil {
+		return false
+	}
+
+	res, ok := toResource(resource)
+	if !ok {
+		return false
+	}
+
+	for _, r := range policies[action] {
+		if !slices.Contains(r.roles, claims.Role) {
+			continue
+		}
+		granted := true
+		for _, cond := range r.when {
+			if !cond(claims, res) {
+				granted = false
+				break
+			}
+		}
+		if granted {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission rejects requests whose caller is not granted the action by role alone.
+// It must run after Authmiddlewares; actions depending on a resource are checked in the controllers with Can.
+func RequirePermission(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetUserClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authorization header required"})
+			return
+		}
+		if !Can(claims, action, nil) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Forbidden: Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// toResource extracts the attributes used by the rules from the supported models.
+func toResource(resource interface{}) (Resource, bool) {
+	switch v := resource.(type) {
+	case nil:
+		return Resource{}, true
+	case Resource:
+		return v, true
+	case *models.School:
+		return Resource{SchoolID: v.ID}, true
+	case *models.User:
+		return Resource{SchoolID: v.SchoolID, OwnerID: v.ID, Role: v.Role}, true
+	case *models.Activity:
+		return Resource{SchoolID: v.SchoolID, OwnerID: v.OwnerID}, true
+	case *models.Record:
+		res := Resource{SchoolID: v.Activity.SchoolID, OwnerID: v.StudentID, Status: v.Status}
+		if v.TeacherID != nil {
+			res.TeacherID = *v.TeacherID
+		}
+		return res, true
+	case *models.StudentGroup:
+		return Resource{SchoolID: v.SchoolID, OwnerID: v.OwnerID, Shared: v.IsShared}, true
+	case *models.InvitationCode:
+		return Resource{SchoolID: v.SchoolID, OwnerID: v.CreatedByID}, true
+	default:
+		return Resource{}, false
+	}
+}
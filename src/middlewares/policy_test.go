@@ -0,0 +1,265 @@
+package middlewares
+
+import (
+	"slices"
+	"testing"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/utils"
+)
+
+const (
+	mySchoolID    uint = 1
+	otherSchoolID uint = 2
+)
+
+// callers are one user of every role, all in school 1 except the Sama Crew member.
+// The guardian is linked to the student.
+var callers = []*utils.Claims{
+	{UserID: 1, Role: "SAMA"},
+	{UserID: 10, SchoolID: mySchoolID, Role: "ADMIN"},
+	{UserID: 20, SchoolID: mySchoolID, Role: "TCH"},
+	{UserID: 30, SchoolID: mySchoolID, Role: "STD"},
+	{UserID: 40, SchoolID: mySchoolID, Role: "GRD"},
+}
+
+var (
+	admin             = &models.User{ID: 10, SchoolID: mySchoolID, Role: "ADMIN"}
+	otherAdmin        = &models.User{ID: 11, SchoolID: mySchoolID, Role: "ADMIN"}
+	teacher           = &models.User{ID: 20, SchoolID: mySchoolID, Role: "TCH"}
+	student           = &models.User{ID: 30, SchoolID: mySchoolID, Role: "STD"}
+	classmate         = &models.User{ID: 31, SchoolID: mySchoolID, Role: "STD"}
+	guardian          = &models.User{ID: 40, SchoolID: mySchoolID, Role: "GRD"}
+	foreignStudent    = &models.User{ID: 50, SchoolID: otherSchoolID, Role: "STD"}
+	foreignTeacher    = &models.User{ID: 60, SchoolID: otherSchoolID, Role: "TCH"}
+	foreignGuardian   = &models.User{ID: 70, SchoolID: otherSchoolID, Role: "GRD"}
+	mySchool          = &models.School{ID: mySchoolID}
+	otherSchool       = &models.School{ID: otherSchoolID}
+	teacherActivity   = &models.Activity{SchoolID: mySchoolID, OwnerID: teacher.ID}
+	adminActivity     = &models.Activity{SchoolID: mySchoolID, OwnerID: admin.ID}
+	foreignActivity   = &models.Activity{SchoolID: otherSchoolID, OwnerID: foreignTeacher.ID}
+	teacherGroup      = &models.StudentGroup{SchoolID: mySchoolID, OwnerID: teacher.ID}
+	colleagueGroup    = &models.StudentGroup{SchoolID: mySchoolID, OwnerID: 21}
+	sharedGroup       = &models.StudentGroup{SchoolID: mySchoolID, OwnerID: 21, IsShared: true}
+	foreignGroup      = &models.StudentGroup{SchoolID: otherSchoolID, OwnerID: foreignTeacher.ID, IsShared: true}
+	myInvitation      = &models.InvitationCode{SchoolID: mySchoolID, CreatedByID: admin.ID}
+	foreignInvitation = &models.InvitationCode{SchoolID: otherSchoolID, CreatedByID: 80}
+)
+
+// record returns a record of the student in the given school, sent to the given teacher.
+func record(schoolID, studentID, teacherID uint, status string) *models.Record {
+	return &models.Record{
+		StudentID: studentID,
+		TeacherID: &teacherID,
+		Status:    status,
+		Activity:  models.Activity{SchoolID: schoolID},
+	}
+}
+
+func roles(roles ...string) []string {
+	return roles
+}
+
+// policyCase is an action on a resource with the roles of the callers allowed to perform it.
+type policyCase struct {
+	name     string
+	action   string
+	resource interface{}
+	allowed  []string
+}
+
+var policyCases = []policyCase{
+	// Schools
+	{"no resource", "school:create", nil, roles("SAMA")},
+	{"no resource", "school:list", nil, roles("SAMA")},
+	{"same school", "school:read", mySchool, roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"other school", "school:read", otherSchool, roles("SAMA")},
+	{"same school", "school:update", mySchool, roles("SAMA", "ADMIN")},
+	{"other school", "school:update", otherSchool, roles("SAMA")},
+	{"same school", "school:delete", mySchool, roles("SAMA", "ADMIN")},
+	{"other school", "school:delete", otherSchool, roles("SAMA")},
+	{"same school", "school:archive", mySchool, roles("SAMA")},
+	{"same school", "school:restore", mySchool, roles("SAMA")},
+	{"same school", "school:semester", mySchool, roles("SAMA", "ADMIN")},
+	{"other school", "school:semester", otherSchool, roles("SAMA")},
+	{"same school", "school:list-users", mySchool, roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"other school", "school:list-users", otherSchool, roles("SAMA")},
+	{"same school", "school:import-users", mySchool, roles("SAMA", "ADMIN")},
+	{"other school", "school:import-users", otherSchool, roles("SAMA")},
+	{"same school", "school:statistic", mySchool, roles("SAMA", "ADMIN", "TCH")},
+	{"other school", "school:statistic", otherSchool, roles("SAMA")},
+	{"same school", "school:settings:read", mySchool, roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"other school", "school:settings:read", otherSchool, roles("SAMA")},
+	{"same school", "school:settings:write", mySchool, roles("SAMA", "ADMIN")},
+	{"other school", "school:settings:write", otherSchool, roles("SAMA")},
+	{"same school", "school:sso", mySchool, roles("SAMA", "ADMIN")},
+	{"other school", "school:sso", otherSchool, roles("SAMA")},
+
+	// Invitation codes
+	{"same school", "invitation:create", mySchool, roles("SAMA", "ADMIN")},
+	{"other school", "invitation:create", otherSchool, roles("SAMA")},
+	{"same school", "invitation:list", mySchool, roles("SAMA", "ADMIN")},
+	{"other school", "invitation:list", otherSchool, roles("SAMA")},
+	{"same school", "invitation:read", myInvitation, roles("SAMA", "ADMIN")},
+	{"other school", "invitation:read", foreignInvitation, roles("SAMA")},
+	{"same school", "invitation:revoke", myInvitation, roles("SAMA", "ADMIN")},
+	{"other school", "invitation:revoke", foreignInvitation, roles("SAMA")},
+	{"same school", "invitation:email", myInvitation, roles("SAMA", "ADMIN")},
+	{"other school", "invitation:email", foreignInvitation, roles("SAMA")},
+
+	// Users
+	{"own profile", "user:update", student, roles("SAMA", "ADMIN", "STD")},
+	{"teacher of same school", "user:update", teacher, roles("SAMA", "ADMIN", "TCH")},
+	{"student of same school", "user:update", classmate, roles("SAMA", "ADMIN")},
+	{"admin of same school", "user:update", otherAdmin, roles("SAMA")},
+	{"own admin profile", "user:update", admin, roles("SAMA", "ADMIN")},
+	{"own guardian profile", "user:update", guardian, roles("SAMA", "GRD")},
+	{"other school", "user:update", foreignStudent, roles("SAMA")},
+	{"own profile", "user:delete", student, roles("SAMA", "ADMIN", "STD")},
+	{"student of same school", "user:delete", classmate, roles("SAMA", "ADMIN")},
+	{"admin of same school", "user:delete", otherAdmin, roles("SAMA")},
+	{"other school", "user:delete", foreignStudent, roles("SAMA")},
+	{"linked student", "user:read", student, roles("SAMA", "ADMIN", "TCH", "STD", "GRD")},
+	{"unlinked student", "user:read", classmate, roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"own guardian profile", "user:read", guardian, roles("SAMA", "ADMIN", "TCH", "STD", "GRD")},
+	{"same school", "user:sessions", classmate, roles("SAMA", "ADMIN")},
+	{"other school", "user:sessions", foreignStudent, roles("SAMA")},
+	{"same school", "user:impersonate", classmate, roles("SAMA")},
+	{"student of same school", "user:status", classmate, roles("SAMA", "ADMIN")},
+	{"teacher of same school", "user:status", teacher, roles("SAMA", "ADMIN")},
+	{"admin of same school", "user:status", otherAdmin, roles("SAMA")},
+	{"other school", "user:status", foreignStudent, roles("SAMA")},
+	{"student of same school", "user:transfer", classmate, roles("SAMA", "ADMIN")},
+	{"teacher of same school", "user:transfer", teacher, roles("SAMA")},
+	{"other school", "user:transfer", foreignStudent, roles("SAMA")},
+
+	// Guardians
+	{"same school", "guardian:link", guardian, roles("SAMA", "ADMIN")},
+	{"other school", "guardian:link", foreignGuardian, roles("SAMA")},
+	{"own links", "guardian:list-students", guardian, roles("SAMA", "ADMIN", "GRD")},
+	{"other school", "guardian:list-students", foreignGuardian, roles("SAMA")},
+
+	// Student groups
+	{"same school", "group:create", mySchool, roles("SAMA", "ADMIN", "TCH")},
+	{"other school", "group:create", otherSchool, roles("SAMA")},
+	{"same school", "group:list", mySchool, roles("SAMA", "ADMIN", "TCH")},
+	{"other school", "group:list", otherSchool, roles("SAMA")},
+	{"same school", "group:list-all", mySchool, roles("SAMA", "ADMIN")},
+	{"other school", "group:list-all", otherSchool, roles("SAMA")},
+	{"own group", "group:read", teacherGroup, roles("SAMA", "ADMIN", "TCH")},
+	{"colleague group", "group:read", colleagueGroup, roles("SAMA", "ADMIN")},
+	{"shared group", "group:read", sharedGroup, roles("SAMA", "ADMIN", "TCH")},
+	{"other school", "group:read", foreignGroup, roles("SAMA")},
+	{"own group", "group:update", teacherGroup, roles("SAMA", "ADMIN", "TCH")},
+	{"shared group", "group:update", sharedGroup, roles("SAMA", "ADMIN")},
+	{"other school", "group:update", foreignGroup, roles("SAMA")},
+	{"own group", "group:delete", teacherGroup, roles("SAMA", "ADMIN", "TCH")},
+	{"shared group", "group:delete", sharedGroup, roles("SAMA", "ADMIN")},
+	{"other school", "group:delete", foreignGroup, roles("SAMA")},
+
+	// Transcripts
+	{"own transcript", "transcript:read", student, roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"same school", "transcript:read", classmate, roles("SAMA", "ADMIN", "TCH")},
+	{"other school", "transcript:read", foreignStudent, roles("SAMA")},
+	{"own transcript", "transcript:issue", student, roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"same school", "transcript:issue", classmate, roles("SAMA", "ADMIN", "TCH")},
+	{"other school", "transcript:issue", foreignStudent, roles("SAMA")},
+
+	// Activities
+	{"no resource", "activity:create", nil, roles("SAMA", "ADMIN", "TCH")},
+	{"no resource", "activity:list", nil, roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"same school", "activity:read", teacherActivity, roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"other school", "activity:read", foreignActivity, roles("SAMA")},
+	{"listing same school", "activity:read", SchoolResource(mySchoolID), roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"listing other school", "activity:read", SchoolResource(otherSchoolID), roles("SAMA")},
+	{"teacher activity", "activity:update", teacherActivity, roles("SAMA", "TCH")},
+	{"admin activity", "activity:update", adminActivity, roles("SAMA", "ADMIN")},
+	{"other school", "activity:update", foreignActivity, roles("SAMA")},
+	{"teacher activity", "activity:delete", teacherActivity, roles("SAMA", "TCH")},
+	{"admin activity", "activity:delete", adminActivity, roles("SAMA", "ADMIN")},
+	{"other school", "activity:delete", foreignActivity, roles("SAMA")},
+
+	// Records
+	{"no resource", "record:create", nil, roles("STD")},
+	{"no resource", "record:list", nil, roles("SAMA", "ADMIN", "TCH", "STD", "GRD")},
+	{"own record", "record:read", record(mySchoolID, student.ID, teacher.ID, "SENDED"), roles("SAMA", "ADMIN", "TCH", "STD", "GRD")},
+	{"classmate record", "record:read", record(mySchoolID, classmate.ID, teacher.ID, "SENDED"), roles("SAMA", "ADMIN", "TCH")},
+	{"other school", "record:read", record(otherSchoolID, foreignStudent.ID, foreignTeacher.ID, "SENDED"), roles("SAMA")},
+	{"created record", "record:update", record(mySchoolID, student.ID, teacher.ID, "CREATED"), roles("SAMA", "ADMIN", "TCH", "STD")},
+	{"approved record", "record:update", record(mySchoolID, student.ID, teacher.ID, "APPROVED"), roles("SAMA", "ADMIN", "STD")},
+	{"classmate record", "record:update", record(mySchoolID, classmate.ID, teacher.ID, "APPROVED"), roles("SAMA", "ADMIN")},
+	{"other school", "record:update", record(otherSchoolID, foreignStudent.ID, foreignTeacher.ID, "CREATED"), roles("SAMA")},
+	{"own record", "record:delete", record(mySchoolID, student.ID, teacher.ID, "CREATED"), roles("SAMA", "ADMIN", "TCH")},
+	{"other school", "record:delete", record(otherSchoolID, foreignStudent.ID, foreignTeacher.ID, "CREATED"), roles("SAMA")},
+	{"created record", "record:send", record(mySchoolID, student.ID, teacher.ID, "CREATED"), roles("SAMA", "ADMIN", "STD")},
+	{"sent record", "record:send", record(mySchoolID, student.ID, teacher.ID, "SENDED"), roles("SAMA", "ADMIN")},
+	{"classmate record", "record:send", record(mySchoolID, classmate.ID, teacher.ID, "CREATED"), roles("SAMA", "ADMIN")},
+	{"other school", "record:send", record(otherSchoolID, foreignStudent.ID, foreignTeacher.ID, "CREATED"), roles("SAMA")},
+	{"sent record", "record:unsend", record(mySchoolID, student.ID, teacher.ID, "SENDED"), roles("SAMA", "ADMIN", "STD")},
+	{"created record", "record:unsend", record(mySchoolID, student.ID, teacher.ID, "CREATED"), roles("SAMA", "ADMIN")},
+	{"assigned teacher", "record:approve", record(mySchoolID, student.ID, teacher.ID, "SENDED"), roles("SAMA", "ADMIN", "TCH")},
+	{"other teacher", "record:approve", record(mySchoolID, student.ID, 21, "SENDED"), roles("SAMA", "ADMIN")},
+	{"approved record", "record:approve", record(mySchoolID, student.ID, teacher.ID, "APPROVED"), roles("SAMA", "ADMIN")},
+	{"other school", "record:approve", record(otherSchoolID, foreignStudent.ID, foreignTeacher.ID, "SENDED"), roles("SAMA")},
+	{"assigned teacher", "record:reject", record(mySchoolID, student.ID, teacher.ID, "SENDED"), roles("SAMA", "ADMIN", "TCH")},
+	{"other teacher", "record:reject", record(mySchoolID, student.ID, 21, "SENDED"), roles("SAMA", "ADMIN")},
+	{"other school", "record:reject", record(otherSchoolID, foreignStudent.ID, foreignTeacher.ID, "SENDED"), roles("SAMA")},
+	{"linked student", "record:acknowledge", record(mySchoolID, student.ID, teacher.ID, "APPROVED"), roles("GRD")},
+	{"unlinked student", "record:acknowledge", record(mySchoolID, classmate.ID, teacher.ID, "APPROVED"), roles()},
+
+	// Email templates and images
+	{"no resource", "email-template:preview", nil, roles("SAMA", "ADMIN")},
+	{"no resource", "image:upload", nil, roles("SAMA", "ADMIN", "TCH", "STD")},
+
+	// Actions missing from the table
+	{"no resource", "school:explode", nil, roles()},
+	{"same school", "school:explode", mySchool, roles()},
+}
+
+// linkGuardian makes the guardian caller linked to the student caller for the duration of the test.
+func linkGuardian(t *testing.T) {
+	previous := guardianOf
+	guardianOf = func(guardianID, studentID uint) (bool, error) {
+		return guardianID == guardian.ID && studentID == student.ID, nil
+	}
+	t.Cleanup(func() { guardianOf = previous })
+}
+
+func TestCan(t *testing.T) {
+	linkGuardian(t)
+
+	for _, tc := range policyCases {
+		for _, claims := range callers {
+			want := slices.Contains(tc.allowed, claims.Role)
+			t.Run(tc.action+"/"+tc.name+"/"+claims.Role, func(t *testing.T) {
+				if got := Can(claims, tc.action, tc.resource); got != want {
+					t.Errorf("Can(%s, %s, %s) = %v, want %v", claims.Role, tc.action, tc.name, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestCanCoversEveryAction(t *testing.T) {
+	for action := range policies {
+		covered := slices.ContainsFunc(policyCases, func(tc policyCase) bool {
+			return tc.action == action
+		})
+		if !covered {
+			t.Errorf("action %s has no test case", action)
+		}
+	}
+}
+
+func TestCanDeniesWithoutClaims(t *testing.T) {
+	if Can(nil, "school:list", nil) {
+		t.Error("Can without claims = true, want false")
+	}
+}
+
+func TestCanDeniesUnsupportedResource(t *testing.T) {
+	sama := callers[0]
+	if Can(sama, "school:read", "school 1") {
+		t.Error("Can on an unsupported resource = true, want false")
+	}
+}
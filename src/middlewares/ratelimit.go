@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"sama/sama-backend-2025/src/pkg"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitByIP rejects a client IP that makes more than limit requests per window to the routes of name.
+func RateLimitByIP(store pkg.RateLimitStore, name string, limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count, err := store.Increment(c.Request.Context(), name+":ip:"+c.ClientIP(), window)
+		if err != nil {
+			// Let the request through, a broken store must not lock everyone out
+			c.Next()
+			return
+		}
+
+		if count > limit {
+			c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "Too many requests, please try again later"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -22,6 +22,7 @@ type Activity struct {
 
 	ExclusiveClassrooms []string `json:"exclusive_classroom" validate:"required" gorm:"-:all"`
 	ExclusiveStudentIDs []uint   `json:"exclusive_student_ids" validate:"required" gorm:"-:all"`
+	ExclusiveGroupIDs   []uint   `json:"exclusive_group_ids" gorm:"-:all"`
 
 	OwnerID uint `json:"owner_id" gorm:"index" validate:"required,gt=0"` // ID of the creator (User)
 
@@ -33,13 +34,16 @@ type Activity struct {
 	CanExceedLimit bool   `json:"can_exceed_limit" validate:"required"`
 	UpdateProtocol string `json:"update_protocol,omitempty" validate:"required,oneof=RE_EVALUATE_ALL_RECORDS IGNORE_PAST_RECORDS"`
 
+	RequiresGuardianConfirmation bool `json:"requires_guardian_confirmation"` // Records must be acknowledged by a guardian of the student
+
 	SchoolYear uint `json:"school_year" validate:"required,gt=0"`
 	Semester   uint `json:"semester" validate:"required,gt=0"`
 
-	School                    School      `json:"-"`
-	Owner                     User        `json:"-"`
-	ExclusiveStudentObjects   []User      `json:"-" gorm:"many2many:activity_exclusive_student_ids"`
-	ExclusiveClassroomObjects []Classroom `json:"-" gorm:"many2many:activity_exclusive_classroom"`
+	School                    School         `json:"-"`
+	Owner                     User           `json:"-"`
+	ExclusiveStudentObjects   []User         `json:"-" gorm:"many2many:activity_exclusive_student_ids"`
+	ExclusiveClassroomObjects []Classroom    `json:"-" gorm:"many2many:activity_exclusive_classroom"`
+	ExclusiveGroupObjects     []StudentGroup `json:"-" gorm:"many2many:activity_exclusive_groups"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -64,6 +68,11 @@ func (a *Activity) AfterFind(tx *gorm.DB) (err error) {
 	for i, obj := range a.ExclusiveStudentObjects {
 		a.ExclusiveStudentIDs[i] = obj.ID
 	}
+
+	a.ExclusiveGroupIDs = make([]uint, len(a.ExclusiveGroupObjects))
+	for i, obj := range a.ExclusiveGroupObjects {
+		a.ExclusiveGroupIDs[i] = obj.ID
+	}
 	return nil
 }
 
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// EmailVerification is a code sent to prove the ownership of an email address, mapped to a PostgreSQL table.
+// A user has at most one pending verification, for either their current email or the one they are changing to.
+type EmailVerification struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex"`
+	Email     string    `json:"email"` // Address the code was sent to, it becomes the email of the user once confirmed
+	CodeHash  string    `json:"-"`     // bcrypt hash, the code itself is only sent by email
+	ExpiresAt time.Time `json:"expires_at"`
+	Attempts  int       `json:"attempts"` // Wrong codes entered, the verification is discarded once the limit is reached
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the EmailVerification model.
+func (EmailVerification) TableName() string {
+	return "email_verifications"
+}
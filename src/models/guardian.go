@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GuardianLink gives a guardian read access to a student, mapped to a PostgreSQL table.
+type GuardianLink struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	GuardianID  uint `json:"guardian_id" gorm:"uniqueIndex:idx_guardian_student"`
+	StudentID   uint `json:"student_id" gorm:"uniqueIndex:idx_guardian_student;index"`
+	CreatedByID uint `json:"created_by_id"`
+
+	Student User `json:"student,omitzero" gorm:"foreignKey:StudentID;references:ID"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string"` // Set when the school is archived
+}
+
+// TableName specifies the table name for the GuardianLink model.
+func (GuardianLink) TableName() string {
+	return "guardian_links"
+}
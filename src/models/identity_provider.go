@@ -0,0 +1,71 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IdentityProvider is an OpenID Connect identity provider, e.g. Google Workspace or Microsoft Entra ID,
+// that users of a school can sign in with, mapped to a PostgreSQL table.
+type IdentityProvider struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	SchoolID uint `json:"school_id" gorm:"index" validate:"required"`
+
+	Name           string   `json:"name" validate:"required" example:"Google Workspace"` // Shown on the sign-in button
+	IssuerURL      string   `json:"issuer_url" validate:"required,url" example:"https://accounts.google.com"`
+	ClientID       string   `json:"client_id" validate:"required"`
+	ClientSecret   string   `json:"-" validate:"required"`
+	AllowedDomains []string `json:"allowed_domains" gorm:"serializer:json" validate:"dive,fqdn"` // Email domains accepted from the provider, any when empty
+	Enabled        bool     `json:"enabled"`
+
+	// Users signing in for the first time are created with the default role when enabled
+	JITProvisioning bool   `json:"jit_provisioning"`
+	DefaultRole     string `json:"default_role" validate:"required,oneof=STD TCH GRD"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string"` // Set when the school is archived
+}
+
+// TableName specifies the table name for the IdentityProvider model.
+func (IdentityProvider) TableName() string {
+	return "identity_providers"
+}
+
+// IsDomainAllowed reports whether users with the given email may sign in with the provider.
+func (p *IdentityProvider) IsDomainAllowed(email string) bool {
+	if len(p.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range p.AllowedDomains {
+		if strings.ToLower(allowed) == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// SSOLoginState keeps what is needed to finish a sign-in started at an identity provider.
+// It is looked up by the hash of the state parameter and can only be used once.
+type SSOLoginState struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	StateHash    string    `json:"-" gorm:"uniqueIndex"`
+	ProviderID   uint      `json:"provider_id"`
+	Nonce        string    `json:"-"`
+	CodeVerifier string    `json:"-"`
+	ExpiresAt    time.Time `json:"expires_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the SSOLoginState model.
+func (SSOLoginState) TableName() string {
+	return "sso_login_states"
+}
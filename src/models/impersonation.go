@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ImpersonationAudit is an entry of the audit trail of SAMA crew acting as users, mapped to a PostgreSQL table.
+// START entries are written when a token is minted, WRITE entries for every write request made with it.
+type ImpersonationAudit struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	ActorID    uint   `json:"actor_id" gorm:"index"` // The SAMA crew member
+	UserID     uint   `json:"user_id" gorm:"index"`  // The impersonated user
+	Action     string `json:"action" example:"WRITE"`
+	Method     string `json:"method,omitempty" example:"PUT"`
+	Path       string `json:"path,omitempty" example:"/api/v1/record/:id"`
+	StatusCode int    `json:"status_code,omitempty" example:"200"`
+	IPAddress  string `json:"ip_address" example:"203.0.113.10"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the ImpersonationAudit model.
+func (ImpersonationAudit) TableName() string {
+	return "impersonation_audits"
+}
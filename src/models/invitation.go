@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// InvitationCode lets users register into a school, mapped to a PostgreSQL table.
+// The role, school and classroom of the new user are taken from the code.
+type InvitationCode struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	Code        string     `json:"code" gorm:"uniqueIndex"`
+	SchoolID    uint       `json:"school_id" gorm:"index" validate:"required"`
+	Role        string     `json:"role" validate:"required,oneof=STD TCH ADMIN GRD"`
+	Classroom   *string    `json:"classroom,omitempty" validate:"omitempty,classroomregex"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxUses     int        `json:"max_uses" validate:"gte=0"` // 0 means unlimited
+	UsedCount   int        `json:"used_count"`
+	CreatedByID uint       `json:"created_by_id"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+
+	Redemptions []InvitationRedemption `json:"redemptions,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the InvitationCode model.
+func (InvitationCode) TableName() string {
+	return "invitation_codes"
+}
+
+// IsUsable reports whether the code can still be used to register at the given time.
+func (c *InvitationCode) IsUsable(now time.Time) bool {
+	if c.RevokedAt != nil {
+		return false
+	}
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return false
+	}
+	return c.MaxUses == 0 || c.UsedCount < c.MaxUses
+}
+
+// InvitationRedemption tracks which user registered with an invitation code.
+type InvitationRedemption struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	InvitationCodeID uint      `json:"invitation_code_id" gorm:"index"`
+	UserID           uint      `json:"user_id" gorm:"index"`
+	RedeemedAt       time.Time `json:"redeemed_at"`
+}
+
+// TableName specifies the table name for the InvitationRedemption model.
+func (InvitationRedemption) TableName() string {
+	return "invitation_redemptions"
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// NOTIFICATION_TYPE_ENUM defines the events a notification is created for.
+// RECORD_SENT goes to the teacher the record was sent to, RECORD_APPROVED and RECORD_REJECTED to the student owning it,
+// DEADLINE_APPROACHING to students who haven't finished the activity and ACTIVITY_PUBLISHED to the students it is assigned to.
+var NOTIFICATION_TYPE_ENUM = []string{"RECORD_SENT", "RECORD_APPROVED", "RECORD_REJECTED", "DEADLINE_APPROACHING", "ACTIVITY_PUBLISHED"}
+
+// NOTIFICATION_EMAIL_DEFAULTS are the events also sent by email to users who haven't set a preference for them.
+var NOTIFICATION_EMAIL_DEFAULTS = []string{"RECORD_REJECTED", "DEADLINE_APPROACHING"}
+
+// Notification is a message shown to a user in the application, mapped to a PostgreSQL table.
+// Title and body are rendered in the language of the user when the event happens.
+type Notification struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	UserID     uint       `json:"user_id" gorm:"index:idx_notifications_user_created,priority:1"`
+	Type       string     `json:"type" example:"RECORD_REJECTED"`
+	Title      string     `json:"title" example:"Your record was rejected"`
+	Body       string     `json:"body"`
+	ActivityID *uint      `json:"activity_id,omitempty"`
+	RecordID   *uint      `json:"record_id,omitempty"`
+	ReadAt     *time.Time `json:"read_at"` // Not read yet when nil
+
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_notifications_user_created,priority:2"`
+}
+
+// TableName specifies the table name for the Notification model.
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference is the choice of a user to also receive an event by email, mapped to a PostgreSQL table.
+// Events without a preference use NOTIFICATION_EMAIL_DEFAULTS.
+type NotificationPreference struct {
+	UserID uint   `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Type   string `json:"type" gorm:"primaryKey" example:"RECORD_REJECTED"`
+	Email  bool   `json:"email" example:"true"`
+}
+
+// TableName specifies the table name for the NotificationPreference model.
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
@@ -5,8 +5,9 @@ import "time"
 type OTP struct {
 	ID        uint      `json:"id" gorm:"primarykey"`
 	UserID    uint      `json:"user_id"`
-	Code      string    `json:"code"`
+	CodeHash  string    `json:"-"` // bcrypt hash, the code itself is only sent by email
 	ExpiresAt time.Time `json:"expired_at"`
+	Attempts  int       `json:"attempts"` // Wrong codes entered, the OTP is discarded once the limit is reached
 
 	User User `json:"user"`
 }
@@ -16,3 +17,19 @@ type OTP struct {
 func (OTP) TableName() string {
 	return "otps"
 }
+
+// PasswordResetToken lets a user set a new password once after validating an OTP.
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the PasswordResetToken model.
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RateLimitEntry is a counter and lock of the database rate limit store, mapped to a PostgreSQL table.
+type RateLimitEntry struct {
+	Key          string     `gorm:"primaryKey;size:255"`
+	Count        int        `gorm:"not null;default:0"`
+	WindowEndsAt time.Time  `gorm:"index"`
+	LockedUntil  *time.Time `gorm:"index"`
+}
+
+// TableName specifies the table name for the RateLimitEntry model.
+func (RateLimitEntry) TableName() string {
+	return "rate_limit_entries"
+}
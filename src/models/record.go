@@ -23,6 +23,10 @@ type Record struct {
 	StatusLogs StatusLogs `json:"status_logs" gorm:"serializer:json" validate:"required"`
 	Status     string     `json:"status" validate:"required,oneof=CREATED SENDED APPROVED REJECTED"`
 
+	// Set when a guardian confirms a record of an activity requiring parental confirmation
+	GuardianAcknowledgedAt   *time.Time `json:"guardian_acknowledged_at,omitempty"`
+	GuardianAcknowledgedByID *uint      `json:"guardian_acknowledged_by_id,omitempty"`
+
 	Activity Activity `json:"-"`
 	Student  User     `json:"student,omitzero" gorm:"foreignKey:StudentID;references:ID"`
 	Teacher  *User    `json:"teacher,omitzero" gorm:"foreignKey:TeacherID;references:ID"`
@@ -48,3 +52,11 @@ func (Record) TableName() string {
 
 // STATUS_ENUM defines the allowed values for the 'Status' field.
 var STATUS_ENUM = []string{"CREATED", "SENDED", "APPROVED", "REJECTED"}
+
+// PendingRecordCount is the number of records of an activity waiting for the review of a teacher.
+type PendingRecordCount struct {
+	TeacherID    uint   `json:"teacher_id"`
+	ActivityID   uint   `json:"activity_id"`
+	ActivityName string `json:"activity_name"`
+	Count        int    `json:"count"`
+}
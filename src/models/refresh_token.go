@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// RefreshToken tracks an issued refresh token, mapped to a PostgreSQL table.
+// Tokens rotated from the same login share a FamilyID, so a reused token can revoke the whole chain.
+type RefreshToken struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	JTI       string     `json:"jti" gorm:"uniqueIndex"`
+	FamilyID  string     `json:"family_id" gorm:"index"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`    // Set when the token is exchanged for a new one
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // Set on logout, reuse detection or forced revocation
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the RefreshToken model.
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ScheduledJob is the state of a background job shared by every instance of the server, mapped to a PostgreSQL table.
+// An instance runs the job only after claiming it, so it runs once however many instances are up,
+// and the next run is kept across restarts.
+type ScheduledJob struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:100"`
+	NextRunAt time.Time `json:"next_run_at"`
+
+	LockedBy    *string    `json:"locked_by,omitempty"`    // Instance running the job
+	LockedUntil *time.Time `json:"locked_until,omitempty"` // Another instance may take over the job after this, in case the running one died
+
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"` // Empty when the last run succeeded
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the ScheduledJob model.
+func (ScheduledJob) TableName() string {
+	return "scheduled_jobs"
+}
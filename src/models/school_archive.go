@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// SchoolArchive keeps track of a school that has been offboarded.
+// Every row under the school is soft deleted, and its invitation codes revoked, with the same ArchivedAt timestamp,
+// so the whole school can be restored consistently until RestoreDeadline.
+type SchoolArchive struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	SchoolID     uint `json:"school_id" gorm:"index"`
+	ArchivedByID uint `json:"archived_by_id"`
+
+	JSONExportKey string `json:"json_export_key"`
+	XLSXExportKey string `json:"xlsx_export_key"`
+
+	TotalUsers      int `json:"total_users"`
+	TotalActivities int `json:"total_activities"`
+	TotalRecords    int `json:"total_records"`
+
+	ArchivedAt      time.Time  `json:"archived_at"`
+	RestoreDeadline time.Time  `json:"restore_deadline"`
+	RestoredAt      *time.Time `json:"restored_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the SchoolArchive model.
+func (SchoolArchive) TableName() string {
+	return "school_archives"
+}
+
+// SchoolExport is the content of the JSON archive produced when a school is offboarded.
+type SchoolExport struct {
+	ExportedAt  time.Time          `json:"exported_at"`
+	School      School             `json:"school"`
+	Users       []User             `json:"users"`
+	Activities  []Activity         `json:"activities"`
+	Records     []Record           `json:"records"`
+	Attachments []AttachmentObject `json:"attachments"`
+}
+
+// AttachmentObject is an entry of the attachment manifest, pointing to an object in the bucket.
+type AttachmentObject struct {
+	OwnerType string `json:"owner_type"` // SCHOOL, USER, ACTIVITY or RECORD
+	OwnerID   uint   `json:"owner_id"`
+	ObjectKey string `json:"object_key"`
+}
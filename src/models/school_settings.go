@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchoolSettings holds the per-school policies consulted by services, mapped to a PostgreSQL table.
+// A school without a stored row uses DefaultSchoolSettings.
+type SchoolSettings struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	SchoolID uint `json:"school_id" gorm:"uniqueIndex" validate:"required"`
+
+	OTPLifetimeMinutes       int      `json:"otp_lifetime_minutes" validate:"gte=1,lte=60"`
+	AllowedRegistrationRoles []string `json:"allowed_registration_roles" gorm:"serializer:json" validate:"dive,oneof=STD TCH ADMIN GRD"`
+	TeacherSelection         string   `json:"teacher_selection" validate:"required,oneof=ANY BOOKMARKED ACTIVITY_OWNER"`
+	RecordEditPolicy         string   `json:"record_edit_policy" validate:"required,oneof=NEVER UNSENT UNAPPROVED"`
+	GracePeriodDays          int      `json:"grace_period_days" validate:"gte=0,lte=365"`       // Days after an activity deadline where records are still accepted
+	CompletionThreshold      uint     `json:"completion_threshold" validate:"gte=1,lte=100"`    // Percent at which a student counts as finished
+	DefaultLanguage          string   `json:"default_language" validate:"required,oneof=th en"` // Language given to new users who don't pick one
+	AllowedAttachmentTypes   []string `json:"allowed_attachment_types" gorm:"serializer:json" validate:"required,min=1,dive,alphanum,lowercase"`
+	RequireVerifiedEmail     bool     `json:"require_verified_email"` // Students must verify their email before sending records
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string"` // Set when the school is archived
+}
+
+// TableName specifies the table name for the SchoolSettings model.
+func (SchoolSettings) TableName() string {
+	return "school_settings"
+}
+
+// DefaultSchoolSettings returns the settings of a school that has never changed them.
+// The values match the behavior before settings existed.
+func DefaultSchoolSettings(schoolID uint) *SchoolSettings {
+	return &SchoolSettings{
+		SchoolID:                 schoolID,
+		OTPLifetimeMinutes:       5,
+		AllowedRegistrationRoles: []string{"STD", "TCH", "ADMIN", "GRD"},
+		TeacherSelection:         "ANY",
+		RecordEditPolicy:         "UNSENT",
+		GracePeriodDays:          0,
+		CompletionThreshold:      100,
+		DefaultLanguage:          "th",
+		AllowedAttachmentTypes:   []string{"jpg", "jpeg", "png", "gif", "webp"},
+		RequireVerifiedEmail:     false,
+	}
+}
+
+// CanRegister reports whether users may self-register with the given role.
+func (s *SchoolSettings) CanRegister(role string) bool {
+	for _, allowed := range s.AllowedRegistrationRoles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanStudentEditRecord reports whether a student may edit a record with the given status.
+func (s *SchoolSettings) CanStudentEditRecord(status string) bool {
+	switch s.RecordEditPolicy {
+	case "UNSENT":
+		return status == "CREATED"
+	case "UNAPPROVED":
+		return status == "CREATED" || status == "SENDED" || status == "REJECTED"
+	default:
+		return false
+	}
+}
+
+// IsAttachmentTypeAllowed reports whether files with the given extension may be uploaded.
+func (s *SchoolSettings) IsAttachmentTypeAllowed(extension string) bool {
+	for _, allowed := range s.AllowedAttachmentTypes {
+		if allowed == extension {
+			return true
+		}
+	}
+	return false
+}
+
+// SubmissionDeadline returns the last moment records are accepted for an activity, or nil if there is none.
+func (s *SchoolSettings) SubmissionDeadline(activity *Activity) *time.Time {
+	if activity.Deadline == nil {
+		return nil
+	}
+	deadline := activity.Deadline.AddDate(0, 0, s.GracePeriodDays)
+	return &deadline
+}
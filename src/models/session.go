@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Session is a login of a user on a device, mapped to a PostgreSQL table.
+// It follows the refresh token family started by the login, so revoking one revokes the other.
+type Session struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	FamilyID   string     `json:"-" gorm:"uniqueIndex"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	DeviceName string     `json:"device_name" example:"Chrome on Windows"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address" example:"203.0.113.10"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the Session model.
+func (Session) TableName() string {
+	return "sessions"
+}
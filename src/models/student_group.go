@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StudentGroup is a named set of students of a school gathered by a teacher, mapped to a PostgreSQL table.
+// Shared groups can be seen and targeted by activities of the whole staff of the school, only the owner and ADMIN edit them.
+type StudentGroup struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	SchoolID    uint   `json:"school_id" gorm:"index" validate:"required"`
+	OwnerID     uint   `json:"owner_id" gorm:"index" validate:"required,gt=0"` // ID of the creator (User)
+	Name        string `json:"name" validate:"required,max=100" example:"Robotics club"`
+	Description string `json:"description,omitempty" example:"Meets on Friday afternoons"`
+	IsShared    bool   `json:"is_shared"`
+
+	MemberIDs []uint `json:"member_ids" gorm:"-:all"`
+
+	Members []User `json:"-" gorm:"many2many:student_group_members"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string"`
+}
+
+// TableName specifies the table name for the StudentGroup model.
+func (StudentGroup) TableName() string {
+	return "student_groups"
+}
+
+// AfterFind is a GORM callback that runs after a group is found.
+// It populates the `MemberIDs []uint` field from the `Members` association.
+func (g *StudentGroup) AfterFind(tx *gorm.DB) (err error) {
+	g.MemberIDs = make([]uint, len(g.Members))
+	for i, member := range g.Members {
+		g.MemberIDs[i] = member.ID
+	}
+	return nil
+}
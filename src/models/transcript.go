@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Transcript is the multi-semester record of a student, built from every term of the school.
+type Transcript struct {
+	StudentID       uint             `json:"student_id"`
+	StudentUniqueID *string          `json:"student_unique_id,omitempty"`
+	Firstname       string           `json:"firstname"`
+	Lastname        string           `json:"lastname"`
+	Classroom       *string          `json:"classroom,omitempty"`
+	SchoolID        uint             `json:"school_id"`
+	SchoolName      string           `json:"school_name"`
+	Terms           []TranscriptTerm `json:"terms"`
+	GeneratedAt     time.Time        `json:"generated_at"`
+}
+
+// TranscriptTerm holds the activities of a student in one semester of a school year.
+type TranscriptTerm struct {
+	SchoolYear        uint                 `json:"school_year"`
+	Semester          uint                 `json:"semester"`
+	Activities        []TranscriptActivity `json:"activities"`
+	CompletionPercent float32              `json:"completion_percent"`
+	RequiredTotal     int                  `json:"required_total"`
+	RequiredPassed    int                  `json:"required_passed"`
+	PassedAllRequired bool                 `json:"passed_all_required"`
+}
+
+// TranscriptActivity is the result of a student in one activity.
+type TranscriptActivity struct {
+	ActivityID         uint    `json:"activity_id"`
+	Name               string  `json:"name"`
+	IsRequired         bool    `json:"is_required"`
+	FinishedUnit       string  `json:"finished_unit"`
+	FinishedAmount     int     `json:"finished_amount"`
+	ApprovedAmount     int     `json:"approved_amount"`
+	FinishedPercentage float32 `json:"finished_percentage"`
+	Passed             bool    `json:"passed"`
+}
+
+// TranscriptIssue records a signed transcript document, mapped to a PostgreSQL table.
+// The snapshot lets anyone holding the verification code check the content of the document.
+type TranscriptIssue struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	Code       string     `json:"code" gorm:"uniqueIndex"` // Public identifier printed on the document
+	StudentID  uint       `json:"student_id" gorm:"index"`
+	SchoolID   uint       `json:"school_id" gorm:"index"`
+	IssuedByID uint       `json:"issued_by_id"`
+	ObjectKey  string     `json:"-"`
+	Snapshot   Transcript `json:"snapshot" gorm:"serializer:json"`
+	Signature  string     `json:"-"`
+	IssuedAt   time.Time  `json:"issued_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the TranscriptIssue model.
+func (TranscriptIssue) TableName() string {
+	return "transcript_issues"
+}
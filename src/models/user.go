@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,15 +13,22 @@ type User struct {
 	ID uint `json:"id" gorm:"primarykey"`
 
 	StudentUniqueID   *string `json:"student_id,omitempty"`
-	Role              string  `json:"role" validate:"required,oneof=STD TCH ADMIN SAMA"`
+	Role              string  `json:"role" validate:"required,oneof=STD TCH ADMIN SAMA GRD"`
 	Email             string  `json:"email" gorm:"uniqueIndex" validate:"required,email"` // Unique index for email
 	Password          string  `json:"-"`
 	Phone             string  `json:"phone,omitempty"`
 	Firstname         string  `json:"firstname" validate:"required"`
 	Lastname          string  `json:"lastname" validate:"required"`
-	ProfilePictureURL *string `json:"profile_picture_url,omitempty"`
+	ProfilePictureURL *string `json:"profile_picture_url,omitempty"` // Object key of the avatar, set by the avatar upload confirmation
 	Language          string  `json:"language" validate:"required"`
 
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`                                  // Nil until the owner of the email confirmed a code sent to it
+	PendingEmail    *string    `json:"pending_email,omitempty" validate:"omitempty,email"` // New email waiting for confirmation, the current one is kept until then
+
+	// Lifecycle of the account; users that aren't ACTIVE are kept for their history but leave rosters and statistics
+	Status            string     `json:"status" gorm:"default:ACTIVE;index" validate:"omitempty,oneof=ACTIVE SUSPENDED GRADUATED TRANSFERRED"`
+	StatusEffectiveAt *time.Time `json:"status_effective_at,omitempty"`
+
 	SchoolID        uint    `json:"school_id" validate:"required"`
 	Classroom       *string `json:"classroom,omitempty"`
 	Number          *uint   `json:"number,omitempty" validate:"gt=0"`
@@ -31,13 +40,32 @@ type User struct {
 	Activities      []Activity `json:"-" gorm:"many2many:activity_exclusive_student_ids"`
 	BookmarkUsers   []User     `json:"-" gorm:"many2many:user_bookmarks"`
 
-	FinishedPercent uint `json:"finished_percent,omitempty" gorm:"-:all"`
+	ProfilePictureThumbnails map[int]string `json:"profile_picture_thumbnails,omitempty" gorm:"-:all"` // Object keys of the avatar thumbnails by size in pixels
+
+	FinishedPercent  uint  `json:"finished_percent,omitempty" gorm:"-:all"`
+	ImpersonatedByID *uint `json:"impersonated_by_id,omitempty" gorm:"-:all"` // Set on the profile of the current user when a SAMA crew member acts as them
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string"`
 }
 
+// IsEmailVerified reports whether the ownership of the current email was confirmed.
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsActive reports whether the user takes part in the school, i.e. is assigned activities and counted in statistics.
+// The status is empty until the user is saved, the database then defaults it to ACTIVE.
+func (u *User) IsActive() bool {
+	return u.Status == "" || u.Status == "ACTIVE"
+}
+
+// IsSuspended reports whether the user is barred from signing in.
+func (u *User) IsSuspended() bool {
+	return u.Status == "SUSPENDED"
+}
+
 // TableName specifies the table name for User model
 func (User) TableName() string {
 	return "users"
@@ -50,6 +78,13 @@ func (u *User) AfterFind(tx *gorm.DB) (err error) {
 		u.Classroom = &u.ClassroomObject.Classroom
 	}
 
+	if u.ProfilePictureURL != nil && IsAvatarKey(*u.ProfilePictureURL) {
+		u.ProfilePictureThumbnails = make(map[int]string, len(AVATAR_THUMBNAIL_SIZES))
+		for _, size := range AVATAR_THUMBNAIL_SIZES {
+			u.ProfilePictureThumbnails[size] = AvatarThumbnailKey(*u.ProfilePictureURL, size)
+		}
+	}
+
 	u.BookmarkUserIDs = make([]uint, len(u.BookmarkUsers))
 	for i, user := range u.BookmarkUsers {
 		u.BookmarkUserIDs[i] = user.ID
@@ -57,7 +92,23 @@ func (u *User) AfterFind(tx *gorm.DB) (err error) {
 	return nil
 }
 
-var ROLE = []string{"STD", "TCH", "ADMIN", "SAMA"}
+var ROLE = []string{"STD", "TCH", "ADMIN", "SAMA", "GRD"}
+
+var USER_STATUS = []string{"ACTIVE", "SUSPENDED", "GRADUATED", "TRANSFERRED"}
+
+// AVATAR_THUMBNAIL_SIZES are the sides in pixels of the square thumbnails generated for every avatar.
+var AVATAR_THUMBNAIL_SIZES = []int{64, 128, 256}
+
+// IsAvatarKey reports whether an object key was produced by the avatar upload flow.
+// Older profile pictures are arbitrary strings and have no thumbnails.
+func IsAvatarKey(key string) bool {
+	return strings.HasPrefix(key, "avatars/") && strings.HasSuffix(key, ".jpg")
+}
+
+// AvatarThumbnailKey returns the object key of the thumbnail of an avatar with the given size.
+func AvatarThumbnailKey(key string, size int) string {
+	return fmt.Sprintf("%s_%d.jpg", strings.TrimSuffix(key, ".jpg"), size)
+}
 
 type UserWithFinishedPercent struct {
 	User
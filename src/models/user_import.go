@@ -0,0 +1,31 @@
+package models
+
+// UserImportRow is one user read from a roster file.
+type UserImportRow struct {
+	Row       int     `json:"row"` // Line of the row in the file, the header is row 1
+	StudentID *string `json:"student_id,omitempty"`
+	Firstname string  `json:"firstname"`
+	Lastname  string  `json:"lastname"`
+	Email     string  `json:"email"`
+	Classroom *string `json:"classroom,omitempty"`
+	Number    *uint   `json:"number,omitempty"`
+	Role      string  `json:"role"`
+}
+
+// UserImportError describes why a row of a roster file was rejected.
+type UserImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// UserImportReport is the result of importing a roster file.
+// Users are only created when the file has no error and the import is not a dry run.
+type UserImportReport struct {
+	DryRun        bool              `json:"dry_run"`
+	TotalRows     int               `json:"total_rows"`
+	ValidRows     int               `json:"valid_rows"`
+	Created       int               `json:"created"`
+	Errors        []UserImportError `json:"errors"`
+	EmailFailures []string          `json:"email_failures"` // Created users whose initial password could not be delivered
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// UserStatusLog is an entry of the lifecycle history of a user, mapped to a PostgreSQL table.
+// Transfers between schools are logged with the TRANSFERRED status and both schools, while the user stays ACTIVE in the new one.
+type UserStatusLog struct {
+	ID uint `json:"id" gorm:"primarykey"`
+
+	UserID       uint      `json:"user_id" gorm:"index"`
+	Status       string    `json:"status" example:"GRADUATED"`
+	SchoolID     uint      `json:"school_id"`                // School of the user once the change applied
+	FromSchoolID *uint     `json:"from_school_id,omitempty"` // Set on transfers
+	Reason       string    `json:"reason,omitempty" example:"Graduated in 2025"`
+	EffectiveAt  time.Time `json:"effective_at"`
+	ChangedByID  uint      `json:"changed_by_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the UserStatusLog model.
+func (UserStatusLog) TableName() string {
+	return "user_status_logs"
+}
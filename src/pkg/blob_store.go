@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sama/sama-backend-2025/src/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// BlobStore stores the files of the application under slash separated object keys.
+// Clients upload and download the files directly with presigned requests.
+type BlobStore interface {
+	// PutObject stores the given content under objectKey, replacing any existing object.
+	PutObject(ctx context.Context, objectKey string, body []byte, contentType string) error
+	// GetObject reads an object and its content type, refusing objects larger than maxBytes.
+	GetObject(ctx context.Context, objectKey string, maxBytes int64) ([]byte, string, error)
+	// DeleteObjects removes the given objects. Missing objects are not an error.
+	DeleteObjects(ctx context.Context, objectKeys ...string) error
+	// PresignUpload generates a form a browser can post to upload an object.
+	PresignUpload(ctx context.Context, objectKey string, policy PostPolicy) (*PresignedPost, error)
+	// PresignDownload generates a temporary URL to download an object.
+	PresignDownload(ctx context.Context, objectKey string) (*PresignedRequest, error)
+	// ListObjects lists the objects whose key starts with prefix, sorted by key.
+	ListObjects(ctx context.Context, prefix string) ([]BlobObject, error)
+}
+
+// PostPolicy restricts what a browser may upload with a presigned POST request.
+// Zero values leave the content type or size unrestricted.
+type PostPolicy struct {
+	ContentType string // Exact Content-Type the upload must declare
+	MaxBytes    int64  // Largest accepted object
+}
+
+// PresignedPost is a multipart form upload: the fields must be posted along with the file in a "file" field.
+type PresignedPost struct {
+	URL    string
+	Values map[string]string
+}
+
+// PresignedRequest is a request signed for a limited time that needs no other credentials.
+type PresignedRequest struct {
+	URL    string
+	Method string
+}
+
+// BlobObject describes a stored object.
+type BlobObject struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ErrObjectNotFound is returned when reading an object that doesn't exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// NewBlobStore creates the blob store selected by the configuration.
+func NewBlobStore(cfg *config.Config, awsCfg *aws.Config) (BlobStore, error) {
+	switch cfg.Storage.Backend {
+	case "s3":
+		if cfg.S3.Bucket == "" {
+			return nil, errors.New("S3_BUCKET_NAME is required by the s3 storage backend")
+		}
+		return NewS3Client(cfg, awsCfg), nil
+	case "local":
+		return NewLocalBlobStore(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
+
+// isValidObjectKey reports whether an object key is a relative slash separated path without empty, "." or ".." segments.
+func isValidObjectKey(objectKey string) bool {
+	if objectKey == "" {
+		return false
+	}
+	for _, segment := range strings.Split(objectKey, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsRune(segment, '\\') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sama/sama-backend-2025/src/config"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer is the Mailer writing every email as an .eml file to a directory, for development.
+// The files open in any mail client.
+type FileMailer struct {
+	dir    string
+	sender mail.Address
+}
+
+// NewFileMailer creates a FileMailer in the configured directory, creating it if needed.
+func NewFileMailer(cfg *config.Config, sender mail.Address) (*FileMailer, error) {
+	if err := os.MkdirAll(cfg.Mailer.FileDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileMailer{dir: cfg.Mailer.FileDir, sender: sender}, nil
+}
+
+// SendMessage writes an email to a new file named after the time it was sent.
+func (m *FileMailer) SendMessage(ctx context.Context, message *EmailMessage) error {
+	content, _, err := buildMIMEMessage(m.sender, message)
+	if err != nil {
+		return err
+	}
+
+	filename := filepath.Join(m.dir, fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405Z"), uuid.New().String()[:8]))
+	if err := os.WriteFile(filename, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	log.Printf("Email %q written to %s", message.Subject, filename)
+	return nil
+}
@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Registers the GIF decoder
+	"image/jpeg"
+	_ "image/png" // Registers the PNG decoder
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder
+)
+
+// maxImagePixels bounds the size of decoded images, so a small compressed file can't take gigabytes of memory.
+const maxImagePixels = 40_000_000
+
+// jpegQuality is the quality of the JPEG images encoded from uploads.
+const jpegQuality = 85
+
+// DecodeImage decodes a JPEG, PNG, GIF or WebP image and returns it with its format.
+// Its dimensions are checked before the pixels are decoded.
+func DecodeImage(data []byte) (image.Image, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.New("file is not a supported image")
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return nil, "", fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.New("file is not a supported image")
+	}
+	return img, format, nil
+}
+
+// FitImage scales an image down so neither side exceeds maxSide, keeping its aspect ratio.
+// Smaller images are only flattened on a white background.
+func FitImage(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			width, height = maxSide, max(1, height*maxSide/width)
+		} else {
+			width, height = max(1, width*maxSide/height), maxSide
+		}
+	}
+	return scaleImage(img, bounds, width, height)
+}
+
+// SquareThumbnail crops the center square of an image and scales it to size×size pixels.
+func SquareThumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	return scaleImage(img, image.Rect(x, y, x+side, y+side), size, size)
+}
+
+// EncodeJPEG encodes an image as JPEG, which drops any metadata of the original file.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// scaleImage draws the source rectangle of an image onto a white width×height canvas,
+// since JPEG has no transparency.
+func scaleImage(img image.Image, source image.Rectangle, width, height int) image.Image {
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(canvas, canvas.Bounds(), img, source, draw.Over, nil)
+	return canvas
+}
@@ -0,0 +1,288 @@
+package pkg
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sama/sama-backend-2025/src/config"
+)
+
+// LocalMaxUploadBytes bounds the uploads of the local backend when the policy sets no size.
+const LocalMaxUploadBytes = 100 << 20
+
+// localTempPrefix marks the files being written, they are skipped when listing.
+const localTempPrefix = ".upload-"
+
+// ErrInvalidSignature is returned for presigned local URLs that are forged, altered or expired.
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// LocalBlobStore is the BlobStore keeping objects in a directory, for development and tests.
+// Presigned URLs point to the storage routes of the API, which check their HMAC signature.
+type LocalBlobStore struct {
+	root     string
+	baseURL  string
+	secret   []byte
+	lifetime time.Duration
+}
+
+// localPostPolicy is the signed policy of a local presigned upload.
+type localPostPolicy struct {
+	Key         string `json:"key"`
+	ContentType string `json:"content_type,omitempty"`
+	MaxBytes    int64  `json:"max_bytes,omitempty"`
+	Expires     int64  `json:"expires"`
+}
+
+// NewLocalBlobStore creates a LocalBlobStore in the configured directory, creating it if needed.
+func NewLocalBlobStore(cfg *config.Config) (*LocalBlobStore, error) {
+	if cfg.Storage.SigningSecret == "" {
+		return nil, errors.New("STORAGE_SIGNING_SECRET is required by the local storage backend")
+	}
+	root, err := filepath.Abs(cfg.Storage.LocalPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage path: %w", err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalBlobStore{
+		root:     root,
+		baseURL:  strings.TrimSuffix(cfg.Storage.PublicURL, "/") + "/api/v1/storage",
+		secret:   []byte(cfg.Storage.SigningSecret),
+		lifetime: time.Duration(cfg.S3.PreSignedLifeTimeMinutes) * time.Minute,
+	}, nil
+}
+
+// PutObject writes the content to the file of objectKey. The content type is derived from the key when served.
+func (s *LocalBlobStore) PutObject(ctx context.Context, objectKey string, body []byte, contentType string) error {
+	return s.writeObject(objectKey, body)
+}
+
+// GetObject reads the file of an object, refusing files larger than maxBytes.
+func (s *LocalBlobStore) GetObject(ctx context.Context, objectKey string, maxBytes int64) ([]byte, string, error) {
+	filename, err := s.path(objectKey)
+	if err != nil {
+		return nil, "", err
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "", ErrObjectNotFound
+		}
+		return nil, "", fmt.Errorf("failed to read object %s: %w", objectKey, err)
+	}
+	if info.Size() > maxBytes {
+		return nil, "", fmt.Errorf("object %s is larger than %d bytes", objectKey, maxBytes)
+	}
+
+	body, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object %s: %w", objectKey, err)
+	}
+	contentType := mime.TypeByExtension(path.Ext(objectKey))
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	return body, contentType, nil
+}
+
+// DeleteObjects removes the files of the given objects.
+func (s *LocalBlobStore) DeleteObjects(ctx context.Context, objectKeys ...string) error {
+	for _, objectKey := range objectKeys {
+		filename, err := s.path(objectKey)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete object %s: %w", objectKey, err)
+		}
+	}
+	return nil
+}
+
+// PresignUpload generates a form posting the file to the upload route of the API.
+func (s *LocalBlobStore) PresignUpload(ctx context.Context, objectKey string, policy PostPolicy) (*PresignedPost, error) {
+	if !isValidObjectKey(objectKey) {
+		return nil, fmt.Errorf("invalid object key %s", objectKey)
+	}
+	document, err := json.Marshal(localPostPolicy{
+		Key:         objectKey,
+		ContentType: policy.ContentType,
+		MaxBytes:    policy.MaxBytes,
+		Expires:     time.Now().Add(s.lifetime).Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upload policy: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(document)
+
+	values := map[string]string{
+		"key":       objectKey,
+		"policy":    encoded,
+		"signature": s.sign("POST", encoded),
+	}
+	if policy.ContentType != "" {
+		values["Content-Type"] = policy.ContentType
+	}
+	return &PresignedPost{URL: s.baseURL + "/upload", Values: values}, nil
+}
+
+// PresignDownload generates a URL of the download route of the API that expires with the configured lifetime.
+func (s *LocalBlobStore) PresignDownload(ctx context.Context, objectKey string) (*PresignedRequest, error) {
+	if !isValidObjectKey(objectKey) {
+		return nil, fmt.Errorf("invalid object key %s", objectKey)
+	}
+	expires := strconv.FormatInt(time.Now().Add(s.lifetime).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.sign("GET", objectKey, expires)},
+	}
+	objectURL := s.baseURL + "/object/" + (&url.URL{Path: objectKey}).EscapedPath()
+	return &PresignedRequest{URL: objectURL + "?" + query.Encode(), Method: http.MethodGet}, nil
+}
+
+// ListObjects walks the storage directory for the files whose key starts with prefix.
+func (s *LocalBlobStore) ListObjects(ctx context.Context, prefix string) ([]BlobObject, error) {
+	var objects []BlobObject
+	err := filepath.WalkDir(s.root, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), localTempPrefix) {
+			return nil
+		}
+		relative, err := filepath.Rel(s.root, filename)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relative)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, BlobObject{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects with prefix %s: %w", prefix, err)
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// OpenDownload checks the signature of a presigned download URL and returns the file of the object.
+func (s *LocalBlobStore) OpenDownload(objectKey, expires, signature string) (string, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt || !hmac.Equal([]byte(signature), []byte(s.sign("GET", objectKey, expires))) {
+		return "", ErrInvalidSignature
+	}
+	filename, err := s.path(objectKey)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(filename); err != nil || info.IsDir() {
+		return "", ErrObjectNotFound
+	}
+	return filename, nil
+}
+
+// AcceptUpload checks a form posted to a presigned upload URL against its policy and stores the file.
+func (s *LocalBlobStore) AcceptUpload(fields map[string]string, file io.Reader) error {
+	encoded := fields["policy"]
+	if !hmac.Equal([]byte(fields["signature"]), []byte(s.sign("POST", encoded))) {
+		return ErrInvalidSignature
+	}
+	document, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	var policy localPostPolicy
+	if err := json.Unmarshal(document, &policy); err != nil || time.Now().Unix() > policy.Expires {
+		return ErrInvalidSignature
+	}
+
+	if fields["key"] != policy.Key {
+		return errors.New("upload violates the policy: key does not match")
+	}
+	if policy.ContentType != "" && fields["Content-Type"] != policy.ContentType {
+		return errors.New("upload violates the policy: content type does not match")
+	}
+	maxBytes := policy.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = LocalMaxUploadBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read upload: %w", err)
+	}
+	if len(body) == 0 || int64(len(body)) > maxBytes {
+		return errors.New("upload violates the policy: file size out of range")
+	}
+	return s.writeObject(policy.Key, body)
+}
+
+// writeObject writes the file of an object through a temporary file, so readers never see partial content.
+func (s *LocalBlobStore) writeObject(objectKey string, body []byte) error {
+	filename, err := s.path(objectKey)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return fmt.Errorf("failed to write object %s: %w", objectKey, err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(filename), localTempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to write object %s: %w", objectKey, err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(body); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write object %s: %w", objectKey, err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write object %s: %w", objectKey, err)
+	}
+	if err := os.Rename(temp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to write object %s: %w", objectKey, err)
+	}
+	return nil
+}
+
+// path returns the file of an object, refusing keys that would escape the storage directory.
+func (s *LocalBlobStore) path(objectKey string) (string, error) {
+	if !isValidObjectKey(objectKey) {
+		return "", fmt.Errorf("invalid object key %s", objectKey)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(objectKey)), nil
+}
+
+// sign computes the hex HMAC of the newline joined parts.
+func (s *LocalBlobStore) sign(parts ...string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,91 +1,213 @@
 package pkg
 
 import (
+	"bytes"
 	"context"
+	"embed"
+	"errors"
 	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
 	"log"
-	"sama/sama-backend-2025/src/config"
+	"net/mail"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
 	"time"
 
+	"sama/sama-backend-2025/src/config"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/sesv2"
-	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
 )
 
-// MailerService encapsulates the AWS SES v2 client and email configuration.
+// DefaultEmailLocale is the language of the email templates used when none exists in the language of the recipient.
+const DefaultEmailLocale = "en"
+
+// emailTemplates holds one file per template and language, named "<template>.<locale>.tmpl".
+// Every file defines a "subject", an "html" and a "text" template. Files of the configured
+// template directory are added to them and replace the embedded ones with the same name.
+//
+//go:embed templates/email/*.tmpl
+var emailTemplates embed.FS
+
+// EmailMessage is a rendered email to one recipient.
+type EmailMessage struct {
+	To       string
+	Locale   string // Language of the template the message was rendered from
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// Mailer delivers rendered emails.
+type Mailer interface {
+	SendMessage(ctx context.Context, message *EmailMessage) error
+}
+
+// NewMailer creates the mailer backend selected by the configuration.
+func NewMailer(cfg *config.Config, awsCfg *aws.Config) (Mailer, error) {
+	sender := mail.Address{Name: cfg.Mailer.SenderName, Address: cfg.Mailer.SenderEmail}
+	switch cfg.Mailer.Backend {
+	case "ses":
+		return NewSESMailer(awsCfg, sender), nil
+	case "smtp":
+		return NewSMTPMailer(cfg, sender)
+	case "file":
+		return NewFileMailer(cfg, sender)
+	default:
+		return nil, fmt.Errorf("unknown mailer backend %q", cfg.Mailer.Backend)
+	}
+}
+
+// emailTemplate is a template in one language, parsed for the plain text and HTML parts.
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// MailerService renders the email templates and sends them with the configured mailer.
 type MailerService struct {
-	sesClient   *sesv2.Client
-	senderEmail string
-	senderName  string
+	mailer    Mailer
+	templates map[string]*emailTemplate // By "<template>.<locale>"
 }
 
-// NewMailerService creates and returns a new MailerService instance.
-// It requires an AWS region and a verified sender email address.
-func NewMailerService(config *config.Config, cfg *aws.Config) *MailerService {
+// NewMailerService creates a MailerService with the backend selected by the configuration and the embedded templates.
+func NewMailerService(cfg *config.Config, awsCfg *aws.Config) (*MailerService, error) {
+	mailer, err := NewMailer(cfg, awsCfg)
+	if err != nil {
+		return nil, err
+	}
+	templates := make(map[string]*emailTemplate)
+	if err := parseEmailTemplates(emailTemplates, "templates/email/*.tmpl", templates); err != nil {
+		return nil, err
+	}
+	if cfg.Mailer.TemplateDir != "" {
+		if err := parseEmailTemplates(os.DirFS(cfg.Mailer.TemplateDir), "*.tmpl", templates); err != nil {
+			return nil, err
+		}
+	}
+	return &MailerService{mailer: mailer, templates: templates}, nil
+}
 
-	// Create an SES v2 client
-	sesClient := sesv2.NewFromConfig(*cfg)
+// Send renders a template in the given language and sends it to every recipient separately,
+// so recipients don't see each other's address. Templates missing in that language fall back to English.
+func (s *MailerService) Send(ctx context.Context, templateName, locale string, data any, recipients ...string) error {
+	rendered, err := s.Render(templateName, locale, data)
+	if err != nil {
+		return err
+	}
 
-	return &MailerService{
-		sesClient:   sesClient,
-		senderEmail: config.Mailer.SenderEmail,
-		senderName:  config.Mailer.SenderName,
+	var errs []error
+	for _, recipient := range recipients {
+		message := *rendered
+		message.To = recipient
+		if err := s.deliver(ctx, &message); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send %s email to %s: %w", templateName, recipient, err))
+		}
 	}
+	return errors.Join(errs...)
 }
 
-// SendOTPEmail sends an OTP email to a specified recipient using AWS SES v2.
-func (s *MailerService) SendOTPEmail(ctx context.Context, recipientName, recipientEmail, otpCode string) error {
-	// Use a context with a timeout for the API call
+// deliver sends one message with a timeout, so a slow backend can't hold the request forever.
+func (s *MailerService) deliver(ctx context.Context, message *EmailMessage) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
+	return s.mailer.SendMessage(ctx, message)
+}
+
+// Render renders a template in the given language, falling back to the base language and then to English.
+func (s *MailerService) Render(templateName, locale string, data any) (*EmailMessage, error) {
+	tmpl, templateLocale := s.findTemplate(templateName, locale)
+	if tmpl == nil {
+		return nil, fmt.Errorf("unknown email template %s", templateName)
+	}
 
-	// Construct the email subject
-	subject := "Your One-Time Password (OTP)"
-
-	// Construct the HTML and plain text bodies of the email
-	htmlBody := fmt.Sprintf(`
-		<html>
-		<body>
-			<h1>Hello %s,</h1>
-			<p>Your one-time password is: <strong>%s</strong></p>
-			<p>This code will expire in 5 minutes.</p>
-			<p>If you did not request this, please ignore this email.</p>
-		</body>
-		</html>
-	`, recipientName, otpCode)
-
-	textBody := fmt.Sprintf("Hello %s,\n\nYour one-time password is: %s\n\nThis code will expire in 5 minutes. If you did not request this, please ignore this email.", recipientName, otpCode)
-
-	// Build the email input
-	input := &sesv2.SendEmailInput{
-		Destination: &types.Destination{
-			ToAddresses: []string{recipientEmail},
-		},
-		Content: &types.EmailContent{
-			Simple: &types.Message{
-				Subject: &types.Content{
-					Data: aws.String(subject),
-				},
-				Body: &types.Body{
-					Html: &types.Content{
-						Data: aws.String(htmlBody),
-					},
-					Text: &types.Content{
-						Data: aws.String(textBody),
-					},
-				},
-			},
-		},
-		FromEmailAddress: aws.String(fmt.Sprintf("%s <%s>", s.senderName, s.senderEmail)),
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", templateName, err)
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", templateName, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", templateName, err)
 	}
 
-	// Send the email
-	result, err := s.sesClient.SendEmail(ctx, input)
+	return &EmailMessage{
+		Locale:   templateLocale,
+		Subject:  strings.TrimSpace(subject.String()),
+		TextBody: strings.TrimSpace(text.String()),
+		HTMLBody: strings.TrimSpace(html.String()),
+	}, nil
+}
+
+// TemplateLocales returns the languages every template exists in, by template name.
+func (s *MailerService) TemplateLocales() map[string][]string {
+	locales := make(map[string][]string)
+	for key := range s.templates {
+		name, locale, _ := strings.Cut(key, ".")
+		locales[name] = append(locales[name], locale)
+	}
+	for _, list := range locales {
+		slices.Sort(list)
+	}
+	return locales
+}
+
+// findTemplate looks up a template for a locale such as "th-TH", then "th", then the default locale.
+// It returns the template with the locale it was found for.
+func (s *MailerService) findTemplate(templateName, locale string) (*emailTemplate, string) {
+	locale = strings.ToLower(locale)
+	base, _, _ := strings.Cut(locale, "-")
+	for _, candidate := range []string{locale, base, DefaultEmailLocale} {
+		if tmpl, ok := s.templates[templateName+"."+candidate]; ok {
+			return tmpl, candidate
+		}
+	}
+	return nil, ""
+}
+
+// parseEmailTemplates parses the template files matching the pattern into templates.
+func parseEmailTemplates(files fs.FS, pattern string, templates map[string]*emailTemplate) error {
+	names, err := fs.Glob(files, pattern)
 	if err != nil {
-		return fmt.Errorf("failed to send OTP email via SES: %w", err)
+		return err
 	}
 
-	log.Printf("OTP email sent successfully. Message ID: %s", *result.MessageId)
+	for _, name := range names {
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return fmt.Errorf("failed to read email template %s: %w", name, err)
+		}
+		key := strings.ToLower(strings.TrimSuffix(path.Base(name), ".tmpl"))
+		if strings.Count(key, ".") != 1 {
+			return fmt.Errorf("email template %s is not named <template>.<locale>.tmpl", name)
+		}
 
+		text, err := texttemplate.New(key).Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("failed to parse email template %s: %w", name, err)
+		}
+		html, err := htmltemplate.New(key).Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("failed to parse email template %s: %w", name, err)
+		}
+		for _, part := range []string{"subject", "text"} {
+			if text.Lookup(part) == nil {
+				return fmt.Errorf("email template %s has no %s", name, part)
+			}
+		}
+		if html.Lookup("html") == nil {
+			return fmt.Errorf("email template %s has no html", name)
+		}
+		templates[key] = &emailTemplate{text: text, html: html}
+	}
 	return nil
 }
+
+// logDelivery logs a sent email without its content.
+func logDelivery(backend, subject, messageID string) {
+	log.Printf("Email %q sent successfully via %s. Message ID: %s", subject, backend, messageID)
+}
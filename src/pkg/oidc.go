@@ -0,0 +1,276 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCProvider is the configuration of a client registered at an OpenID Connect identity provider.
+type OIDCProvider struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// OIDCIdentity holds the claims of a verified ID token.
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified *bool // Nil when the identity provider doesn't send the claim
+	GivenName     string
+	FamilyName    string
+}
+
+// oidcDiscovery is the part of the discovery document of an identity provider used by the client.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// idTokenClaims are the claims read from an ID token.
+type idTokenClaims struct {
+	Nonce         string      `json:"nonce"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"` // Some identity providers send it as a string
+	GivenName     string      `json:"given_name"`
+	FamilyName    string      `json:"family_name"`
+	jwt.RegisteredClaims
+}
+
+// OIDCClient runs the authorization code flow with PKCE against OpenID Connect identity providers.
+// Discovery documents and signing keys are cached per issuer.
+type OIDCClient struct {
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery map[string]*oidcDiscovery
+	keys      map[string]map[string]*rsa.PublicKey // Keys by kid, by JWKS URL
+}
+
+// NewOIDCClient creates an OIDCClient.
+func NewOIDCClient() *OIDCClient {
+	return &OIDCClient{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		discovery:  make(map[string]*oidcDiscovery),
+		keys:       make(map[string]map[string]*rsa.PublicKey),
+	}
+}
+
+// CodeChallenge returns the PKCE S256 challenge of a code verifier.
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthorizationURL returns the URL of the identity provider the user is sent to for signing in.
+func (c *OIDCClient) AuthorizationURL(ctx context.Context, provider OIDCProvider, state, nonce, codeVerifier string) (string, error) {
+	discovery, err := c.discover(ctx, provider.IssuerURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {provider.RedirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange trades an authorization code for an ID token and returns its verified claims.
+// The token must be signed by the identity provider, issued for the client, unexpired and carry the nonce of the sign-in.
+func (c *OIDCClient) Exchange(ctx context.Context, provider OIDCProvider, code, codeVerifier, nonce string) (*OIDCIdentity, error) {
+	discovery, err := c.discover(ctx, provider.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.RedirectURL},
+		"client_id":     {provider.ClientID},
+		"client_secret": {provider.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokenResponse struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := c.doJSON(req, &tokenResponse); err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	if tokenResponse.IDToken == "" {
+		return nil, errors.New("failed to exchange authorization code: no id_token in response")
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(tokenResponse.IDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return c.signingKey(ctx, discovery.JWKSURI, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(provider.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid id token: nonce mismatch")
+	}
+
+	identity := &OIDCIdentity{
+		Subject:    claims.Subject,
+		Email:      strings.ToLower(claims.Email),
+		GivenName:  claims.GivenName,
+		FamilyName: claims.FamilyName,
+	}
+	switch verified := claims.EmailVerified.(type) {
+	case bool:
+		identity.EmailVerified = &verified
+	case string:
+		value := verified == "true"
+		identity.EmailVerified = &value
+	}
+	return identity, nil
+}
+
+// discover fetches the discovery document of an issuer, once.
+func (c *OIDCClient) discover(ctx context.Context, issuerURL string) (*oidcDiscovery, error) {
+	issuerURL = strings.TrimSuffix(issuerURL, "/")
+
+	c.mu.Lock()
+	cached, ok := c.discovery[issuerURL]
+	c.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request: %w", err)
+	}
+	discovery := &oidcDiscovery{}
+	if err := c.doJSON(req, discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover identity provider: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuerURL {
+		return nil, fmt.Errorf("failed to discover identity provider: issuer %s doesn't match %s", discovery.Issuer, issuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("failed to discover identity provider: incomplete discovery document")
+	}
+
+	c.mu.Lock()
+	c.discovery[issuerURL] = discovery
+	c.mu.Unlock()
+	return discovery, nil
+}
+
+// signingKey returns the key of the given kid, fetching the key set again when it is unknown, e.g. after a rotation.
+func (c *OIDCClient) signingKey(ctx context.Context, jwksURI, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	key, ok := c.keys[jwksURI][kid]
+	c.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build key set request: %w", err)
+	}
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := c.doJSON(req, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	c.mu.Lock()
+	c.keys[jwksURI] = keys
+	c.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// doJSON sends a request and decodes its JSON response, failing on any non-2xx status.
+func (c *OIDCClient) doJSON(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,168 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sama/sama-backend-2025/src/config"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher hashes passwords for storage. Hashes carry their algorithm and parameters,
+// so every hasher can verify hashes made by another one, and tell when a hash should be upgraded.
+type PasswordHasher interface {
+	// Hash returns the encoded hash of the password.
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the encoded hash, whichever supported algorithm made it.
+	Verify(encodedHash, password string) (bool, error)
+	// NeedsRehash reports whether the encoded hash was made with another algorithm or other parameters than the hasher's.
+	NeedsRehash(encodedHash string) bool
+}
+
+// NewPasswordHasher creates the hasher selected in the configuration, argon2id unless bcrypt is asked for.
+func NewPasswordHasher(cfg *config.Config) PasswordHasher {
+	if cfg.Password.Hasher == "bcrypt" {
+		return NewBcryptHasher(bcrypt.DefaultCost)
+	}
+	return NewArgon2idHasher(uint32(cfg.Password.Argon2MemoryKiB), uint32(cfg.Password.Argon2Iterations), uint8(cfg.Password.Argon2Parallelism))
+}
+
+// argon2idPrefix starts every hash made by Argon2idHasher, in the PHC string format:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+const argon2idPrefix = "$argon2id$"
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// Argon2idHasher hashes passwords with argon2id.
+type Argon2idHasher struct {
+	memory      uint32 // KiB
+	iterations  uint32
+	parallelism uint8
+}
+
+// NewArgon2idHasher creates an Argon2idHasher with the given cost parameters.
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) *Argon2idHasher {
+	return &Argon2idHasher{
+		memory:      memory,
+		iterations:  iterations,
+		parallelism: parallelism,
+	}
+}
+
+// Hash returns the argon2id hash of the password with a random salt.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.iterations, h.memory, h.parallelism, argon2idKeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, h.memory, h.iterations, h.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports whether the password matches the encoded hash.
+func (h *Argon2idHasher) Verify(encodedHash, password string) (bool, error) {
+	return verifyPassword(encodedHash, password)
+}
+
+// NeedsRehash reports whether the encoded hash isn't an argon2id hash with the parameters of the hasher.
+func (h *Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	params, _, _, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return true
+	}
+	return params.memory != h.memory || params.iterations != h.iterations || params.parallelism != h.parallelism
+}
+
+// BcryptHasher hashes passwords with bcrypt, the algorithm used before argon2id.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a BcryptHasher with the given cost.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{cost: cost}
+}
+
+// Hash returns the bcrypt hash of the password.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify reports whether the password matches the encoded hash.
+func (h *BcryptHasher) Verify(encodedHash, password string) (bool, error) {
+	return verifyPassword(encodedHash, password)
+}
+
+// NeedsRehash reports whether the encoded hash isn't a bcrypt hash with the cost of the hasher.
+func (h *BcryptHasher) NeedsRehash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err != nil || cost != h.cost
+}
+
+// verifyPassword checks a password against a hash of any supported algorithm.
+func verifyPassword(encodedHash, password string) (bool, error) {
+	if strings.HasPrefix(encodedHash, argon2idPrefix) {
+		params, salt, key, err := decodeArgon2idHash(encodedHash)
+		if err != nil {
+			return false, err
+		}
+		otherKey := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to verify password: %w", err)
+	}
+	return true, nil
+}
+
+// decodeArgon2idHash reads the parameters, salt and key of an argon2id hash.
+func decodeArgon2idHash(encodedHash string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errors.New("unsupported argon2id version")
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, nil, nil, errors.New("invalid argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errors.New("invalid argon2id salt")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, errors.New("invalid argon2id key")
+	}
+
+	return params, salt, key, nil
+}
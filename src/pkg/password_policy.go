@@ -0,0 +1,123 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"sama/sama-backend-2025/src/config"
+	"sama/sama-backend-2025/src/utils"
+)
+
+// maxPasswordLength bounds the work done hashing a password.
+const maxPasswordLength = 128
+
+// maxPasswordGenerationAttempts bounds the retries of GeneratePassword when a password lands in the breached list.
+const maxPasswordGenerationAttempts = 10
+
+// PasswordPolicy checks the passwords chosen by users on registration, reset and change.
+// Every error it returns starts with "password ".
+type PasswordPolicy struct {
+	minLength     int
+	requireUpper  bool
+	requireLower  bool
+	requireDigit  bool
+	requireSymbol bool
+	breached      map[string]struct{} // Lowercased passwords that are refused
+}
+
+// NewPasswordPolicy creates the policy of the configuration, loading the breached password list if one is set.
+// The list has one password per line and is compared without case.
+func NewPasswordPolicy(cfg *config.Config) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		minLength:     cfg.Password.MinLength,
+		requireUpper:  cfg.Password.RequireUpper,
+		requireLower:  cfg.Password.RequireLower,
+		requireDigit:  cfg.Password.RequireDigit,
+		requireSymbol: cfg.Password.RequireSymbol,
+		breached:      make(map[string]struct{}),
+	}
+
+	if cfg.Password.BreachedListFile == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(cfg.Password.BreachedListFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			policy.breached[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Check returns why the password is refused, or nil if it follows the policy.
+func (p *PasswordPolicy) Check(password string) error {
+	length := len([]rune(password))
+	if length < p.minLength {
+		return fmt.Errorf("password must be at least %d characters long", p.minLength)
+	}
+	if length > maxPasswordLength {
+		return fmt.Errorf("password must be at most %d characters long", maxPasswordLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.requireUpper && !hasUpper {
+		return fmt.Errorf("password must contain an uppercase letter")
+	}
+	if p.requireLower && !hasLower {
+		return fmt.Errorf("password must contain a lowercase letter")
+	}
+	if p.requireDigit && !hasDigit {
+		return fmt.Errorf("password must contain a digit")
+	}
+	if p.requireSymbol && !hasSymbol {
+		return fmt.Errorf("password must contain a symbol")
+	}
+
+	if _, found := p.breached[strings.ToLower(password)]; found {
+		return fmt.Errorf("password is too common, please choose another one")
+	}
+
+	return nil
+}
+
+// GeneratePassword returns a random password following the policy, used as an initial password.
+// The password is lengthened to the minimum length of the policy when length is shorter.
+func (p *PasswordPolicy) GeneratePassword(length int) (string, error) {
+	length = max(length, p.minLength)
+	for range maxPasswordGenerationAttempts {
+		password, err := utils.GeneratePassword(length)
+		if err != nil {
+			return "", err
+		}
+		if err := p.Check(password); err == nil {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate a password following the policy")
+}
@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"testing"
+
+	"sama/sama-backend-2025/src/config"
+)
+
+// strictPasswordPolicy requires every character class and a length above the one of initial passwords.
+func strictPasswordPolicy(t *testing.T) *PasswordPolicy {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Password.MinLength = 16
+	cfg.Password.RequireUpper = true
+	cfg.Password.RequireLower = true
+	cfg.Password.RequireDigit = true
+	cfg.Password.RequireSymbol = true
+
+	policy, err := NewPasswordPolicy(cfg)
+	if err != nil {
+		t.Fatalf("failed to create password policy: %v", err)
+	}
+	return policy
+}
+
+func TestPasswordPolicyGeneratePasswordFollowsThePolicy(t *testing.T) {
+	policy := strictPasswordPolicy(t)
+
+	for range 500 {
+		password, err := policy.GeneratePassword(12)
+		if err != nil {
+			t.Fatalf("GeneratePassword failed: %v", err)
+		}
+		if len(password) != 16 {
+			t.Fatalf("GeneratePassword(12) length = %d, want the minimum length 16 of the policy", len(password))
+		}
+		if err := policy.Check(password); err != nil {
+			t.Fatalf("generated password %q is refused by the policy: %v", password, err)
+		}
+	}
+}
+
+func TestPasswordPolicyGeneratePasswordKeepsLongerLengths(t *testing.T) {
+	policy := strictPasswordPolicy(t)
+
+	password, err := policy.GeneratePassword(24)
+	if err != nil {
+		t.Fatalf("GeneratePassword failed: %v", err)
+	}
+	if len(password) != 24 {
+		t.Errorf("GeneratePassword(24) length = %d, want 24", len(password))
+	}
+}
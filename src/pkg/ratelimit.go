@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitStore keeps the counters and locks used to limit authentication attempts.
+// The memory store works for a single instance; a shared store is needed when running several.
+type RateLimitStore interface {
+	// Increment adds one to the counter of key and returns the new value.
+	// The counter starts over once window has passed since its first hit.
+	Increment(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock blocks key until the given time.
+	Lock(ctx context.Context, key string, until time.Time) error
+	// LockedUntil returns when the lock of key ends, or the zero time if key is not locked.
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	// Reset clears the counter and the lock of key.
+	Reset(ctx context.Context, key string) error
+}
+
+// sweepInterval is how often expired entries are removed from the memory store.
+const sweepInterval = time.Minute
+
+// MemoryRateLimitStore is a RateLimitStore kept in the memory of the process.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+}
+
+type rateLimitEntry struct {
+	count        int
+	windowEndsAt time.Time
+	lockedUntil  time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries:   make(map[string]*rateLimitEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Increment adds one to the counter of key and returns the new value.
+func (s *MemoryRateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	entry := s.entry(key)
+	if !now.Before(entry.windowEndsAt) {
+		entry.count = 0
+		entry.windowEndsAt = now.Add(window)
+	}
+	entry.count++
+	return entry.count, nil
+}
+
+// Lock blocks key until the given time.
+func (s *MemoryRateLimitStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entry(key).lockedUntil = until
+	return nil
+}
+
+// LockedUntil returns when the lock of key ends, or the zero time if key is not locked.
+func (s *MemoryRateLimitStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !time.Now().Before(entry.lockedUntil) {
+		return time.Time{}, nil
+	}
+	return entry.lockedUntil, nil
+}
+
+// Reset clears the counter and the lock of key.
+func (s *MemoryRateLimitStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// entry returns the entry of key, creating it if needed. The caller must hold the mutex.
+func (s *MemoryRateLimitStore) entry(key string) *rateLimitEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &rateLimitEntry{}
+		s.entries[key] = entry
+	}
+	return entry
+}
+
+// sweep removes entries whose window and lock both ended, so the map doesn't grow forever.
+// The caller must hold the mutex.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if !now.Before(entry.windowEndsAt) && !now.Before(entry.lockedUntil) {
+			delete(s.entries, key)
+		}
+	}
+}
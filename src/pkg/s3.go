@@ -1,20 +1,24 @@
 package pkg
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"time"
 
 	"sama/sama-backend-2025/src/config"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
-// S3Client encapsulates the S3 presigning functionality.
+// S3Client is the BlobStore backed by an S3 bucket.
 type S3Client struct {
+	client        *s3.Client
 	presignClient *s3.PresignClient
 	bucketName    string
 	lifetime      time.Duration
@@ -22,16 +26,33 @@ type S3Client struct {
 
 // NewS3Client creates a new S3Client instance with a default lifetime for presigned URLs.
 func NewS3Client(config *config.Config, cfg *aws.Config) *S3Client {
+	client := s3.NewFromConfig(*cfg)
 
 	return &S3Client{
-		presignClient: s3.NewPresignClient(s3.NewFromConfig(*cfg)),
+		client:        client,
+		presignClient: s3.NewPresignClient(client),
 		bucketName:    config.S3.Bucket,
 		lifetime:      time.Duration(config.S3.PreSignedLifeTimeMinutes) * time.Minute,
 	}
 }
 
-// GetPresignedDownloadURL generates a presigned request for downloading an object.
-func (c *S3Client) GetPresignedDownloadURL(ctx context.Context, objectKey string) (*v4.PresignedHTTPRequest, error) {
+// PutObject uploads the given content to the bucket under objectKey.
+func (c *S3Client) PutObject(ctx context.Context, objectKey string, body []byte, contentType string) error {
+	_, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(c.bucketName),
+		Key:         aws.String(objectKey),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+
+	if err != nil {
+		log.Printf("failed to upload object %s: %v\n", objectKey, err)
+	}
+	return err
+}
+
+// PresignDownload generates a presigned request for downloading an object.
+func (c *S3Client) PresignDownload(ctx context.Context, objectKey string) (*PresignedRequest, error) {
 	request, err := c.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(c.bucketName),
 		Key:    aws.String(objectKey),
@@ -39,57 +60,112 @@ func (c *S3Client) GetPresignedDownloadURL(ctx context.Context, objectKey string
 
 	if err != nil {
 		log.Printf("failed to generate a presigned download request: %v\n", err)
+		return nil, err
 	}
-	return request, err
+	return &PresignedRequest{URL: request.URL, Method: request.Method}, nil
 }
 
-// GetPresignedUploadURL generates a presigned request for uploading an object.
-func (c *S3Client) GetPresignedUploadURL(ctx context.Context, objectKey string) (*v4.PresignedHTTPRequest, error) {
-	request, err := c.presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+// PresignUpload generates a presigned POST request for uploading an object from a browser form.
+func (c *S3Client) PresignUpload(ctx context.Context, objectKey string, policy PostPolicy) (*PresignedPost, error) {
+	conditions := []interface{}{}
+	if policy.ContentType != "" {
+		conditions = append(conditions, []interface{}{"eq", "$Content-Type", policy.ContentType})
+	}
+	if policy.MaxBytes > 0 {
+		conditions = append(conditions, []interface{}{"content-length-range", 1, policy.MaxBytes})
+	}
+
+	request, err := c.presignClient.PresignPostObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(c.bucketName),
 		Key:    aws.String(objectKey),
-	}, s3.WithPresignExpires(c.lifetime))
-
+	}, func(options *s3.PresignPostOptions) {
+		options.Expires = c.lifetime
+		options.Conditions = conditions
+	})
 	if err != nil {
-		log.Printf("failed to generate a presigned upload request: %v\n", err)
+		log.Printf("failed to generate a presigned post request: %v\n", err)
+		return nil, err
+	}
+	if policy.ContentType != "" {
+		// The form must send the same Content-Type the policy was signed with
+		request.Values["Content-Type"] = policy.ContentType
 	}
-	return request, err
+	return &PresignedPost{URL: request.URL, Values: request.Values}, nil
 }
 
-// GetPresignedDeleteURL generates a presigned request for deleting an object.
-func (c *S3Client) GetPresignedDeleteURL(ctx context.Context, objectKey string) (*v4.PresignedHTTPRequest, error) {
-	request, err := c.presignClient.PresignDeleteObject(ctx, &s3.DeleteObjectInput{
+// GetObject downloads an object from the bucket, refusing objects larger than maxBytes.
+func (c *S3Client) GetObject(ctx context.Context, objectKey string, maxBytes int64) ([]byte, string, error) {
+	output, err := c.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(c.bucketName),
 		Key:    aws.String(objectKey),
-	}, s3.WithPresignExpires(c.lifetime))
-
+	})
 	if err != nil {
-		log.Printf("failed to generate a presigned delete request: %v\n", err)
-		return nil, err
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, "", ErrObjectNotFound
+		}
+		log.Printf("failed to download object %s: %v\n", objectKey, err)
+		return nil, "", err
 	}
+	defer output.Body.Close()
 
-	return request, err
+	if output.ContentLength != nil && *output.ContentLength > maxBytes {
+		return nil, "", fmt.Errorf("object %s is larger than %d bytes", objectKey, maxBytes)
+	}
+	body, err := io.ReadAll(io.LimitReader(output.Body, maxBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object %s: %w", objectKey, err)
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, "", fmt.Errorf("object %s is larger than %d bytes", objectKey, maxBytes)
+	}
+	return body, aws.ToString(output.ContentType), nil
 }
 
-func (c *S3Client) PresignPostObject(ctx context.Context, objectKey string) (*s3.PresignedPostRequest, error) {
-	// policy := `[["starts-with", "$Content-Type", "image/"]]`
-	policy := `[]`
-	var policyJson []interface{}
-	err := json.Unmarshal([]byte(policy), &policyJson)
-	if err != nil {
-		return nil, err
+// DeleteObjects removes the given objects from the bucket. Missing objects are not an error.
+func (c *S3Client) DeleteObjects(ctx context.Context, objectKeys ...string) error {
+	if len(objectKeys) == 0 {
+		return nil
+	}
+	objects := make([]types.ObjectIdentifier, len(objectKeys))
+	for i, key := range objectKeys {
+		objects[i] = types.ObjectIdentifier{Key: aws.String(key)}
 	}
 
-	request, err := c.presignClient.PresignPostObject(ctx, &s3.PutObjectInput{
+	output, err := c.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
 		Bucket: aws.String(c.bucketName),
-		Key:    aws.String(objectKey),
-	}, func(options *s3.PresignPostOptions) {
-		options.Expires = c.lifetime
-		options.Conditions = policyJson
+		Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
 	})
 	if err != nil {
-		log.Printf("failed to generate a presigned post request: %v\n", err)
-		return nil, err
+		log.Printf("failed to delete objects: %v\n", err)
+		return err
+	}
+	if len(output.Errors) > 0 {
+		return fmt.Errorf("failed to delete object %s: %s", aws.ToString(output.Errors[0].Key), aws.ToString(output.Errors[0].Message))
+	}
+	return nil
+}
+
+// ListObjects lists the objects of the bucket whose key starts with prefix.
+func (c *S3Client) ListObjects(ctx context.Context, prefix string) ([]BlobObject, error) {
+	var objects []BlobObject
+	paginator := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("failed to list objects with prefix %s: %v\n", prefix, err)
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			objects = append(objects, BlobObject{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
 	}
-	return request, nil
+	return objects, nil
 }
@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobStore keeps the state of the scheduled jobs, shared by every instance of the server.
+type JobStore interface {
+	// EnsureJob creates the state of a job running first at firstRunAt, unless it exists already.
+	EnsureJob(ctx context.Context, name string, firstRunAt time.Time) error
+	// ClaimJob locks a job that is due for the given instance until the lease ends.
+	// It returns false when the job isn't due or another instance holds it.
+	ClaimJob(ctx context.Context, name, owner string, lease time.Duration) (bool, error)
+	// FinishJob releases a job claimed by the given instance and sets when it runs next.
+	FinishJob(ctx context.Context, name, owner string, nextRunAt time.Time, runErr error) error
+}
+
+// Schedule returns when a job runs next, after the given time.
+type Schedule func(after time.Time) time.Time
+
+// Daily is the schedule of a job running every day at the given hour.
+func Daily(hour int, loc *time.Location) Schedule {
+	return func(after time.Time) time.Time {
+		t := after.In(loc)
+		next := time.Date(t.Year(), t.Month(), t.Day(), hour, 0, 0, 0, loc)
+		if !next.After(t) {
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, hour, 0, 0, 0, loc)
+		}
+		return next
+	}
+}
+
+// Weekly is the schedule of a job running every week on the given day at the given hour.
+func Weekly(weekday time.Weekday, hour int, loc *time.Location) Schedule {
+	return func(after time.Time) time.Time {
+		t := after.In(loc)
+		days := (int(weekday) - int(t.Weekday()) + 7) % 7
+		next := time.Date(t.Year(), t.Month(), t.Day()+days, hour, 0, 0, 0, loc)
+		if !next.After(t) {
+			next = time.Date(t.Year(), t.Month(), t.Day()+days+7, hour, 0, 0, 0, loc)
+		}
+		return next
+	}
+}
+
+// Job is a task run in the background on a schedule.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs the registered jobs when they are due, by one instance of the server at a time.
+// Jobs missed while no instance was up run once when the next instance starts.
+type Scheduler struct {
+	store        JobStore
+	owner        string        // Identifies this instance in the job state
+	pollInterval time.Duration // How often due jobs are looked for
+	lease        time.Duration // Longest a job may run, another instance takes it over afterwards
+	jobs         []Job
+}
+
+// NewScheduler creates a Scheduler keeping the state of its jobs in store.
+func NewScheduler(store JobStore, pollInterval, lease time.Duration) *Scheduler {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return &Scheduler{
+		store:        store,
+		owner:        fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8]),
+		pollInterval: pollInterval,
+		lease:        lease,
+	}
+}
+
+// Register adds a job to the scheduler. Jobs must be registered before Start.
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start creates the state of new jobs and runs the due ones in the background until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) error {
+	now := time.Now()
+	for _, job := range s.jobs {
+		if err := s.store.EnsureJob(ctx, job.Name, job.Schedule(now)); err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			s.runDueJobs(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// runDueJobs runs, one after the other, the jobs this instance manages to claim.
+func (s *Scheduler) runDueJobs(ctx context.Context) {
+	for _, job := range s.jobs {
+		claimed, err := s.store.ClaimJob(ctx, job.Name, s.owner, s.lease)
+		if err != nil {
+			log.Printf("failed to claim job %s: %v", job.Name, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		start := time.Now()
+		runErr := s.run(ctx, job)
+		if runErr != nil {
+			log.Printf("Job %s failed after %s: %v", job.Name, time.Since(start), runErr)
+		} else {
+			log.Printf("Job %s finished in %s", job.Name, time.Since(start))
+		}
+
+		// The next run follows the schedule from now, runs missed meanwhile aren't caught up one by one
+		if err := s.store.FinishJob(ctx, job.Name, s.owner, job.Schedule(time.Now()), runErr); err != nil {
+			log.Printf("failed to release job %s: %v", job.Name, err)
+		}
+	}
+}
+
+// run runs a job within its lease, turning a panic into an error so the scheduler keeps going.
+func (s *Scheduler) run(ctx context.Context, job Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.lease)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+// SESMailer is the Mailer sending through AWS SES v2.
+type SESMailer struct {
+	sesClient *sesv2.Client
+	sender    mail.Address
+}
+
+// NewSESMailer creates a SESMailer. The sender address must be verified in SES.
+func NewSESMailer(cfg *aws.Config, sender mail.Address) *SESMailer {
+	return &SESMailer{
+		sesClient: sesv2.NewFromConfig(*cfg),
+		sender:    sender,
+	}
+}
+
+// SendMessage sends an email with its HTML and plain text bodies.
+func (m *SESMailer) SendMessage(ctx context.Context, message *EmailMessage) error {
+	input := &sesv2.SendEmailInput{
+		Destination: &types.Destination{
+			ToAddresses: []string{message.To},
+		},
+		Content: &types.EmailContent{
+			Simple: &types.Message{
+				Subject: &types.Content{
+					Data:    aws.String(message.Subject),
+					Charset: aws.String("UTF-8"),
+				},
+				Body: &types.Body{
+					Html: &types.Content{
+						Data:    aws.String(message.HTMLBody),
+						Charset: aws.String("UTF-8"),
+					},
+					Text: &types.Content{
+						Data:    aws.String(message.TextBody),
+						Charset: aws.String("UTF-8"),
+					},
+				},
+			},
+		},
+		FromEmailAddress: aws.String(m.sender.String()),
+	}
+
+	result, err := m.sesClient.SendEmail(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to send email via SES: %w", err)
+	}
+
+	logDelivery("SES", message.Subject, aws.ToString(result.MessageId))
+	return nil
+}
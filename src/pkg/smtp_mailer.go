@@ -0,0 +1,155 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"sama/sama-backend-2025/src/config"
+
+	"github.com/google/uuid"
+)
+
+// SMTPMailer is the Mailer sending through an SMTP relay, upgrading the connection with STARTTLS when offered.
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	sender   mail.Address
+}
+
+// NewSMTPMailer creates an SMTPMailer for the configured relay.
+func NewSMTPMailer(cfg *config.Config, sender mail.Address) (*SMTPMailer, error) {
+	if cfg.Mailer.SMTPHost == "" {
+		return nil, errors.New("MAILER_SMTP_HOST is required by the smtp mailer backend")
+	}
+	return &SMTPMailer{
+		host:     cfg.Mailer.SMTPHost,
+		port:     cfg.Mailer.SMTPPort,
+		username: cfg.Mailer.SMTPUsername,
+		password: cfg.Mailer.SMTPPassword,
+		sender:   sender,
+	}, nil
+}
+
+// SendMessage sends an email as a multipart message with its HTML and plain text bodies.
+func (m *SMTPMailer) SendMessage(ctx context.Context, message *EmailMessage) error {
+	content, messageID, err := buildMIMEMessage(m.sender, message)
+	if err != nil {
+		return err
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(m.host, strconv.Itoa(m.port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("failed to start TLS with SMTP server: %w", err)
+		}
+	}
+	if m.username != "" {
+		// PlainAuth refuses to send the password over an unencrypted connection to a remote host
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("failed to authenticate with SMTP server: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.sender.Address); err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %w", err)
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %w", err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %w", err)
+	}
+	if _, err := writer.Write(content); err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %w", err)
+	}
+	if err := client.Quit(); err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %w", err)
+	}
+
+	logDelivery("SMTP", message.Subject, messageID)
+	return nil
+}
+
+// buildMIMEMessage encodes an email as a multipart/alternative message and returns it with its Message-ID.
+func buildMIMEMessage(sender mail.Address, message *EmailMessage) ([]byte, string, error) {
+	domain := "localhost"
+	if _, host, ok := strings.Cut(sender.Address, "@"); ok {
+		domain = host
+	}
+	messageID := fmt.Sprintf("<%s@%s>", uuid.New().String(), domain)
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", message.TextBody},
+		{"text/html; charset=UTF-8", message.HTMLBody},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode email: %w", err)
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, "", fmt.Errorf("failed to encode email: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, "", fmt.Errorf("failed to encode email: %w", err)
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to encode email: %w", err)
+	}
+
+	var content bytes.Buffer
+	headers := [][2]string{
+		{"From", sender.String()},
+		{"To", message.To},
+		{"Subject", mime.QEncoding.Encode("UTF-8", message.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&content, "%s: %s\r\n", header[0], header[1])
+	}
+	content.WriteString("\r\n")
+	content.Write(body.Bytes())
+	return content.Bytes(), messageID, nil
+}
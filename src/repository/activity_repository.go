@@ -40,15 +40,25 @@ func (r *ActivityRepository) CreateActivity(activity *models.Activity) error {
 		}
 
 		activity.ExclusiveStudentObjects = make([]models.User, len(activity.ExclusiveStudentIDs))
-		// Get student's id first
+		// Get student's id first, only active students can be assigned
 		for i, id := range activity.ExclusiveStudentIDs {
-			if err := tx.Select("id").First(&activity.ExclusiveStudentObjects[i], "id = ?", id).Error; err != nil {
-				return fmt.Errorf("failed to find student %d: %w", id, err)
+			if err := tx.Select("id").First(&activity.ExclusiveStudentObjects[i], "id = ? AND school_id = ? AND status = ?", id, activity.SchoolID, "ACTIVE").Error; err != nil {
+				return fmt.Errorf("failed to find active student %d: %w", id, err)
+			}
+		}
+
+		activity.ExclusiveGroupObjects = make([]models.StudentGroup, len(activity.ExclusiveGroupIDs))
+		// Get group's id first, a group can be targeted by its owner or by anyone once shared
+		for i, id := range activity.ExclusiveGroupIDs {
+			err := tx.Select("id").First(&activity.ExclusiveGroupObjects[i],
+				"id = ? AND school_id = ? AND (owner_id = ? OR is_shared = ?)", id, activity.SchoolID, activity.OwnerID, true).Error
+			if err != nil {
+				return fmt.Errorf("failed to find student group %d: %w", id, err)
 			}
 		}
 
 		// Create activity with exclusiveClassroom association, omit the upesrt of classroom
-		err := tx.Model(activity).Omit("ExclusiveClassroomObjects.*").Omit("ExclusiveStudentObjects.*").Create(activity).Error
+		err := tx.Model(activity).Omit("ExclusiveClassroomObjects.*").Omit("ExclusiveStudentObjects.*").Omit("ExclusiveGroupObjects.*").Create(activity).Error
 		if err != nil {
 			return fmt.Errorf("failed to create activity: %w", err)
 		}
@@ -57,10 +67,19 @@ func (r *ActivityRepository) CreateActivity(activity *models.Activity) error {
 	})
 }
 
-// GetActivityByID retrieves an activity by its ID, preloading custom student IDs.
-func (r *ActivityRepository) GetActivityByID(id uint) (*models.ActivityWithStatistic, error) {
+// GetActivityByID retrieves an activity by its ID within the tenant scope, preloading custom student IDs.
+func (r *ActivityRepository) GetActivityByID(scope TenantScope, id uint) (*models.ActivityWithStatistic, error) {
 	var activity models.ActivityWithStatistic
 
+	// Ensure the activity is visible in the tenant scope before aggregating its records
+	var scopedActivity models.Activity
+	if err := r.db.Scopes(scope.Activities).Select("id").First(&scopedActivity, "activities.id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("activity with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to retrieve activity by ID: %w", err)
+	}
+
 	query := `
         SELECT 
             ac.*,
@@ -94,6 +113,7 @@ func (r *ActivityRepository) GetActivityByID(id uint) (*models.ActivityWithStati
 	err = r.db.Model(&activity.Activity).
 		Preload("ExclusiveStudentObjects").
 		Preload("ExclusiveClassroomObjects").
+		Preload("ExclusiveGroupObjects", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select("id") }).
 		Where("id = ?", id).
 		First(&activity.Activity).Error
 
@@ -108,7 +128,7 @@ func (r *ActivityRepository) GetActivityByID(id uint) (*models.ActivityWithStati
 
 // GetAllActivities retrieves all activities with pagination, optionally filtering by owner ID or school ID/year/semester.
 // This method can be expanded for more complex filtering.
-func (r *ActivityRepository) GetAllActivities(ownerID, schoolID, semester, schoolYear uint, limit, offset int) ([]models.Activity, int, error) {
+func (r *ActivityRepository) GetAllActivities(scope TenantScope, ownerID, schoolID, semester, schoolYear uint, limit, offset int) ([]models.Activity, int, error) {
 	var activities []models.Activity
 	var count int64
 	// Start building the query
@@ -124,13 +144,14 @@ func (r *ActivityRepository) GetAllActivities(ownerID, schoolID, semester, schoo
 	query = query.Joins("LEFT JOIN schools ON activities.school_id = schools.id")
 
 	// Apply primary filters
-	query = query.Where("activities.semester = ? AND activities.school_year = ?", semester, schoolYear)
-	countQuery := r.db.Model(&models.Activity{}).Where("activities.semester = ? AND activities.school_year = ?", semester, schoolYear)
+	query = query.Where("activities.semester = ? AND activities.school_year = ?", semester, schoolYear).Scopes(scope.Activities)
+	countQuery := r.db.Model(&models.Activity{}).Where("activities.semester = ? AND activities.school_year = ?", semester, schoolYear).Scopes(scope.Activities)
 
 	// Apply Preloads (these will still work correctly because we're using GORM's builder)
 	query = query. // Preload School model (might not be necessary if you only need default_activity_deadline)
 			Preload("ExclusiveStudentObjects").
 			Preload("ExclusiveClassroomObjects").
+			Preload("ExclusiveGroupObjects", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select("id") }).
 			Model(&models.Activity{})
 
 	// Apply ownerID filter
@@ -157,10 +178,11 @@ func (r *ActivityRepository) GetAllActivities(ownerID, schoolID, semester, schoo
 func (r *ActivityRepository) GetAssignedActivitiesByUserID(userID, schoolID, semester, schoolYear uint, sortByRequired bool) ([]models.ActivityWithStatistic, error) {
 	activities := make([]models.ActivityWithStatistic, 0)
 
-	// Query all activities assigned to user based on 3 condition
+	// Query all activities assigned to user based on 4 condition
 	// 1. activities is for junior or senior
 	// 2. activitity exclusive classroom contain classroom of user
 	// 3. activity exclusive student id contain user
+	// 4. activity exclusive student group contain user
 	baseQuery := `
 		SELECT 
 			ac.*,
@@ -209,6 +231,15 @@ func (r *ActivityRepository) GetAssignedActivitiesByUserID(userID, schoolID, sem
 				WHERE aes.activity_id = ac.id
 				AND aes.user_id = ? -- Target user ID
 			)
+			OR
+			-- Condition 4: Check if activity is assigned to a student group of the user, deleted groups included
+			EXISTS (
+				SELECT 1
+				FROM activity_exclusive_groups aeg
+				JOIN student_group_members sgm ON aeg.student_group_id = sgm.student_group_id
+				WHERE aeg.activity_id = ac.id
+				AND sgm.user_id = ? -- Target user ID
+			)
 		)
 		GROUP BY ac.id, s.default_activity_deadline
 	`
@@ -223,7 +254,7 @@ func (r *ActivityRepository) GetAssignedActivitiesByUserID(userID, schoolID, sem
 
 	query := baseQuery + orderByClause
 
-	if err := r.db.Raw(query, userID, schoolID, semester, schoolYear, userID, userID, userID).Scan(&activities).Error; err != nil {
+	if err := r.db.Raw(query, userID, schoolID, semester, schoolYear, userID, userID, userID, userID).Scan(&activities).Error; err != nil {
 		return activities, fmt.Errorf("failed to get activities: %w", err)
 	}
 
@@ -234,13 +265,62 @@ func (r *ActivityRepository) GetAssignedActivitiesByUserID(userID, schoolID, sem
 	return activities, nil
 }
 
-// UpdateActivity updates an existing activity record.
+// GetAssignedStudentsByActivityID retrieves the active students an activity is assigned to,
+// using the same conditions as GetAssignedActivitiesByUserID.
+func (r *ActivityRepository) GetAssignedStudentsByActivityID(activityID uint) ([]models.User, error) {
+	students := make([]models.User, 0)
+
+	query := `
+		SELECT u.*
+		FROM users u
+		JOIN activities ac ON ac.id = ? AND ac.school_id = u.school_id
+		LEFT JOIN classrooms cl ON u.classroom_id = cl.id
+		WHERE u.role = 'STD' AND
+			  u.status = 'ACTIVE' AND
+			  u.deleted_at IS NULL AND
+		(
+			(ac.is_for_junior = TRUE AND cl.is_junior = TRUE) OR
+			(ac.is_for_senior = TRUE AND cl.is_junior = FALSE)
+			OR
+			EXISTS (
+				SELECT 1
+				FROM activity_exclusive_classroom aec
+				WHERE aec.activity_id = ac.id
+				AND aec.classroom_id = u.classroom_id
+			)
+			OR
+			EXISTS (
+				SELECT 1
+				FROM activity_exclusive_student_ids aes
+				WHERE aes.activity_id = ac.id
+				AND aes.user_id = u.id
+			)
+			OR
+			EXISTS (
+				SELECT 1
+				FROM activity_exclusive_groups aeg
+				JOIN student_group_members sgm ON aeg.student_group_id = sgm.student_group_id
+				WHERE aeg.activity_id = ac.id
+				AND sgm.user_id = u.id
+			)
+		)
+		ORDER BY u.id ASC
+	`
+
+	if err := r.db.Raw(query, activityID).Scan(&students).Error; err != nil {
+		return students, fmt.Errorf("failed to get assigned students: %w", err)
+	}
+
+	return students, nil
+}
+
+// UpdateActivity updates an existing activity record within the tenant scope.
 // This includes handling updates to the CustomStudentIDs association.
-func (r *ActivityRepository) UpdateActivity(activity *models.Activity) error {
+func (r *ActivityRepository) UpdateActivity(scope TenantScope, activity *models.Activity) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 
 		var existedActivity models.Activity
-		if err := tx.Where("id = ?", activity.ID).First(&existedActivity).Error; err != nil {
+		if err := tx.Scopes(scope.Activities).Where("activities.id = ?", activity.ID).First(&existedActivity).Error; err != nil {
 			return fmt.Errorf("failed to find existed activity: %w", err)
 		}
 
@@ -269,10 +349,24 @@ func (r *ActivityRepository) UpdateActivity(activity *models.Activity) error {
 		}
 
 		activity.ExclusiveStudentObjects = make([]models.User, len(activity.ExclusiveStudentIDs))
-		// Get student's id first
+		// Get student's id first, only active students can be newly assigned while those already assigned are kept
 		for i, id := range activity.ExclusiveStudentIDs {
-			if err := tx.Select("id").First(&activity.ExclusiveStudentObjects[i], "id = ?", id).Error; err != nil {
-				return fmt.Errorf("failed to find student %d: %w", id, err)
+			err := tx.Select("id").First(&activity.ExclusiveStudentObjects[i],
+				"id = ? AND school_id = ? AND (status = ? OR id IN (SELECT user_id FROM activity_exclusive_student_ids WHERE activity_id = ?))",
+				id, activity.SchoolID, "ACTIVE", activity.ID).Error
+			if err != nil {
+				return fmt.Errorf("failed to find active student %d: %w", id, err)
+			}
+		}
+
+		activity.ExclusiveGroupObjects = make([]models.StudentGroup, len(activity.ExclusiveGroupIDs))
+		// Get group's id first, groups already targeted are kept even if they are no longer shared
+		for i, id := range activity.ExclusiveGroupIDs {
+			err := tx.Unscoped().Select("id").First(&activity.ExclusiveGroupObjects[i],
+				"id = ? AND school_id = ? AND (deleted_at IS NULL AND (owner_id = ? OR is_shared = ?) OR id IN (SELECT student_group_id FROM activity_exclusive_groups WHERE activity_id = ?))",
+				id, activity.SchoolID, activity.OwnerID, true, activity.ID).Error
+			if err != nil {
+				return fmt.Errorf("failed to find student group %d: %w", id, err)
 			}
 		}
 
@@ -291,15 +385,20 @@ func (r *ActivityRepository) UpdateActivity(activity *models.Activity) error {
 			return fmt.Errorf("failed to update exclusive student: %w", err)
 		}
 
+		// Update the link to exclusive student group using Replace (delete all previous link, then create every new link)
+		if err := tx.Model(activity).Association("ExclusiveGroupObjects").Replace(activity.ExclusiveGroupObjects); err != nil {
+			return fmt.Errorf("failed to update exclusive student group: %w", err)
+		}
+
 		return nil
 	})
 }
 
-// DeleteActivity deletes an activity record by its ID.
+// DeleteActivity deletes an activity record by its ID within the tenant scope.
 // GORM's soft delete (DeletedAt) will be applied. Associations might need explicit handling
 // if you want to clean up join table entries on hard delete, but for soft delete, they remain.
-func (r *ActivityRepository) DeleteActivity(id uint) error {
-	result := r.db.Delete(&models.Activity{}, id)
+func (r *ActivityRepository) DeleteActivity(scope TenantScope, id uint) error {
+	result := r.db.Scopes(scope.Activities).Delete(&models.Activity{}, id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete activity: %w", result.Error)
 	}
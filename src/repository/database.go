@@ -50,9 +50,33 @@ func AutoMigrate() error {
 	DB.AutoMigrate(&models.User{})
 	DB.AutoMigrate(&models.School{})
 	DB.AutoMigrate(&models.Classroom{})
+	// Student groups are targeted by activities, so their table must exist first
+	DB.AutoMigrate(&models.StudentGroup{})
 	DB.AutoMigrate(&models.Activity{})
 	DB.AutoMigrate(&models.Record{})
 	DB.AutoMigrate(&models.OTP{})
+	// OTP codes used to be stored in plain text, only their hash is kept now
+	if DB.Migrator().HasColumn(&models.OTP{}, "code") {
+		DB.Migrator().DropColumn(&models.OTP{}, "code")
+	}
+	DB.AutoMigrate(&models.PasswordResetToken{})
+	DB.AutoMigrate(&models.SchoolArchive{})
+	DB.AutoMigrate(&models.SchoolSettings{})
+	DB.AutoMigrate(&models.TranscriptIssue{})
+	DB.AutoMigrate(&models.InvitationCode{})
+	DB.AutoMigrate(&models.InvitationRedemption{})
+	DB.AutoMigrate(&models.RefreshToken{})
+	DB.AutoMigrate(&models.Session{})
+	DB.AutoMigrate(&models.RateLimitEntry{})
+	DB.AutoMigrate(&models.GuardianLink{})
+	DB.AutoMigrate(&models.ImpersonationAudit{})
+	DB.AutoMigrate(&models.EmailVerification{})
+	DB.AutoMigrate(&models.IdentityProvider{})
+	DB.AutoMigrate(&models.SSOLoginState{})
+	DB.AutoMigrate(&models.UserStatusLog{})
+	DB.AutoMigrate(&models.Notification{})
+	DB.AutoMigrate(&models.NotificationPreference{})
+	DB.AutoMigrate(&models.ScheduledJob{})
 	return nil
 }
 
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EmailVerificationRepository handles database operations for the EmailVerification model.
+type EmailVerificationRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailVerificationRepository creates a new instance of EmailVerificationRepository.
+func NewEmailVerificationRepository() *EmailVerificationRepository {
+	return &EmailVerificationRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateEmailVerification generates a code to verify the email of a user, replacing any pending verification.
+// The plain code is returned to be sent to the email, it cannot be read back later.
+func (r *EmailVerificationRepository) CreateEmailVerification(userID uint, email string, lifetime time.Duration) (string, error) {
+	code, err := utils.GenerateOTPCode()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	codeHash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash verification code: %w", err)
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.EmailVerification{}, "user_id = ?", userID).Error; err != nil {
+			return fmt.Errorf("failed to delete existing email verification: %w", err)
+		}
+		verification := &models.EmailVerification{
+			UserID:    userID,
+			Email:     email,
+			CodeHash:  string(codeHash),
+			ExpiresAt: time.Now().Add(lifetime),
+		}
+		if err := tx.Create(verification).Error; err != nil {
+			return fmt.Errorf("failed to create email verification: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// DeleteEmailVerification discards the pending verification of a user.
+func (r *EmailVerificationRepository) DeleteEmailVerification(userID uint) error {
+	if err := r.db.Delete(&models.EmailVerification{}, "user_id = ?", userID).Error; err != nil {
+		return fmt.Errorf("failed to delete email verification: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailVerification checks the code of a user and, when it matches, makes the verified address their email.
+// The verification row is locked while it is checked, so parallel guesses are counted one by one;
+// it stops working after maxAttempts wrong codes. It returns false when the code is wrong, expired or out of attempts.
+func (r *EmailVerificationRepository) ConfirmEmailVerification(userID uint, code string, maxAttempts int) (bool, error) {
+	confirmed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var verification models.EmailVerification
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&verification).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil // No verification requested
+			}
+			return fmt.Errorf("failed to query email verification: %w", err)
+		}
+
+		if time.Now().After(verification.ExpiresAt) || verification.Attempts >= maxAttempts {
+			return nil
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(verification.CodeHash), []byte(code)) != nil {
+			if err := tx.Model(&verification).Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+				return fmt.Errorf("failed to count verification attempt: %w", err)
+			}
+			return nil
+		}
+
+		if err := tx.Delete(&verification).Error; err != nil {
+			return fmt.Errorf("failed to consume email verification: %w", err)
+		}
+		err = tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"email":             verification.Email,
+			"email_verified_at": time.Now(),
+			"pending_email":     nil,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to verify user email: %w", err)
+		}
+
+		confirmed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return confirmed, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// GuardianRepository handles database operations for the links between guardians and students.
+type GuardianRepository struct {
+	db *gorm.DB
+}
+
+// NewGuardianRepository creates a new instance of GuardianRepository.
+func NewGuardianRepository() *GuardianRepository {
+	return &GuardianRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateGuardianLink links a guardian to a student.
+func (r *GuardianRepository) CreateGuardianLink(link *models.GuardianLink) error {
+	if err := r.db.Create(link).Error; err != nil {
+		return fmt.Errorf("failed to create guardian link: %w", err)
+	}
+	return nil
+}
+
+// DeleteGuardianLink removes the link between a guardian and a student.
+// The row is removed for good so the pair can be linked again, only archiving a school soft deletes its links.
+func (r *GuardianRepository) DeleteGuardianLink(guardianID, studentID uint) error {
+	result := r.db.Unscoped().Where("guardian_id = ? AND student_id = ?", guardianID, studentID).Delete(&models.GuardianLink{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete guardian link: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("guardian is not linked to student %d", studentID)
+	}
+	return nil
+}
+
+// GetGuardianLinksByGuardianID retrieves the students linked to a guardian.
+func (r *GuardianRepository) GetGuardianLinksByGuardianID(guardianID uint) ([]models.GuardianLink, error) {
+	var links []models.GuardianLink
+	err := r.db.Preload("Student").Where("guardian_id = ?", guardianID).Order("student_id").Find(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve guardian links: %w", err)
+	}
+	return links, nil
+}
+
+// IsGuardianOf reports whether the guardian is linked to the student.
+func (r *GuardianRepository) IsGuardianOf(guardianID, studentID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.GuardianLink{}).Where("guardian_id = ? AND student_id = ?", guardianID, studentID).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check guardian link: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// IdentityProviderRepository handles database operations for identity providers and the sign-ins started with them.
+type IdentityProviderRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityProviderRepository creates a new instance of IdentityProviderRepository.
+func NewIdentityProviderRepository() *IdentityProviderRepository {
+	return &IdentityProviderRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateIdentityProvider creates a new identity provider.
+func (r *IdentityProviderRepository) CreateIdentityProvider(provider *models.IdentityProvider) error {
+	if err := r.db.Create(provider).Error; err != nil {
+		return fmt.Errorf("failed to create identity provider: %w", err)
+	}
+	return nil
+}
+
+// GetIdentityProviderByID retrieves an identity provider by its ID.
+func (r *IdentityProviderRepository) GetIdentityProviderByID(id uint) (*models.IdentityProvider, error) {
+	var provider models.IdentityProvider
+	if err := r.db.First(&provider, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("identity provider with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to retrieve identity provider by ID: %w", err)
+	}
+	return &provider, nil
+}
+
+// GetIdentityProvidersBySchoolID retrieves the identity providers of a school.
+func (r *IdentityProviderRepository) GetIdentityProvidersBySchoolID(schoolID uint) ([]models.IdentityProvider, error) {
+	var providers []models.IdentityProvider
+	if err := r.db.Where("school_id = ?", schoolID).Order("id").Find(&providers).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve identity providers: %w", err)
+	}
+	return providers, nil
+}
+
+// GetEnabledIdentityProviders retrieves every enabled identity provider.
+func (r *IdentityProviderRepository) GetEnabledIdentityProviders() ([]models.IdentityProvider, error) {
+	var providers []models.IdentityProvider
+	if err := r.db.Where("enabled = ?", true).Order("id").Find(&providers).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve identity providers: %w", err)
+	}
+	return providers, nil
+}
+
+// UpdateIdentityProvider saves an existing identity provider.
+func (r *IdentityProviderRepository) UpdateIdentityProvider(provider *models.IdentityProvider) error {
+	if err := r.db.Save(provider).Error; err != nil {
+		return fmt.Errorf("failed to update identity provider: %w", err)
+	}
+	return nil
+}
+
+// DeleteIdentityProvider deletes an identity provider by its ID.
+// The row is removed for good, only archiving a school soft deletes its providers.
+func (r *IdentityProviderRepository) DeleteIdentityProvider(id uint) error {
+	result := r.db.Unscoped().Delete(&models.IdentityProvider{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete identity provider: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("identity provider with ID %d not found", id)
+	}
+	return nil
+}
+
+// CreateLoginState stores a sign-in started at an identity provider, and removes the expired ones.
+func (r *IdentityProviderRepository) CreateLoginState(state *models.SSOLoginState) error {
+	if err := r.db.Delete(&models.SSOLoginState{}, "expires_at < ?", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to delete expired sso login states: %w", err)
+	}
+	if err := r.db.Create(state).Error; err != nil {
+		return fmt.Errorf("failed to create sso login state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeLoginState deletes the sign-in of a state and returns it, so a state can only be used once.
+func (r *IdentityProviderRepository) ConsumeLoginState(stateHash string) (*models.SSOLoginState, error) {
+	var state models.SSOLoginState
+	result := r.db.Clauses(clause.Returning{}).
+		Where("state_hash = ? AND expires_at > ?", stateHash, time.Now()).
+		Delete(&state)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to consume sso login state: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("invalid or expired sso state")
+	}
+	return &state, nil
+}
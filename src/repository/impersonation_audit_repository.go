@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// ImpersonationAuditRepository handles database operations for the impersonation audit trail.
+type ImpersonationAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewImpersonationAuditRepository creates a new instance of ImpersonationAuditRepository.
+func NewImpersonationAuditRepository() *ImpersonationAuditRepository {
+	return &ImpersonationAuditRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateImpersonationAudit stores an entry of the audit trail.
+func (r *ImpersonationAuditRepository) CreateImpersonationAudit(audit *models.ImpersonationAudit) error {
+	if err := r.db.Create(audit).Error; err != nil {
+		return fmt.Errorf("failed to create impersonation audit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// InvitationRepository handles database operations for invitation codes.
+type InvitationRepository struct {
+	db *gorm.DB
+}
+
+// NewInvitationRepository creates a new instance of InvitationRepository.
+func NewInvitationRepository() *InvitationRepository {
+	return &InvitationRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateInvitationCode creates a new invitation code.
+func (r *InvitationRepository) CreateInvitationCode(invitation *models.InvitationCode) error {
+	if err := r.db.Create(invitation).Error; err != nil {
+		return fmt.Errorf("failed to create invitation code: %w", err)
+	}
+	return nil
+}
+
+// GetInvitationCodeByID retrieves an invitation code with its redemptions within the tenant scope.
+func (r *InvitationRepository) GetInvitationCodeByID(scope TenantScope, id uint) (*models.InvitationCode, error) {
+	var invitation models.InvitationCode
+	err := r.db.Scopes(scope.InvitationCodes).Preload("Redemptions").First(&invitation, "invitation_codes.id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("invitation code with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to retrieve invitation code by ID: %w", err)
+	}
+	return &invitation, nil
+}
+
+// GetInvitationCodeByCode retrieves an invitation code by the code shared with users.
+func (r *InvitationRepository) GetInvitationCodeByCode(code string) (*models.InvitationCode, error) {
+	var invitation models.InvitationCode
+	err := r.db.First(&invitation, "code = ?", code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("invitation code %s not found", code)
+		}
+		return nil, fmt.Errorf("failed to retrieve invitation code: %w", err)
+	}
+	return &invitation, nil
+}
+
+// GetInvitationCodesBySchoolID retrieves the invitation codes of a school with pagination, newest first.
+func (r *InvitationRepository) GetInvitationCodesBySchoolID(scope TenantScope, schoolID uint, limit, offset int) ([]models.InvitationCode, int, error) {
+	var invitations []models.InvitationCode
+	var count int64
+
+	query := r.db.Model(&models.InvitationCode{}).Scopes(scope.InvitationCodes).Where("invitation_codes.school_id = ?", schoolID)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count invitation codes: %w", err)
+	}
+
+	if err := query.Order("invitation_codes.id DESC").Limit(limit).Offset(offset).Find(&invitations).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve invitation codes: %w", err)
+	}
+	return invitations, int(count), nil
+}
+
+// RevokeInvitationCode marks an invitation code as revoked within the tenant scope.
+func (r *InvitationRepository) RevokeInvitationCode(scope TenantScope, id uint) error {
+	result := r.db.Model(&models.InvitationCode{}).Scopes(scope.InvitationCodes).
+		Where("invitation_codes.id = ? AND invitation_codes.revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke invitation code: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("invitation code with ID %d not found", id)
+	}
+	return nil
+}
+
+// RedeemInvitationCode uses one slot of an invitation code and creates the user in the same transaction.
+// The usage is counted with a conditional update, so concurrent registrations cannot exceed the limit.
+func (r *InvitationRepository) RedeemInvitationCode(invitationID uint, user *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		result := tx.Model(&models.InvitationCode{}).
+			Where("id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?) AND (max_uses = 0 OR used_count < max_uses)", invitationID, now).
+			Update("used_count", gorm.Expr("used_count + 1"))
+		if result.Error != nil {
+			return fmt.Errorf("failed to use invitation code: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invitation code is invalid or expired")
+		}
+
+		if err := (&UserRepository{db: tx}).CreateUser(user); err != nil {
+			return err
+		}
+
+		redemption := models.InvitationRedemption{
+			InvitationCodeID: invitationID,
+			UserID:           user.ID,
+			RedeemedAt:       now,
+		}
+		if err := tx.Create(&redemption).Error; err != nil {
+			return fmt.Errorf("failed to record invitation redemption: %w", err)
+		}
+
+		return nil
+	})
+}
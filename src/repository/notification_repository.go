@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// NotificationRepository handles database operations for notifications and notification preferences.
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new instance of NotificationRepository.
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateNotifications stores new notifications in one statement.
+func (r *NotificationRepository) CreateNotifications(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := r.db.Create(&notifications).Error; err != nil {
+		return fmt.Errorf("failed to create notifications: %w", err)
+	}
+	return nil
+}
+
+// GetNotificationsByUserID retrieves the notifications of a user with pagination, newest first.
+// Only unread ones are returned when unreadOnly is set.
+func (r *NotificationRepository) GetNotificationsByUserID(userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, int, error) {
+	notifications := make([]models.Notification, 0)
+	var total int64
+
+	query := r.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve notifications: %w", err)
+	}
+
+	return notifications, int(total), nil
+}
+
+// CountUnreadNotifications counts the notifications a user hasn't read.
+func (r *NotificationRepository) CountUnreadNotifications(userID uint) (int, error) {
+	var count int64
+	if err := r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return int(count), nil
+}
+
+// MarkNotificationRead marks a notification of a user as read, keeping the time it was first read.
+func (r *NotificationRepository) MarkNotificationRead(userID, id uint) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.First(&notification, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("notification with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to retrieve notification by ID: %w", err)
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := r.db.Model(&notification).Update("read_at", now).Error; err != nil {
+			return nil, fmt.Errorf("failed to mark notification as read: %w", err)
+		}
+		notification.ReadAt = &now
+	}
+	return &notification, nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of a user as read and returns how many there were.
+func (r *NotificationRepository) MarkAllNotificationsRead(userID uint) (int, error) {
+	result := r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// GetNotifiedActivityIDs retrieves the activities a user was notified of for one event.
+func (r *NotificationRepository) GetNotifiedActivityIDs(userID uint, notificationType string) ([]uint, error) {
+	activityIDs := make([]uint, 0)
+	err := r.db.Model(&models.Notification{}).Distinct("activity_id").
+		Where("user_id = ? AND type = ? AND activity_id IS NOT NULL", userID, notificationType).
+		Pluck("activity_id", &activityIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve notified activities: %w", err)
+	}
+	return activityIDs, nil
+}
+
+// DeleteNotificationsBefore deletes the notifications of every user created before the cutoff.
+func (r *NotificationRepository) DeleteNotificationsBefore(cutoff time.Time) (int, error) {
+	result := r.db.Where("created_at < ?", cutoff).Delete(&models.Notification{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old notifications: %w", result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// GetNotificationPreferencesByUserIDs retrieves the preferences of several users for one event, by user ID.
+// Users who never set a preference for it are missing from the map.
+func (r *NotificationRepository) GetNotificationPreferencesByUserIDs(userIDs []uint, notificationType string) (map[uint]bool, error) {
+	preferences := make([]models.NotificationPreference, 0)
+	if len(userIDs) > 0 {
+		if err := r.db.Where("user_id IN ? AND type = ?", userIDs, notificationType).Find(&preferences).Error; err != nil {
+			return nil, fmt.Errorf("failed to retrieve notification preferences: %w", err)
+		}
+	}
+
+	emailByUserID := make(map[uint]bool, len(preferences))
+	for _, preference := range preferences {
+		emailByUserID[preference.UserID] = preference.Email
+	}
+	return emailByUserID, nil
+}
+
+// GetNotificationPreferencesByUserID retrieves the preferences a user has set.
+func (r *NotificationRepository) GetNotificationPreferencesByUserID(userID uint) ([]models.NotificationPreference, error) {
+	preferences := make([]models.NotificationPreference, 0)
+	if err := r.db.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve notification preferences: %w", err)
+	}
+	return preferences, nil
+}
+
+// SaveNotificationPreferences creates or replaces preferences of a user.
+func (r *NotificationRepository) SaveNotificationPreferences(preferences []models.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"email"}),
+	}).Create(&preferences).Error
+	if err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return nil
+}
@@ -1,12 +1,15 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"sama/sama-backend-2025/src/models"
 	"sama/sama-backend-2025/src/utils"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // OTPRepository handles database operations for the OTP model.
@@ -21,50 +24,83 @@ func NewOTPRepository() *OTPRepository {
 	}
 }
 
-// CreateOrUpdateOTP generates a new OTP and saves it to the database.
+// CreateOrUpdateOTP generates a new OTP valid for the given lifetime and saves its hash to the database.
 // It will also delete any existing OTP for the user to prevent conflicts.
-func (r *OTPRepository) CreateOTP(userID uint) (*models.OTP, error) {
+// The plain code is returned to be sent to the user, it cannot be read back later.
+func (r *OTPRepository) CreateOTP(userID uint, lifetime time.Duration) (string, error) {
 	// Step 1: Generate a new OTP code and set its expiration
-	otpCode := utils.GenerateOTPCode()
-	expiresAt := time.Now().Add(5 * time.Minute)
+	otpCode, err := utils.GenerateOTPCode()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate OTP: %w", err)
+	}
+	codeHash, err := bcrypt.GenerateFromPassword([]byte(otpCode), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash OTP: %w", err)
+	}
+	expiresAt := time.Now().Add(lifetime)
 
 	// Step 2: Delete any existing OTP for the user to ensure uniqueness
 	if err := r.db.Delete(&models.OTP{}, "user_id = ?", userID).Error; err != nil {
-		return nil, fmt.Errorf("failed to delete existing OTP: %w", err)
+		return "", fmt.Errorf("failed to delete existing OTP: %w", err)
 	}
 
 	// Step 3: Create the new OTP
 	otp := &models.OTP{
 		UserID:    userID,
-		Code:      otpCode,
+		CodeHash:  string(codeHash),
 		ExpiresAt: expiresAt,
 	}
 
 	if err := r.db.Create(otp).Error; err != nil {
-		return nil, fmt.Errorf("failed to create new OTP: %w", err)
+		return "", fmt.Errorf("failed to create new OTP: %w", err)
 	}
 
-	return otp, nil
+	return otpCode, nil
 }
 
-// VerifyOTP checks if a given OTP code is valid and not expired.
-func (r *OTPRepository) VerifyOTP(userID uint, code string) (bool, error) {
-	var otp models.OTP
-	result := r.db.Where("user_id = ? AND code = ?", userID, code).First(&otp)
+// ExchangeOTP consumes the OTP of a user for a password reset token.
+// The OTP row is locked while it is checked, so a code can only be exchanged once and parallel
+// guesses are counted one by one; the OTP stops working after maxAttempts wrong codes.
+// It returns false when the code is wrong, expired or out of attempts.
+func (r *OTPRepository) ExchangeOTP(userID uint, code string, maxAttempts int, resetToken *models.PasswordResetToken) (bool, error) {
+	exchanged := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var otp models.OTP
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&otp).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil // No OTP requested
+			}
+			return fmt.Errorf("failed to query OTP: %w", err)
+		}
 
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			return false, nil // Code not found
+		// Check if the OTP is expired or used up
+		if time.Now().After(otp.ExpiresAt) || otp.Attempts >= maxAttempts {
+			return nil
 		}
-		return false, fmt.Errorf("failed to query OTP: %w", result.Error)
-	}
 
-	// Check if the OTP is expired
-	if time.Now().After(otp.ExpiresAt) {
-		return false, nil // OTP is expired
-	}
+		if bcrypt.CompareHashAndPassword([]byte(otp.CodeHash), []byte(code)) != nil {
+			if err := tx.Model(&otp).Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+				return fmt.Errorf("failed to count OTP attempt: %w", err)
+			}
+			return nil
+		}
+
+		if err := tx.Delete(&otp).Error; err != nil {
+			return fmt.Errorf("failed to consume OTP: %w", err)
+		}
+		resetToken.UserID = userID
+		if err := tx.Create(resetToken).Error; err != nil {
+			return fmt.Errorf("failed to create password reset token: %w", err)
+		}
 
-	return true, nil
+		exchanged = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return exchanged, nil
 }
 
 // DeleteOTP
@@ -78,3 +114,27 @@ func (r *OTPRepository) DeleteOTP(userID uint) error {
 
 	return nil
 }
+
+// ConsumeResetToken marks a password reset token as used and returns its user.
+// The conditional update makes sure a token can only be used once.
+func (r *OTPRepository) ConsumeResetToken(tokenHash string) (uint, error) {
+	var resetToken models.PasswordResetToken
+	if err := r.db.First(&resetToken, "token_hash = ?", tokenHash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("invalid or expired reset token")
+		}
+		return 0, fmt.Errorf("failed to retrieve password reset token: %w", err)
+	}
+
+	now := time.Now()
+	result := r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", resetToken.ID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to consume password reset token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, errors.New("invalid or expired reset token")
+	}
+	return resetToken.UserID, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// RateLimitRepository is a rate limit store kept in the database, shared by every instance of the server.
+// It implements pkg.RateLimitStore.
+type RateLimitRepository struct {
+	db *gorm.DB
+}
+
+// NewRateLimitRepository creates a new instance of RateLimitRepository.
+func NewRateLimitRepository() *RateLimitRepository {
+	return &RateLimitRepository{
+		db: GetDB(),
+	}
+}
+
+// Increment adds one to the counter of key and returns the new value.
+// The upsert keeps concurrent increments from different instances consistent.
+func (r *RateLimitRepository) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	now := time.Now()
+	var count int
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO rate_limit_entries (key, count, window_ends_at) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limit_entries.window_ends_at <= ? THEN 1 ELSE rate_limit_entries.count + 1 END,
+			window_ends_at = CASE WHEN rate_limit_entries.window_ends_at <= ? THEN EXCLUDED.window_ends_at ELSE rate_limit_entries.window_ends_at END
+		RETURNING count`, key, now.Add(window), now, now).Scan(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment rate limit counter: %w", err)
+	}
+	return count, nil
+}
+
+// Lock blocks key until the given time.
+func (r *RateLimitRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	entry := models.RateLimitEntry{Key: key, WindowEndsAt: time.Now(), LockedUntil: &until}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"locked_until"}),
+	}).Create(&entry).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock rate limit key: %w", err)
+	}
+	return nil
+}
+
+// LockedUntil returns when the lock of key ends, or the zero time if key is not locked.
+func (r *RateLimitRepository) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	var entry models.RateLimitEntry
+	err := r.db.WithContext(ctx).First(&entry, "key = ?", key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to retrieve rate limit lock: %w", err)
+	}
+	if entry.LockedUntil == nil || !time.Now().Before(*entry.LockedUntil) {
+		return time.Time{}, nil
+	}
+	return *entry.LockedUntil, nil
+}
+
+// Reset clears the counter and the lock of key.
+func (r *RateLimitRepository) Reset(ctx context.Context, key string) error {
+	if err := r.db.WithContext(ctx).Delete(&models.RateLimitEntry{}, "key = ?", key).Error; err != nil {
+		return fmt.Errorf("failed to reset rate limit key: %w", err)
+	}
+	return nil
+}
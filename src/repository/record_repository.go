@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"sama/sama-backend-2025/src/models"
 )
@@ -26,12 +27,13 @@ func (r *RecordRepository) CreateRecord(record *models.Record) error {
 	return r.db.Create(record).Error
 }
 
-// GetRecordByID retrieves a record by its primary ID.
-func (r *RecordRepository) GetRecordByID(id uint) (*models.Record, error) {
+// GetRecordByID retrieves a record by its primary ID within the tenant scope.
+func (r *RecordRepository) GetRecordByID(scope TenantScope, id uint) (*models.Record, error) {
 	var record models.Record
-	// Preload any associations if needed (e.g., Activity, School, Student, Teacher)
-	// For example: .Preload("Activity").Preload("School")...
-	err := r.db.Joins("Teacher").First(&record, id).Error
+	// The activity carries the school of the record, which authorization rules rely on
+	err := r.db.Scopes(scope.Records).Joins("Teacher").
+		Preload("Activity", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		First(&record, "records.id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("record with ID %d not found", id)
@@ -44,13 +46,14 @@ func (r *RecordRepository) GetRecordByID(id uint) (*models.Record, error) {
 // GetAllRecords retrieves all records with pagination and optional filtering.
 // Filters can be added based on SchoolID, StudentID, TeacherID, ActivityID, Status etc.
 func (r *RecordRepository) GetAllRecords(
+	scope TenantScope,
 	studentID, teacherID, activityID uint,
 	status string,
 	limit, offset int,
 ) ([]models.Record, int, error) {
 	var records []models.Record
 	var count int64
-	query := r.db.Model(&models.Record{}).Preload("Student").Preload("Teacher")
+	query := r.db.Model(&models.Record{}).Scopes(scope.Records).Preload("Student").Preload("Teacher")
 
 	if studentID != 0 {
 		query = query.Where("student_id = ?", studentID)
@@ -84,15 +87,25 @@ func (r *RecordRepository) GetAllRecords(
 // UpdateRecord updates an existing record.
 // This method is designed to update the entire record object, including JSONB fields.
 // The service layer will handle appending to StatusLogs before calling this.
-func (r *RecordRepository) UpdateRecord(record *models.Record) error {
+func (r *RecordRepository) UpdateRecord(scope TenantScope, record *models.Record) error {
+	// Make sure the record is visible in the tenant scope before overwriting it
+	var existed models.Record
+	if err := r.db.Scopes(scope.Records).Select("id").First(&existed, "records.id = ?", record.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("record with ID %d not found", record.ID)
+		}
+		return fmt.Errorf("failed to retrieve record by ID: %w", err)
+	}
+
 	// Use Save to update all fields, including JSONB fields like Data and StatusLogs.
 	// GORM will handle the marshaling/unmarshaling due to Value/Scan methods.
-	return r.db.Save(record).Error
+	// The loaded activity and teacher are left untouched.
+	return r.db.Omit(clause.Associations).Save(record).Error
 }
 
-// DeleteRecord deletes a record by its ID.
-func (r *RecordRepository) DeleteRecord(id uint) error {
-	result := r.db.Delete(&models.Record{}, id)
+// DeleteRecord deletes a record by its ID within the tenant scope.
+func (r *RecordRepository) DeleteRecord(scope TenantScope, id uint) error {
+	result := r.db.Scopes(scope.Records).Delete(&models.Record{}, id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete record: %w", result.Error)
 	}
@@ -140,3 +153,26 @@ func (r *RecordRepository) GetRecordTotalAmount(activityID, userID uint) int {
 	r.db.Raw(query, activityID, userID).Scan(&totalCount)
 	return int(totalCount)
 }
+
+// GetPendingRecordCounts counts the records waiting for review by teacher and activity.
+func (r *RecordRepository) GetPendingRecordCounts() ([]models.PendingRecordCount, error) {
+	counts := make([]models.PendingRecordCount, 0)
+	query := `
+		SELECT
+			r.teacher_id,
+			r.activity_id,
+			ac.name AS activity_name,
+			COUNT(*) AS count
+		FROM records r
+		JOIN activities ac ON ac.id = r.activity_id AND ac.deleted_at IS NULL
+		WHERE r.status = 'SENDED' AND
+			  r.teacher_id IS NOT NULL AND
+			  r.deleted_at IS NULL
+		GROUP BY r.teacher_id, r.activity_id, ac.name
+		ORDER BY r.teacher_id ASC, ac.name ASC
+	`
+	if err := r.db.Raw(query).Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count pending records: %w", err)
+	}
+	return counts, nil
+}
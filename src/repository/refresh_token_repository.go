@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// RefreshTokenRepository handles database operations for issued refresh tokens.
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository.
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateRefreshToken stores a newly issued refresh token.
+func (r *RefreshTokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	if err := r.db.Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshTokenByJTI retrieves a refresh token by its JWT ID.
+func (r *RefreshTokenRepository) GetRefreshTokenByJTI(jti string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.First(&token, "jti = ?", jti).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("refresh token %s not found", jti)
+		}
+		return nil, fmt.Errorf("failed to retrieve refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed marks a token as exchanged. It returns false when the token was
+// already used or revoked, so two concurrent refreshes cannot both succeed.
+func (r *RefreshTokenRepository) MarkRefreshTokenUsed(id uint) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark refresh token as used: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every token rotated from the same login, together with its session.
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.RefreshToken{}).Where("family_id = ? AND revoked_at IS NULL", familyID).Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("failed to revoke refresh token family: %w", err)
+		}
+		if err := tx.Model(&models.Session{}).Where("family_id = ? AND revoked_at IS NULL", familyID).Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		return nil
+	})
+}
+
+// RevokeUserTokens revokes every refresh token and session of a user, logging them out of all devices.
+func (r *RefreshTokenRepository) RevokeUserTokens(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens of user: %w", err)
+		}
+		if err := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("failed to revoke sessions of user: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// ScheduledJobRepository keeps the state of the background jobs in the database, shared by every instance of the server.
+// It implements pkg.JobStore.
+type ScheduledJobRepository struct {
+	db *gorm.DB
+}
+
+// NewScheduledJobRepository creates a new instance of ScheduledJobRepository.
+func NewScheduledJobRepository() *ScheduledJobRepository {
+	return &ScheduledJobRepository{
+		db: GetDB(),
+	}
+}
+
+// EnsureJob creates the state of a job running first at firstRunAt, unless it exists already.
+func (r *ScheduledJobRepository) EnsureJob(ctx context.Context, name string, firstRunAt time.Time) error {
+	job := models.ScheduledJob{Name: name, NextRunAt: firstRunAt}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&job).Error; err != nil {
+		return fmt.Errorf("failed to create scheduled job: %w", err)
+	}
+	return nil
+}
+
+// ClaimJob locks a job that is due for the given instance until the lease ends.
+// The conditions are checked by the update itself, so only one instance gets a job when several try at once.
+// It returns false when the job isn't due or another instance holds it.
+func (r *ScheduledJobRepository) ClaimJob(ctx context.Context, name, owner string, lease time.Duration) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.ScheduledJob{}).
+		Where("name = ? AND next_run_at <= ? AND (locked_until IS NULL OR locked_until < ?)", name, now, now).
+		Updates(map[string]interface{}{
+			"locked_by":       owner,
+			"locked_until":    now.Add(lease),
+			"last_started_at": now,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim scheduled job: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FinishJob releases a job claimed by the given instance and sets when it runs next.
+// Nothing changes if the lease ran out and another instance claimed the job meanwhile.
+func (r *ScheduledJobRepository) FinishJob(ctx context.Context, name, owner string, nextRunAt time.Time, runErr error) error {
+	lastError := ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+	err := r.db.WithContext(ctx).Model(&models.ScheduledJob{}).
+		Where("name = ? AND locked_by = ?", name, owner).
+		Updates(map[string]interface{}{
+			"locked_by":        nil,
+			"locked_until":     nil,
+			"last_finished_at": time.Now(),
+			"last_error":       lastError,
+			"next_run_at":      nextRunAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to release scheduled job: %w", err)
+	}
+	return nil
+}
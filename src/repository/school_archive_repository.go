@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// SchoolArchiveRepository handles database operations for offboarding a school.
+type SchoolArchiveRepository struct {
+	db *gorm.DB
+}
+
+// NewSchoolArchiveRepository creates a new instance of SchoolArchiveRepository.
+func NewSchoolArchiveRepository() *SchoolArchiveRepository {
+	return &SchoolArchiveRepository{
+		db: GetDB(),
+	}
+}
+
+// GetSchoolExportData retrieves every user, activity and record belonging to a school.
+func (r *SchoolArchiveRepository) GetSchoolExportData(schoolID uint) ([]models.User, []models.Activity, []models.Record, error) {
+	var users []models.User
+	if err := r.db.Joins("ClassroomObject", DB.Select("classroom")).Where("users.school_id = ?", schoolID).Order("users.id ASC").Find(&users).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to retrieve users: %w", err)
+	}
+
+	var activities []models.Activity
+	if err := r.db.Preload("ExclusiveStudentObjects").Preload("ExclusiveClassroomObjects").Where("school_id = ?", schoolID).Order("id ASC").Find(&activities).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to retrieve activities: %w", err)
+	}
+
+	var records []models.Record
+	if err := r.db.Where("activity_id IN (?)", r.schoolActivityIDs(r.db, schoolID)).Order("id ASC").Find(&records).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to retrieve records: %w", err)
+	}
+
+	return users, activities, records, nil
+}
+
+// ArchiveSchool soft deletes a school and everything under it with the archive timestamp,
+// revokes its invitation codes at that same time, then stores the archive entry. Everything happens in one transaction.
+func (r *SchoolArchiveRepository) ArchiveSchool(archive *models.SchoolArchive) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+
+		// Records are linked to the school through their activity, so they must go first
+		if err := tx.Model(&models.Record{}).Where("activity_id IN (?)", r.schoolActivityIDs(tx, archive.SchoolID)).UpdateColumn("deleted_at", archive.ArchivedAt).Error; err != nil {
+			return fmt.Errorf("failed to archive records: %w", err)
+		}
+
+		if err := tx.Model(&models.Activity{}).Where("school_id = ?", archive.SchoolID).UpdateColumn("deleted_at", archive.ArchivedAt).Error; err != nil {
+			return fmt.Errorf("failed to archive activities: %w", err)
+		}
+
+		if err := tx.Model(&models.User{}).Where("school_id = ?", archive.SchoolID).UpdateColumn("deleted_at", archive.ArchivedAt).Error; err != nil {
+			return fmt.Errorf("failed to archive users: %w", err)
+		}
+
+		if err := tx.Model(&models.Classroom{}).Where("school_id = ?", archive.SchoolID).UpdateColumn("deleted_at", archive.ArchivedAt).Error; err != nil {
+			return fmt.Errorf("failed to archive classrooms: %w", err)
+		}
+
+		if err := tx.Model(&models.StudentGroup{}).Where("school_id = ?", archive.SchoolID).UpdateColumn("deleted_at", archive.ArchivedAt).Error; err != nil {
+			return fmt.Errorf("failed to archive student groups: %w", err)
+		}
+
+		// Guardians and students of a link belong to the same school
+		if err := tx.Model(&models.GuardianLink{}).Where("student_id IN (?)", r.schoolUserIDs(tx, archive.SchoolID)).UpdateColumn("deleted_at", archive.ArchivedAt).Error; err != nil {
+			return fmt.Errorf("failed to archive guardian links: %w", err)
+		}
+
+		// Nobody can join the school nor sign in to it with single sign-on anymore
+		if err := tx.Model(&models.InvitationCode{}).Where("school_id = ? AND revoked_at IS NULL", archive.SchoolID).UpdateColumn("revoked_at", archive.ArchivedAt).Error; err != nil {
+			return fmt.Errorf("failed to revoke invitation codes: %w", err)
+		}
+
+		if err := tx.Model(&models.IdentityProvider{}).Where("school_id = ?", archive.SchoolID).UpdateColumn("deleted_at", archive.ArchivedAt).Error; err != nil {
+			return fmt.Errorf("failed to archive identity providers: %w", err)
+		}
+
+		if err := tx.Model(&models.SchoolSettings{}).Where("school_id = ?", archive.SchoolID).UpdateColumn("deleted_at", archive.ArchivedAt).Error; err != nil {
+			return fmt.Errorf("failed to archive school settings: %w", err)
+		}
+
+		result := tx.Model(&models.School{}).Where("id = ?", archive.SchoolID).UpdateColumn("deleted_at", archive.ArchivedAt)
+		if result.Error != nil {
+			return fmt.Errorf("failed to archive school: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("school with ID %d not found for deletion", archive.SchoolID)
+		}
+
+		if err := tx.Create(archive).Error; err != nil {
+			return fmt.Errorf("failed to create school archive: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RestoreSchool brings back every row that was archived together with the school.
+// Rows deleted before the archival keep their own timestamp and stay deleted.
+func (r *SchoolArchiveRepository) RestoreSchool(archive *models.SchoolArchive, restoredAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Unscoped().Model(&models.School{}).Where("id = ? AND deleted_at = ?", archive.SchoolID, archive.ArchivedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore school: %w", err)
+		}
+
+		if err := tx.Unscoped().Model(&models.Classroom{}).Where("school_id = ? AND deleted_at = ?", archive.SchoolID, archive.ArchivedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore classrooms: %w", err)
+		}
+
+		if err := tx.Unscoped().Model(&models.SchoolSettings{}).Where("school_id = ? AND deleted_at = ?", archive.SchoolID, archive.ArchivedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore school settings: %w", err)
+		}
+
+		if err := tx.Unscoped().Model(&models.IdentityProvider{}).Where("school_id = ? AND deleted_at = ?", archive.SchoolID, archive.ArchivedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore identity providers: %w", err)
+		}
+
+		// Codes revoked before the archival keep their own timestamp and stay revoked
+		if err := tx.Model(&models.InvitationCode{}).Where("school_id = ? AND revoked_at = ?", archive.SchoolID, archive.ArchivedAt).UpdateColumn("revoked_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore invitation codes: %w", err)
+		}
+
+		if err := tx.Unscoped().Model(&models.StudentGroup{}).Where("school_id = ? AND deleted_at = ?", archive.SchoolID, archive.ArchivedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore student groups: %w", err)
+		}
+
+		if err := tx.Unscoped().Model(&models.User{}).Where("school_id = ? AND deleted_at = ?", archive.SchoolID, archive.ArchivedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore users: %w", err)
+		}
+
+		if err := tx.Unscoped().Model(&models.Activity{}).Where("school_id = ? AND deleted_at = ?", archive.SchoolID, archive.ArchivedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore activities: %w", err)
+		}
+
+		if err := tx.Unscoped().Model(&models.Record{}).Where("activity_id IN (?) AND deleted_at = ?", r.schoolActivityIDs(tx, archive.SchoolID), archive.ArchivedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore records: %w", err)
+		}
+
+		if err := tx.Unscoped().Model(&models.GuardianLink{}).Where("student_id IN (?) AND deleted_at = ?", r.schoolUserIDs(tx, archive.SchoolID), archive.ArchivedAt).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore guardian links: %w", err)
+		}
+
+		archive.RestoredAt = &restoredAt
+		if err := tx.Save(archive).Error; err != nil {
+			return fmt.Errorf("failed to update school archive: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetLatestArchiveBySchoolID retrieves the most recent archive entry of a school.
+func (r *SchoolArchiveRepository) GetLatestArchiveBySchoolID(schoolID uint) (*models.SchoolArchive, error) {
+	var archive models.SchoolArchive
+	err := r.db.Where("school_id = ?", schoolID).Order("archived_at DESC").First(&archive).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("archive of school with ID %d not found", schoolID)
+		}
+		return nil, fmt.Errorf("failed to retrieve school archive: %w", err)
+	}
+	return &archive, nil
+}
+
+// schoolUserIDs builds a subquery selecting every user id of a school within the given session, including deleted ones.
+func (r *SchoolArchiveRepository) schoolUserIDs(db *gorm.DB, schoolID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.User{}).Select("id").Where("school_id = ?", schoolID)
+}
+
+// schoolActivityIDs builds a subquery selecting every activity id of a school within the given session, including deleted ones.
+func (r *SchoolArchiveRepository) schoolActivityIDs(db *gorm.DB, schoolID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.Activity{}).Select("id").Where("school_id = ?", schoolID)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// SchoolSettingsRepository handles database operations for the SchoolSettings model.
+type SchoolSettingsRepository struct {
+	db *gorm.DB
+}
+
+// NewSchoolSettingsRepository creates a new instance of SchoolSettingsRepository.
+func NewSchoolSettingsRepository() *SchoolSettingsRepository {
+	return &SchoolSettingsRepository{
+		db: GetDB(),
+	}
+}
+
+// GetSettingsBySchoolID retrieves the settings of a school.
+// The default settings are returned when the school never saved any.
+func (r *SchoolSettingsRepository) GetSettingsBySchoolID(schoolID uint) (*models.SchoolSettings, error) {
+	var settings models.SchoolSettings
+	err := r.db.Where("school_id = ?", schoolID).First(&settings).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.DefaultSchoolSettings(schoolID), nil
+		}
+		return nil, fmt.Errorf("failed to retrieve school settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// SaveSettings creates or replaces the settings of a school.
+func (r *SchoolSettingsRepository) SaveSettings(settings *models.SchoolSettings) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "school_id"}},
+		UpdateAll: true,
+	}).Create(settings).Error
+	if err != nil {
+		return fmt.Errorf("failed to save school settings: %w", err)
+	}
+	return nil
+}
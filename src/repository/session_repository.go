@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// SessionRepository handles database operations for login sessions.
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new instance of SessionRepository.
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateSession stores a new login session.
+func (r *SessionRepository) CreateSession(session *models.Session) error {
+	if err := r.db.Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// TouchSession records that the session of a token family was used from the given IP address.
+func (r *SessionRepository) TouchSession(familyID, ipAddress string) error {
+	updates := map[string]interface{}{"last_used_at": time.Now()}
+	if ipAddress != "" {
+		updates["ip_address"] = ipAddress
+	}
+	if err := r.db.Model(&models.Session{}).Where("family_id = ?", familyID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// GetSessionByID retrieves a session of a user by ID.
+func (r *SessionRepository) GetSessionByID(userID, id uint) (*models.Session, error) {
+	var session models.Session
+	err := r.db.First(&session, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("session with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to retrieve session by ID: %w", err)
+	}
+	return &session, nil
+}
+
+// GetActiveSessionsByUserID retrieves sessions of a user that are not revoked and were used after since,
+// most recently used first.
+func (r *SessionRepository) GetActiveSessionsByUserID(userID uint, since time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND last_used_at > ?", userID, since).
+		Order("last_used_at DESC").Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve sessions: %w", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// StudentGroupRepository handles database operations for student groups.
+type StudentGroupRepository struct {
+	db *gorm.DB
+}
+
+// NewStudentGroupRepository creates a new instance of StudentGroupRepository.
+func NewStudentGroupRepository() *StudentGroupRepository {
+	return &StudentGroupRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateStudentGroup creates a new student group with its members.
+// Members must be active students of the group's school.
+func (r *StudentGroupRepository) CreateStudentGroup(group *models.StudentGroup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		members, err := findGroupMembers(tx, group)
+		if err != nil {
+			return err
+		}
+		group.Members = members
+
+		if err := tx.Omit("Members.*").Create(group).Error; err != nil {
+			return fmt.Errorf("failed to create student group: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetStudentGroupByID retrieves a student group by its ID, preloading its members.
+func (r *StudentGroupRepository) GetStudentGroupByID(id uint) (*models.StudentGroup, error) {
+	var group models.StudentGroup
+	if err := r.db.Preload("Members", DB.Select("id")).First(&group, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("student group with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to retrieve student group by ID: %w", err)
+	}
+	return &group, nil
+}
+
+// GetStudentGroupsBySchoolID retrieves the student groups of a school.
+// When visibleTo is set, only the groups owned by that user or shared with the school are returned.
+func (r *StudentGroupRepository) GetStudentGroupsBySchoolID(schoolID, visibleTo uint) ([]models.StudentGroup, error) {
+	var groups []models.StudentGroup
+	query := r.db.Preload("Members", DB.Select("id")).Where("school_id = ?", schoolID)
+	if visibleTo != 0 {
+		query = query.Where("(owner_id = ? OR is_shared = ?)", visibleTo, true)
+	}
+	if err := query.Order("name, id").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve student groups: %w", err)
+	}
+	return groups, nil
+}
+
+// UpdateStudentGroup saves an existing student group and replaces its members.
+// Members already in the group are kept even if they are no longer active, new ones must be active.
+func (r *StudentGroupRepository) UpdateStudentGroup(group *models.StudentGroup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		members, err := findGroupMembers(tx, group)
+		if err != nil {
+			return err
+		}
+		group.Members = members
+
+		if err := tx.Omit(clause.Associations).Save(group).Error; err != nil {
+			return fmt.Errorf("failed to update student group: %w", err)
+		}
+
+		// Update the members using Replace (delete all previous link, then create every new link)
+		if err := tx.Model(group).Association("Members").Replace(group.Members); err != nil {
+			return fmt.Errorf("failed to update student group members: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteStudentGroup deletes a student group by its ID.
+// Activities keep targeting the members of a deleted group, so past assignments don't change.
+func (r *StudentGroupRepository) DeleteStudentGroup(id uint) error {
+	result := r.db.Delete(&models.StudentGroup{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete student group: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("student group with ID %d not found", id)
+	}
+	return nil
+}
+
+// findGroupMembers resolves the member IDs of a group to active students of its school, or current members.
+func findGroupMembers(tx *gorm.DB, group *models.StudentGroup) ([]models.User, error) {
+	members := make([]models.User, len(group.MemberIDs))
+	for i, id := range group.MemberIDs {
+		err := tx.Select("id").First(&members[i],
+			"id = ? AND school_id = ? AND role = ? AND (status = ? OR id IN (SELECT user_id FROM student_group_members WHERE student_group_id = ?))",
+			id, group.SchoolID, "STD", "ACTIVE", group.ID).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to find active student %d: %w", id, err)
+		}
+	}
+	return members, nil
+}
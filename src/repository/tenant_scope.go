@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/utils"
+)
+
+// TenantScope restricts repository queries to the school of the caller.
+// A scope built from claims is always limited to the caller's SchoolID; only SAMA
+// can lift that restriction, and only by calling AllSchools explicitly.
+type TenantScope struct {
+	SchoolID   uint
+	Role       string
+	allSchools bool
+}
+
+// NewTenantScope creates a scope limited to the school in the caller's claims.
+func NewTenantScope(claims *utils.Claims) TenantScope {
+	return TenantScope{
+		SchoolID: claims.SchoolID,
+		Role:     claims.Role,
+	}
+}
+
+// SystemScope creates a scope without school filtering.
+// It is meant for internal flows (authentication, background jobs) that do not act on behalf of a tenant user.
+func SystemScope() TenantScope {
+	return TenantScope{
+		Role:       "SYSTEM",
+		allSchools: true,
+	}
+}
+
+// AllSchools lifts the school restriction for SAMA. For every other role the scope is returned unchanged.
+func (s TenantScope) AllSchools() TenantScope {
+	if s.Role == "SAMA" {
+		s.allSchools = true
+	}
+	return s
+}
+
+// ForSchool narrows a scope lifted with AllSchools down to a single school.
+// Scopes already limited to a school are returned unchanged.
+func (s TenantScope) ForSchool(schoolID uint) TenantScope {
+	if s.allSchools {
+		s.allSchools = false
+		s.SchoolID = schoolID
+	}
+	return s
+}
+
+// IsAllSchools reports whether the scope bypasses school filtering.
+func (s TenantScope) IsAllSchools() bool {
+	return s.allSchools
+}
+
+// CanAccessSchool reports whether the scope allows reading data of the given school.
+func (s TenantScope) CanAccessSchool(schoolID uint) bool {
+	return s.allSchools || s.SchoolID == schoolID
+}
+
+// Users is a GORM scope limiting a query on the users table.
+func (s TenantScope) Users(db *gorm.DB) *gorm.DB {
+	if s.allSchools {
+		return db
+	}
+	return db.Where("users.school_id = ?", s.SchoolID)
+}
+
+// Activities is a GORM scope limiting a query on the activities table.
+func (s TenantScope) Activities(db *gorm.DB) *gorm.DB {
+	if s.allSchools {
+		return db
+	}
+	return db.Where("activities.school_id = ?", s.SchoolID)
+}
+
+// Classrooms is a GORM scope limiting a query on the classrooms table.
+func (s TenantScope) Classrooms(db *gorm.DB) *gorm.DB {
+	if s.allSchools {
+		return db
+	}
+	return db.Where("classrooms.school_id = ?", s.SchoolID)
+}
+
+// Records is a GORM scope limiting a query on the records table.
+// Records don't carry a school, so they are filtered through their activity.
+func (s TenantScope) Records(db *gorm.DB) *gorm.DB {
+	if s.allSchools {
+		return db
+	}
+	// The subquery shares the connection, transaction and context of the query it filters
+	activityIDs := db.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.Activity{}).Select("id").Where("school_id = ?", s.SchoolID)
+	return db.Where("records.activity_id IN (?)", activityIDs)
+}
+
+// InvitationCodes is a GORM scope limiting a query on the invitation_codes table.
+func (s TenantScope) InvitationCodes(db *gorm.DB) *gorm.DB {
+	if s.allSchools {
+		return db
+	}
+	return db.Where("invitation_codes.school_id = ?", s.SchoolID)
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/utils"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	schoolA uint = 1
+	schoolB uint = 2
+)
+
+// sqlRecorder is a GORM logger keeping the SQL of every statement, with its variables inlined.
+type sqlRecorder struct {
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface      { return r }
+func (r *sqlRecorder) Info(context.Context, string, ...interface{})  {}
+func (r *sqlRecorder) Warn(context.Context, string, ...interface{})  {}
+func (r *sqlRecorder) Error(context.Context, string, ...interface{}) {}
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// contains reports whether one of the recorded statements contains the given SQL.
+func (r *sqlRecorder) contains(sql string) bool {
+	for _, statement := range r.statements {
+		if strings.Contains(statement, sql) {
+			return true
+		}
+	}
+	return false
+}
+
+// useDryRunDB replaces the database of the repositories for the duration of the test with one that
+// builds the SQL of the queries without running them, and returns the recorder of that SQL.
+func useDryRunDB(t *testing.T) *sqlRecorder {
+	t.Helper()
+	recorder := &sqlRecorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry run database: %v", err)
+	}
+
+	previous := DB
+	DB = db
+	t.Cleanup(func() { DB = previous })
+	return recorder
+}
+
+// scopedQueries run a repository method on rows of school B, with the filter the tenant scope must add for a given school.
+// No row is found without a database, so only the SQL they build matters and their results are ignored.
+var scopedQueries = []struct {
+	name   string
+	run    func(scope TenantScope)
+	filter string
+}{
+	{"GetUserByID", func(scope TenantScope) {
+		NewUserRepository().GetUserByID(scope, 20)
+	}, "users.school_id = %d"},
+	{"DeleteUser", func(scope TenantScope) {
+		NewUserRepository().DeleteUser(scope, 20)
+	}, "users.school_id = %d"},
+	{"GetUsersBySchoolID", func(scope TenantScope) {
+		NewUserRepository().GetUsersBySchoolID(scope, schoolB, 0, 0, "", "", "", "", -1, -1)
+	}, "users.school_id = %d"},
+	{"GetUsersBySchoolID by classroom", func(scope TenantScope) {
+		NewUserRepository().GetUsersBySchoolID(scope, schoolB, 0, 0, "", "", "1/1", "", -1, -1)
+	}, "classrooms.school_id = %d"},
+	{"GetActivityByID", func(scope TenantScope) {
+		NewActivityRepository().GetActivityByID(scope, 20)
+	}, "activities.school_id = %d"},
+	{"DeleteActivity", func(scope TenantScope) {
+		NewActivityRepository().DeleteActivity(scope, 20)
+	}, "activities.school_id = %d"},
+	{"GetRecordByID", func(scope TenantScope) {
+		NewRecordRepository().GetRecordByID(scope, 20)
+	}, `records.activity_id IN (SELECT "id" FROM "activities" WHERE school_id = %d)`},
+	{"UpdateRecord", func(scope TenantScope) {
+		NewRecordRepository().UpdateRecord(scope, &models.Record{ID: 20, Status: "APPROVED"})
+	}, `records.activity_id IN (SELECT "id" FROM "activities" WHERE school_id = %d)`},
+	{"DeleteRecord", func(scope TenantScope) {
+		NewRecordRepository().DeleteRecord(scope, 20)
+	}, `records.activity_id IN (SELECT "id" FROM "activities" WHERE school_id = %d)`},
+	{"GetInvitationCodeByID", func(scope TenantScope) {
+		NewInvitationRepository().GetInvitationCodeByID(scope, 20)
+	}, "invitation_codes.school_id = %d"},
+	{"GetInvitationCodesBySchoolID", func(scope TenantScope) {
+		NewInvitationRepository().GetInvitationCodesBySchoolID(scope, schoolB, -1, -1)
+	}, "invitation_codes.school_id = %d"},
+}
+
+// runScopedQueries runs every scoped query with the scope and checks whether its school filter was applied.
+func runScopedQueries(t *testing.T, scope TenantScope, wantFiltered bool) {
+	for _, query := range scopedQueries {
+		t.Run(query.name, func(t *testing.T) {
+			recorder := useDryRunDB(t)
+			query.run(scope)
+
+			filter := fmt.Sprintf(query.filter, scope.SchoolID)
+			if filtered := recorder.contains(filter); filtered != wantFiltered {
+				t.Errorf("%s filtered by %q = %v, want %v\nSQL: %s", query.name, filter, filtered, wantFiltered, strings.Join(recorder.statements, "\n"))
+			}
+		})
+	}
+}
+
+func TestTenantScopeLimitsQueriesToTheCallerSchool(t *testing.T) {
+	for _, role := range []string{"ADMIN", "TCH", "STD", "GRD"} {
+		t.Run(role, func(t *testing.T) {
+			runScopedQueries(t, NewTenantScope(&utils.Claims{UserID: 10, SchoolID: schoolA, Role: role}), true)
+		})
+	}
+}
+
+func TestTenantScopeAllSchoolsOnlyLiftsTheFilterForSama(t *testing.T) {
+	for _, role := range []string{"ADMIN", "TCH", "STD", "GRD"} {
+		t.Run(role, func(t *testing.T) {
+			runScopedQueries(t, NewTenantScope(&utils.Claims{UserID: 10, SchoolID: schoolA, Role: role}).AllSchools(), true)
+		})
+	}
+	t.Run("SAMA", func(t *testing.T) {
+		runScopedQueries(t, NewTenantScope(&utils.Claims{UserID: 1, SchoolID: schoolA, Role: "SAMA"}).AllSchools(), false)
+	})
+}
+
+func TestTenantScopeWithoutAllSchoolsKeepsSamaInTheirSchool(t *testing.T) {
+	runScopedQueries(t, NewTenantScope(&utils.Claims{UserID: 1, SchoolID: schoolA, Role: "SAMA"}), true)
+}
+
+func TestTenantScopeForSchool(t *testing.T) {
+	sama := NewTenantScope(&utils.Claims{UserID: 1, SchoolID: schoolA, Role: "SAMA"}).AllSchools().ForSchool(schoolB)
+	if sama.IsAllSchools() || sama.SchoolID != schoolB {
+		t.Errorf("SAMA scope narrowed to school %d = %+v, want limited to school %d", schoolB, sama, schoolB)
+	}
+
+	admin := NewTenantScope(&utils.Claims{UserID: 10, SchoolID: schoolA, Role: "ADMIN"}).ForSchool(schoolB)
+	if admin.IsAllSchools() || admin.SchoolID != schoolA {
+		t.Errorf("ADMIN scope narrowed to school %d = %+v, want limited to school %d", schoolB, admin, schoolA)
+	}
+}
+
+func TestTenantScopeCanAccessSchool(t *testing.T) {
+	admin := NewTenantScope(&utils.Claims{UserID: 10, SchoolID: schoolA, Role: "ADMIN"}).AllSchools()
+	if !admin.CanAccessSchool(schoolA) || admin.CanAccessSchool(schoolB) {
+		t.Errorf("ADMIN of school %d can access school %d = %v and school %d = %v, want true and false",
+			schoolA, schoolA, admin.CanAccessSchool(schoolA), schoolB, admin.CanAccessSchool(schoolB))
+	}
+
+	sama := NewTenantScope(&utils.Claims{UserID: 1, SchoolID: schoolA, Role: "SAMA"}).AllSchools()
+	if !sama.CanAccessSchool(schoolB) {
+		t.Errorf("SAMA with all schools can access school %d = false, want true", schoolB)
+	}
+}
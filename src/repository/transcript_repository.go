@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// TranscriptRepository handles database operations for the TranscriptIssue model.
+type TranscriptRepository struct {
+	db *gorm.DB
+}
+
+// NewTranscriptRepository creates a new instance of TranscriptRepository.
+func NewTranscriptRepository() *TranscriptRepository {
+	return &TranscriptRepository{
+		db: GetDB(),
+	}
+}
+
+// CreateTranscriptIssue stores a new signed transcript.
+func (r *TranscriptRepository) CreateTranscriptIssue(issue *models.TranscriptIssue) error {
+	if err := r.db.Create(issue).Error; err != nil {
+		return fmt.Errorf("failed to create transcript issue: %w", err)
+	}
+	return nil
+}
+
+// GetTranscriptIssueByCode retrieves a signed transcript by its verification code.
+func (r *TranscriptRepository) GetTranscriptIssueByCode(code string) (*models.TranscriptIssue, error) {
+	var issue models.TranscriptIssue
+	err := r.db.Where("code = ?", code).First(&issue).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("transcript with code %s not found", code)
+		}
+		return nil, fmt.Errorf("failed to retrieve transcript issue: %w", err)
+	}
+	return &issue, nil
+}
@@ -59,10 +59,55 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 	})
 }
 
-// GetUserByID retrieves a user by ID.
-func (r *UserRepository) GetUserByID(id uint) (*models.User, error) {
+// CreateUsers creates many users of one school in a single transaction.
+// Either every user is created or none of them.
+func (r *UserRepository) CreateUsers(schoolID uint, users []models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+
+		var classrooms []models.Classroom
+		if err := tx.Where("school_id = ?", schoolID).Find(&classrooms).Error; err != nil {
+			return fmt.Errorf("failed to retrieve classrooms of school: %w", err)
+		}
+		classroomIDs := make(map[string]uint, len(classrooms))
+		for _, classroom := range classrooms {
+			classroomIDs[classroom.Classroom] = classroom.ID
+		}
+
+		for i := range users {
+			users[i].SchoolID = schoolID
+			if users[i].Classroom != nil {
+				classroomID, ok := classroomIDs[*users[i].Classroom]
+				if !ok {
+					return fmt.Errorf("classroom %s not found", *users[i].Classroom)
+				}
+				users[i].ClassroomID = &classroomID
+			}
+
+			if err := tx.Omit("BookmarkUsers.*").Create(&users[i]).Error; err != nil {
+				return fmt.Errorf("failed to create user %s: %w", users[i].Email, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetExistingEmails returns which of the given emails are already used, including by deleted users.
+func (r *UserRepository) GetExistingEmails(emails []string) ([]string, error) {
+	var existing []string
+	if len(emails) == 0 {
+		return existing, nil
+	}
+	if err := r.db.Unscoped().Model(&models.User{}).Where("email IN ?", emails).Pluck("email", &existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to check existing emails: %w", err)
+	}
+	return existing, nil
+}
+
+// GetUserByID retrieves a user by ID within the tenant scope.
+func (r *UserRepository) GetUserByID(scope TenantScope, id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.Preload("School").Preload("School.ClassroomObjects").Joins("ClassroomObject", DB.Select("classroom")).Preload("BookmarkUsers").First(&user, id).Error
+	err := r.db.Scopes(scope.Users).Preload("School").Preload("School.ClassroomObjects").Joins("ClassroomObject", DB.Select("classroom")).Preload("BookmarkUsers").First(&user, "users.id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("user with ID %d not found", id)
@@ -88,11 +133,12 @@ func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 
 // GetUsersBySchoolID retrieves all users belonging to a specific school with pagination.
 // This supports the "only able to access data from their school" feature.
-func (r *UserRepository) GetUsersBySchoolID(schoolID, userID uint, name, role, classroom string, limit, offset int) ([]models.User, int, error) {
+// An empty status lists users of every status, and a group ID limits the listing to the members of that student group.
+func (r *UserRepository) GetUsersBySchoolID(scope TenantScope, schoolID, userID, groupID uint, name, role, classroom, status string, limit, offset int) ([]models.User, int, error) {
 	var users []models.User
 	var count int64
 	// Start building the query
-	query := r.db.Model(&models.User{}).Joins("ClassroomObject", DB.Select("classroom"))
+	query := r.db.Model(&models.User{}).Scopes(scope.Users).Joins("ClassroomObject", DB.Select("classroom"))
 
 	// Apply school_id filter
 	query = query.Where("users.school_id = ?", schoolID)
@@ -102,6 +148,14 @@ func (r *UserRepository) GetUsersBySchoolID(schoolID, userID uint, name, role, c
 		query = query.Where("users.role = ?", role)
 	}
 
+	if status != "" {
+		query = query.Where("users.status = ?", status)
+	}
+
+	if groupID != 0 {
+		query = query.Where("users.id IN (?)", r.db.Table("student_group_members").Select("user_id").Where("student_group_id = ?", groupID))
+	}
+
 	if name != "" {
 		likeNameSearch := "%" + name + "%"
 		query = query.Where("CONCAT(users.firstname, ' ', users.lastname) LIKE ?", likeNameSearch)
@@ -110,7 +164,7 @@ func (r *UserRepository) GetUsersBySchoolID(schoolID, userID uint, name, role, c
 	if classroom != "" {
 		var classroomObject models.Classroom
 
-		if err := r.db.Select("id").Where("school_id = ? AND classroom = ?", schoolID, classroom).First(&classroomObject).Error; err != nil {
+		if err := r.db.Scopes(scope.Classrooms).Select("id").Where("classrooms.school_id = ? AND classrooms.classroom = ?", schoolID, classroom).First(&classroomObject).Error; err != nil {
 			return nil, 0, fmt.Errorf("failed to retrieve classroom '%s': %w", classroom, err)
 		}
 		query = query.Where("users.classroom_id = ?", classroomObject.ID)
@@ -142,10 +196,16 @@ func (r *UserRepository) GetUsersBySchoolID(schoolID, userID uint, name, role, c
 	return users, int(count), err
 }
 
-// UpdateUser updates an existing user's general profile information.
-func (r *UserRepository) UpdateUser(user *models.User) error {
+// UpdateUser updates an existing user's general profile information within the tenant scope.
+func (r *UserRepository) UpdateUser(scope TenantScope, user *models.User) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 
+		// Make sure the user is visible in the tenant scope before overwriting it
+		var existedUser models.User
+		if err := tx.Scopes(scope.Users).Select("id").First(&existedUser, "users.id = ?", user.ID).Error; err != nil {
+			return fmt.Errorf("user with ID %d not found", user.ID)
+		}
+
 		// Check if new classroom is valid
 		if user.Classroom != nil {
 			classroom := models.Classroom{}
@@ -160,7 +220,7 @@ func (r *UserRepository) UpdateUser(user *models.User) error {
 		// Get student's id first
 		for i, id := range user.BookmarkUserIDs {
 			var temp models.User
-			if err := tx.Select("id").First(&temp).Error; err != nil {
+			if err := tx.Select("id").First(&temp, "id = ?", id).Error; err != nil {
 				return fmt.Errorf("failed to find user with id %d: %w", id, err)
 			}
 			user.BookmarkUsers[i].ID = id
@@ -197,10 +257,76 @@ func (r *UserRepository) DeleteUserProfilePicture(userID uint) error {
 	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("profile_picture_url", gorm.Expr("NULL")).Error
 }
 
-// DeleteUser deletes a user by ID.
+// DeleteUser deletes a user by ID within the tenant scope.
 // This supports deletion by self, ADMIN, or Sama Crew.
-func (r *UserRepository) DeleteUser(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+func (r *UserRepository) DeleteUser(scope TenantScope, id uint) error {
+	result := r.db.Scopes(scope.Users).Delete(&models.User{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user with ID %d not found", id)
+	}
+	return nil
+}
+
+// UpdateUserStatus sets the lifecycle status of a user and logs the change.
+func (r *UserRepository) UpdateUserStatus(entry *models.UserStatusLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", entry.UserID).Updates(map[string]interface{}{
+			"status":              entry.Status,
+			"status_effective_at": entry.EffectiveAt,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update user status: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("user with ID %d not found", entry.UserID)
+		}
+
+		if err := tx.Create(entry).Error; err != nil {
+			return fmt.Errorf("failed to create user status log: %w", err)
+		}
+		return nil
+	})
+}
+
+// TransferUser moves a user to another school as an ACTIVE member and logs the transfer.
+// The classroom and bookmarks belong to the previous school and are dropped, records stay linked to the user.
+func (r *UserRepository) TransferUser(entry *models.UserStatusLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", entry.UserID).Updates(map[string]interface{}{
+			"school_id":           entry.SchoolID,
+			"classroom_id":        gorm.Expr("NULL"),
+			"number":              gorm.Expr("NULL"),
+			"status":              "ACTIVE",
+			"status_effective_at": entry.EffectiveAt,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to transfer user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("user with ID %d not found", entry.UserID)
+		}
+
+		if err := tx.Exec("DELETE FROM user_bookmarks WHERE user_id = ? OR bookmark_user_id = ?", entry.UserID, entry.UserID).Error; err != nil {
+			return fmt.Errorf("failed to delete bookmarks of transferred user: %w", err)
+		}
+
+		if err := tx.Create(entry).Error; err != nil {
+			return fmt.Errorf("failed to create user status log: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetUserStatusLogs retrieves the lifecycle history of a user, oldest first.
+func (r *UserRepository) GetUserStatusLogs(userID uint) ([]models.UserStatusLog, error) {
+	var logs []models.UserStatusLog
+	if err := r.db.Where("user_id = ?", userID).Order("effective_at ASC, id ASC").Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve user status logs: %w", err)
+	}
+	return logs, nil
 }
 
 // CountUsers returns the total number of users.
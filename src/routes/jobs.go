@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sama/sama-backend-2025/src/config"
+	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+	"sama/sama-backend-2025/src/services"
+)
+
+// startJobs registers the background jobs and starts running them.
+// Their state is kept in the database, so each run happens on one instance only and survives restarts.
+func startJobs(cfg *config.Config, mailerClient *pkg.MailerService, activityService *services.ActivityService, notificationService *services.NotificationService) {
+	location, err := time.LoadLocation(cfg.Scheduler.Timezone)
+	if err != nil {
+		log.Fatalf("failed to load scheduler time zone: %v", err)
+	}
+	reminderService := services.NewReminderService(cfg, location, activityService, notificationService, mailerClient)
+
+	scheduler := pkg.NewScheduler(
+		repository.NewScheduledJobRepository(),
+		time.Duration(cfg.Scheduler.PollSeconds)*time.Second,
+		time.Duration(cfg.Scheduler.LeaseMinutes)*time.Minute,
+	)
+	scheduler.Register(pkg.Job{
+		Name:     "deadline-reminders",
+		Schedule: pkg.Daily(cfg.Scheduler.ReminderHour, location),
+		Run:      reminderService.SendDeadlineReminders,
+	})
+	scheduler.Register(pkg.Job{
+		Name:     "pending-record-digest",
+		Schedule: pkg.Weekly(time.Weekday(cfg.Scheduler.DigestWeekday), cfg.Scheduler.ReminderHour, location),
+		Run:      reminderService.SendPendingRecordDigests,
+	})
+	scheduler.Register(pkg.Job{
+		Name:     "notification-cleanup",
+		Schedule: pkg.Daily(3, location), // At night, when nobody reads them
+		Run:      notificationService.DeleteExpiredNotifications,
+	})
+
+	if err := scheduler.Start(context.Background()); err != nil {
+		log.Fatalf("failed to start scheduler: %v", err)
+	}
+}
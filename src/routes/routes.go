@@ -1,10 +1,14 @@
 package routes
 
 import (
+	"log"
+	"time"
+
 	"sama/sama-backend-2025/src/config"
 	"sama/sama-backend-2025/src/controllers"
 	"sama/sama-backend-2025/src/middlewares"
 	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
 	"sama/sama-backend-2025/src/services"
 	"sama/sama-backend-2025/src/utils"
 
@@ -24,28 +28,75 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 	// Load the default AWS configuration. This automatically handles credentials and region.
 
 	validate := utils.Validate
-	s3Client := pkg.NewS3Client(cfg, awsConfig)
-	mailerClient := pkg.NewMailerService(cfg, awsConfig)
+	blobStore, err := pkg.NewBlobStore(cfg, awsConfig)
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+	mailerClient, err := pkg.NewMailerService(cfg, awsConfig)
+	if err != nil {
+		log.Fatalf("failed to initialize mailer: %v", err)
+	}
+
+	// Counters of the rate limits are kept in the database when several instances must share them
+	var rateLimitStore pkg.RateLimitStore = pkg.NewMemoryRateLimitStore()
+	if cfg.RateLimit.Backend == "database" {
+		rateLimitStore = repository.NewRateLimitRepository()
+	}
+	authRateLimit := middlewares.RateLimitByIP(rateLimitStore, "auth", cfg.RateLimit.IPRequestLimit, time.Duration(cfg.RateLimit.WindowMinutes)*time.Minute)
+
+	passwordHasher := pkg.NewPasswordHasher(cfg)
+	passwordPolicy, err := pkg.NewPasswordPolicy(cfg)
+	if err != nil {
+		log.Fatalf("failed to load password policy: %v", err)
+	}
 
 	// Initialize services
 	authService := services.NewAuthService(
 		cfg,
 		mailerClient,
+		rateLimitStore,
+		passwordHasher,
+		passwordPolicy,
 		validate,
 	)
-	userService := services.NewUserService(validate)
-	schoolService := services.NewSchoolService(s3Client, validate)
-	activityService := services.NewActivityService(validate)
-	recordService := services.NewRecordService(validate)
-	imageService := services.NewImageService(s3Client)
+	userService := services.NewUserService(mailerClient, validate)
+	schoolService := services.NewSchoolService(cfg, blobStore, validate)
+	notificationService := services.NewNotificationService(cfg, mailerClient)
+	activityService := services.NewActivityService(notificationService, validate)
+	recordService := services.NewRecordService(notificationService, validate)
+	imageService := services.NewImageService(blobStore)
+	avatarService := services.NewAvatarService(cfg, blobStore)
+	transcriptService := services.NewTranscriptService(cfg, blobStore)
+	userImportService := services.NewUserImportService(mailerClient, passwordHasher, passwordPolicy, validate)
+	invitationService := services.NewInvitationService(mailerClient, validate)
+	emailTemplateService := services.NewEmailTemplateService(mailerClient)
+	guardianService := services.NewGuardianService()
+	studentGroupService := services.NewStudentGroupService(validate)
+	sessionService := services.NewSessionService(cfg)
+	ssoService := services.NewSSOService(cfg, authService, pkg.NewOIDCClient(), passwordHasher, passwordPolicy, validate)
+
+	// Instances with the scheduler disabled only serve requests
+	if cfg.Scheduler.Enabled {
+		startJobs(cfg, mailerClient, activityService, notificationService)
+	}
 
 	// Initialize handlers
 	authController := controllers.NewAuthController(authService, validate)
 	userController := controllers.NewUserController(userService, activityService, recordService, validate)
-	schoolController := controllers.NewSchoolController(schoolService, userService, validate)
+	schoolController := controllers.NewSchoolController(schoolService, userService, studentGroupService, validate)
 	activityController := controllers.NewActivityController(activityService, validate)
 	recordController := controllers.NewRecordController(recordService)
 	imageController := controllers.NewImageController(imageService)
+	avatarController := controllers.NewAvatarController(avatarService)
+	transcriptController := controllers.NewTranscriptController(transcriptService, userService)
+	userImportController := controllers.NewUserImportController(userImportService)
+	invitationController := controllers.NewInvitationController(invitationService)
+	emailTemplateController := controllers.NewEmailTemplateController(emailTemplateService)
+	notificationController := controllers.NewNotificationController(notificationService)
+	guardianController := controllers.NewGuardianController(guardianService, userService)
+	studentGroupController := controllers.NewStudentGroupController(studentGroupService)
+	sessionController := controllers.NewSessionController(sessionService, userService)
+	ssoController := controllers.NewSSOController(ssoService)
 
 	// Swagger documentation
 	// docs.SwaggerInfo.BasePath = "/api/v1"
@@ -59,54 +110,119 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 	// Public routes (no authentication required)
 	publicRoutes := router.Group("/api/v1")
 	{
-		publicRoutes.POST("/register", authController.RegisterUser)
-		publicRoutes.POST("/login", authController.Login)
-		publicRoutes.POST("/refresh-token", authController.RefreshToken)
-		publicRoutes.POST("/password-reset/request-otp", authController.RequestOtp)
-		publicRoutes.POST("/password-reset/validate-otp", authController.ValidateOtp)
-		publicRoutes.POST("/password-reset/change-password", authController.ResetPassword)
-		publicRoutes.POST("/school", schoolController.CreateSchool)
-		publicRoutes.GET("/school", schoolController.GetAllSchools)
+		publicRoutes.POST("/register", authRateLimit, authController.RegisterUser)
+		publicRoutes.POST("/login", authRateLimit, authController.Login)
+		publicRoutes.POST("/refresh-token", authRateLimit, authController.RefreshToken)
+		publicRoutes.POST("/password-reset/request-otp", authRateLimit, authController.RequestOtp)
+		publicRoutes.POST("/password-reset/validate-otp", authRateLimit, authController.ValidateOtp)
+		publicRoutes.POST("/password-reset/change-password", authRateLimit, authController.ResetPassword)
+		publicRoutes.GET("/transcript/verify/:code", transcriptController.VerifyTranscript)
+		publicRoutes.GET("/sso/provider", authRateLimit, ssoController.FindSSOProviders)
+		publicRoutes.POST("/sso/:id/authorize", authRateLimit, ssoController.StartSSOLogin)
+		publicRoutes.POST("/sso/callback", authRateLimit, ssoController.FinishSSOLogin)
+	}
+
+	// Files kept on disk are served by the API itself, the signature of the URLs replaces authentication
+	if localStore, ok := blobStore.(*pkg.LocalBlobStore); ok {
+		storageController := controllers.NewStorageController(localStore)
+		publicRoutes.GET("/storage/object/*key", storageController.DownloadObject)
+		publicRoutes.POST("/storage/upload", storageController.UploadObject)
 	}
 
 	// Authenticated routes (protected by JWT middlewares)
 	authRoutes := router.Group("/api/v1")
-	authRoutes.Use(middlewares.Authmiddlewares(cfg.JWT.Secret))
+	authRoutes.Use(middlewares.Authmiddlewares(cfg.JWT.Secret), middlewares.AuditImpersonation())
 	{
+		// Tokens of Sama Crew acting as a user can't end the sessions of the user nor delete them
+		authRoutes.POST("/logout", middlewares.RejectImpersonation(), authController.Logout)
+		authRoutes.POST("/logout-all", middlewares.RejectImpersonation(), authController.LogoutAll)
+
 		authRoutes.GET("/user/me", userController.GetMyProfile)
+		authRoutes.POST("/user/me/email-verification", middlewares.RejectImpersonation(), authController.ResendEmailVerification)
+		authRoutes.POST("/user/me/email-verification/confirm", middlewares.RejectImpersonation(), authController.VerifyEmail)
+		authRoutes.POST("/user/me/avatar/upload-url", avatarController.RequestAvatarUpload)
+		authRoutes.PUT("/user/me/avatar", avatarController.ConfirmAvatar)
+		authRoutes.DELETE("/user/me/avatar", avatarController.DeleteAvatar)
+		authRoutes.GET("/user/me/notification-preference", notificationController.GetMyNotificationPreferences)
+		authRoutes.PUT("/user/me/notification-preference", notificationController.UpdateMyNotificationPreferences)
+		authRoutes.GET("/user/me/sessions", sessionController.GetMySessions)
+		authRoutes.DELETE("/user/me/sessions/:id", middlewares.RejectImpersonation(), sessionController.RevokeMySession)
 		authRoutes.GET("/user/:id", userController.GetUserByID)
 		authRoutes.PUT("/user/:id", userController.UpdateUserProfile)
-		authRoutes.DELETE("/user/:id", userController.DeleteUser)
+		authRoutes.DELETE("/user/:id", middlewares.RejectImpersonation(), userController.DeleteUser)
+		authRoutes.PATCH("/user/:id/status", userController.UpdateUserStatus)
+		authRoutes.GET("/user/:id/status-history", userController.GetUserStatusLogs)
+		authRoutes.POST("/user/:id/transfer", userController.TransferUser)
 		authRoutes.GET("/user/:id/activity", userController.GetAssignedActivities)
 		authRoutes.GET("/user/:id/statistic", userController.GetUserStatisticByID)
-
+		authRoutes.GET("/user/:id/sessions", sessionController.GetUserSessions)
+		authRoutes.GET("/user/:id/transcript", transcriptController.GetTranscript)
+		authRoutes.POST("/user/:id/transcript-file", transcriptController.IssueTranscriptFile)
+		authRoutes.POST("/user/:id/impersonate", middlewares.RejectImpersonation(), authController.Impersonate)
+		authRoutes.GET("/user/:id/linked-student", guardianController.GetLinkedStudents)
+		authRoutes.POST("/user/:id/linked-student", guardianController.LinkStudent)
+		authRoutes.DELETE("/user/:id/linked-student/:student_id", guardianController.UnlinkStudent)
+
+		authRoutes.POST("/school", middlewares.RequirePermission("school:create"), schoolController.CreateSchool)
+		authRoutes.GET("/school", middlewares.RequirePermission("school:list"), schoolController.GetAllSchools)
 		authRoutes.GET("/school/:id", schoolController.GetSchoolByID)
 		authRoutes.PUT("/school/:id", schoolController.UpdateSchool)
 		authRoutes.DELETE("/school/:id", schoolController.DeleteSchool)
+		authRoutes.GET("/school/:id/archive", schoolController.GetSchoolArchive)
+		authRoutes.POST("/school/:id/restore", schoolController.RestoreSchool)
+		authRoutes.GET("/school/:id/settings", schoolController.GetSchoolSettings)
+		authRoutes.PUT("/school/:id/settings", schoolController.UpdateSchoolSettings)
 		authRoutes.POST("/school/advance-semester", schoolController.AdvanceSemester)
 		authRoutes.POST("/school/revert-semester", schoolController.RevertSemester)
 		authRoutes.GET("/school/:id/user", schoolController.GetUsersBySchoolID)
+		authRoutes.POST("/school/:id/user/import", userImportController.ImportUsers)
+		authRoutes.GET("/school/:id/invitation", invitationController.GetInvitationCodesBySchoolID)
+		authRoutes.POST("/school/:id/invitation", invitationController.CreateInvitationCode)
+		authRoutes.GET("/school/:id/identity-provider", ssoController.GetIdentityProviders)
+		authRoutes.POST("/school/:id/identity-provider", ssoController.CreateIdentityProvider)
+		authRoutes.GET("/school/:id/student-group", studentGroupController.GetStudentGroups)
+		authRoutes.POST("/school/:id/student-group", studentGroupController.CreateStudentGroup)
 		authRoutes.GET("/school/:id/statistic", schoolController.GetSchoolStatisticByID)
 		authRoutes.POST("/school/:id/statistic-file", schoolController.GetSchoolStatisticFileByID)
 
+		authRoutes.PUT("/identity-provider/:id", ssoController.UpdateIdentityProvider)
+		authRoutes.DELETE("/identity-provider/:id", ssoController.DeleteIdentityProvider)
+
+		authRoutes.GET("/student-group/:id", studentGroupController.GetStudentGroupByID)
+		authRoutes.PUT("/student-group/:id", studentGroupController.UpdateStudentGroup)
+		authRoutes.DELETE("/student-group/:id", studentGroupController.DeleteStudentGroup)
+
+		authRoutes.GET("/invitation/:id", invitationController.GetInvitationCodeByID)
+		authRoutes.PATCH("/invitation/:id/revoke", invitationController.RevokeInvitationCode)
+		authRoutes.POST("/invitation/:id/email", invitationController.EmailInvitationCode)
+
+		authRoutes.GET("/email-template", emailTemplateController.GetEmailTemplates)
+		authRoutes.GET("/email-template/:name/preview", emailTemplateController.PreviewEmailTemplate)
+
+		authRoutes.GET("/notification", notificationController.GetMyNotifications)
+		authRoutes.GET("/notification/unread-count", notificationController.GetUnreadNotificationCount)
+		authRoutes.PATCH("/notification/:id/read", notificationController.MarkNotificationRead)
+		authRoutes.POST("/notification/read-all", notificationController.MarkAllNotificationsRead)
+
 		authRoutes.POST("/activity", activityController.CreateActivity)
-		authRoutes.GET("/activity", activityController.GetAllActivities)
+		authRoutes.GET("/activity", middlewares.RequirePermission("activity:list"), activityController.GetAllActivities)
 		authRoutes.GET("/activity/:id", activityController.GetActivityByID)
 		authRoutes.PUT("/activity/:id", activityController.UpdateActivity)
 		authRoutes.DELETE("/activity/:id", activityController.DeleteActivity)
 
-		authRoutes.GET("/record", recordController.GetAllRecords)
+		authRoutes.GET("/record", middlewares.RequirePermission("record:list"), recordController.GetAllRecords)
 		authRoutes.GET("/record/:id", recordController.GetRecordByID)
-		authRoutes.POST("/record", recordController.CreateRecord)
+		authRoutes.POST("/record", middlewares.RequirePermission("record:create"), recordController.CreateRecord)
 		authRoutes.PUT("/record/:id", recordController.UpdateRecord)
 		authRoutes.DELETE("/record/:id", recordController.DeleteRecord)
 		authRoutes.PATCH("/record/:id/send", recordController.SendRecord)
 		authRoutes.PATCH("/record/:id/unsend", recordController.UnsendRecord)
 		authRoutes.PATCH("/record/:id/approve", recordController.ApproveRecord)
 		authRoutes.PATCH("/record/:id/reject", recordController.RejectRecord)
+		authRoutes.PATCH("/record/:id/acknowledge", recordController.AcknowledgeRecord)
 
 		authRoutes.POST("/images/download-url", imageController.RequestDownloadPresignedURL)
-		authRoutes.POST("/images/upload-url", imageController.RequestUploadPresignedURL)
+		authRoutes.POST("/images/upload-url", middlewares.RequirePermission("image:upload"), imageController.RequestUploadPresignedURL)
 	}
 
 	return router
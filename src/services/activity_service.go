@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log"
 
 	"github.com/go-playground/validator/v10"
 	"gorm.io/gorm"
@@ -17,15 +18,17 @@ type ActivityService struct {
 	activityRepo *repository.ActivityRepository
 	schoolRepo   *repository.SchoolRepository
 	userRepo     *repository.UserRepository // Need user repo to validate CustomStudentIDs
+	notifier     *NotificationService
 	validator    *validator.Validate
 }
 
 // NewActivityService creates a new instance of ActivityService.
-func NewActivityService(validate *validator.Validate) *ActivityService {
+func NewActivityService(notifier *NotificationService, validate *validator.Validate) *ActivityService {
 	return &ActivityService{
 		activityRepo: repository.NewActivityRepository(),
 		schoolRepo:   repository.NewSchoolRepository(),
 		userRepo:     repository.NewUserRepository(), // Re-using UserRepository for user validation
+		notifier:     notifier,
 		validator:    validate,
 	}
 }
@@ -44,7 +47,7 @@ func (s *ActivityService) validateActivityData(activity *models.Activity) error
 	}
 
 	// Validate OwnerID exists
-	owner, err := s.userRepo.GetUserByID(activity.OwnerID)
+	owner, err := s.userRepo.GetUserByID(repository.SystemScope(), activity.OwnerID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("owner_id not found")
@@ -84,16 +87,29 @@ func (s *ActivityService) CreateActivity(activity *models.Activity) error {
 
 	activity.IsActive = true
 
-	return s.activityRepo.CreateActivity(activity)
+	if err := s.activityRepo.CreateActivity(activity); err != nil {
+		return err
+	}
+
+	// The activity is created already, a failed notification doesn't undo it
+	if err := s.notifier.NotifyActivityPublished(activity); err != nil {
+		log.Printf("failed to notify students of activity %d: %v", activity.ID, err)
+	}
+	return nil
 }
 
-// GetActivityByID retrieves an activity by its ID.
-func (s *ActivityService) GetActivityByID(id uint) (*models.ActivityWithStatistic, error) {
-	return s.activityRepo.GetActivityByID(id)
+// GetActivityByID retrieves an activity by its ID within the tenant scope.
+func (s *ActivityService) GetActivityByID(scope repository.TenantScope, id uint) (*models.ActivityWithStatistic, error) {
+	return s.activityRepo.GetActivityByID(scope, id)
 }
 
 // GetAllActivities retrieves activities with filtering and pagination.
-func (s *ActivityService) GetAllActivities(ownerID, schoolID, semester, schoolYear uint, limit, offset int) ([]models.Activity, int, error) {
+func (s *ActivityService) GetAllActivities(scope repository.TenantScope, ownerID, schoolID, semester, schoolYear uint, limit, offset int) ([]models.Activity, int, error) {
+	// Without the SAMA bypass, the listing always belongs to the caller's school
+	if !scope.IsAllSchools() {
+		schoolID = scope.SchoolID
+	}
+
 	// if either semester of school year is invalid, get current semester and year
 	if semester == 0 || schoolYear == 0 {
 		var err error
@@ -103,13 +119,13 @@ func (s *ActivityService) GetAllActivities(ownerID, schoolID, semester, schoolYe
 		}
 	}
 
-	return s.activityRepo.GetAllActivities(ownerID, schoolID, semester, schoolYear, limit, offset)
+	return s.activityRepo.GetAllActivities(scope, ownerID, schoolID, semester, schoolYear, limit, offset)
 }
 
 // UpdateActivity updates an existing activity.
-func (s *ActivityService) UpdateActivity(activity *models.Activity) error {
+func (s *ActivityService) UpdateActivity(scope repository.TenantScope, activity *models.Activity) error {
 	// Fetch existing activity to ensure it exists and preserve original fields not being updated.
-	_, err := s.activityRepo.GetActivityByID(activity.ID)
+	_, err := s.activityRepo.GetActivityByID(scope, activity.ID)
 	if err != nil {
 		return fmt.Errorf("activity not found for update: %w", err)
 	}
@@ -124,10 +140,17 @@ func (s *ActivityService) UpdateActivity(activity *models.Activity) error {
 	// 	return fmt.Errorf("updated activity data validation failed: %w", err)
 	// }
 
-	return s.activityRepo.UpdateActivity(activity)
+	return s.activityRepo.UpdateActivity(scope, activity)
 }
 
-func (r *ActivityService) GetAssignedActivitiesByUserID(userID, schoolID, semester, schoolYear uint) ([]models.ActivityWithStatistic, error) {
+func (r *ActivityService) GetAssignedActivitiesByUserID(scope repository.TenantScope, userID, semester, schoolYear uint) ([]models.ActivityWithStatistic, error) {
+
+	// The user must be visible in the tenant scope, activities are taken from the user's school
+	user, err := r.userRepo.GetUserByID(scope, userID)
+	if err != nil {
+		return nil, err
+	}
+	schoolID := user.SchoolID
 
 	// if either semester of school year is invalid, get current semester and year
 	if semester == 0 || schoolYear == 0 {
@@ -146,7 +169,7 @@ func (r *ActivityService) GetAssignedActivitiesByUserID(userID, schoolID, semest
 	return activities, nil
 }
 
-// DeleteActivity deletes an activity by its ID.
-func (s *ActivityService) DeleteActivity(id uint) error {
-	return s.activityRepo.DeleteActivity(id)
+// DeleteActivity deletes an activity by its ID within the tenant scope.
+func (s *ActivityService) DeleteActivity(scope repository.TenantScope, id uint) error {
+	return s.activityRepo.DeleteActivity(scope, id)
 }
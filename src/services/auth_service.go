@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"regexp"
+	"log"
+	"strings"
+	"time"
+
 	"sama/sama-backend-2025/src/config"
 	"sama/sama-backend-2025/src/models"
 	"sama/sama-backend-2025/src/pkg"
@@ -12,18 +15,39 @@ import (
 	"sama/sama-backend-2025/src/utils"
 
 	"github.com/go-playground/validator/v10"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// resetTokenLifetime is how long a password reset token can be used after the OTP was validated.
+const resetTokenLifetime = 10 * time.Minute
+
+// resetTokenBytes is the amount of random bytes in a password reset token.
+const resetTokenBytes = 32
+
+// loginFailureWindow is how long failed logins are remembered for the progressive lockout.
+const loginFailureWindow = 24 * time.Hour
+
 // userService handles business logic for user accounts.
 type AuthService struct {
 	userRepo          *repository.UserRepository
 	otpRepo           *repository.OTPRepository
+	invitationRepo    *repository.InvitationRepository
+	refreshTokenRepo  *repository.RefreshTokenRepository
+	sessionRepo       *repository.SessionRepository
+	impersonationRepo *repository.ImpersonationAuditRepository
+	verificationRepo  *repository.EmailVerificationRepository
+	settingsRepo      *repository.SchoolSettingsRepository
 	mailerClient      *pkg.MailerService
+	rateLimitStore    pkg.RateLimitStore
+	passwordHasher    pkg.PasswordHasher
+	passwordPolicy    *pkg.PasswordPolicy
+	dummyPasswordHash string // Verified when the email of a login doesn't exist, so the response time doesn't tell whether an account exists
+	rateLimit         config.RateLimitConfig
 	validator         *validator.Validate
 	jwtSecret         string // JWT secret for token generation
 	jwtExpMins        int    // JWT expiration in minutes
+	impersonationMins int    // Impersonation JWT expiration in minutes
 	refreshJwtSecret  string // JWT secret for token generation
 	refreshJwtExpMins int    // JWT expiration in minutes
 }
@@ -32,14 +56,31 @@ type AuthService struct {
 func NewAuthService(
 	cfg *config.Config,
 	mailerClient *pkg.MailerService,
+	rateLimitStore pkg.RateLimitStore,
+	passwordHasher pkg.PasswordHasher,
+	passwordPolicy *pkg.PasswordPolicy,
 	validate *validator.Validate,
 ) *AuthService {
+	dummyPasswordHash, _ := passwordHasher.Hash("dummy_password")
+
 	return &AuthService{
 		userRepo:          repository.NewUserRepository(),
 		otpRepo:           repository.NewOTPRepository(),
+		invitationRepo:    repository.NewInvitationRepository(),
+		refreshTokenRepo:  repository.NewRefreshTokenRepository(),
+		sessionRepo:       repository.NewSessionRepository(),
+		impersonationRepo: repository.NewImpersonationAuditRepository(),
+		verificationRepo:  repository.NewEmailVerificationRepository(),
+		settingsRepo:      repository.NewSchoolSettingsRepository(),
 		mailerClient:      mailerClient,
+		rateLimitStore:    rateLimitStore,
+		passwordHasher:    passwordHasher,
+		passwordPolicy:    passwordPolicy,
+		dummyPasswordHash: dummyPasswordHash,
+		rateLimit:         cfg.RateLimit,
 		jwtSecret:         cfg.JWT.Secret,
 		jwtExpMins:        cfg.JWT.Expiry,
+		impersonationMins: cfg.JWT.ImpersonationExpiry,
 		refreshJwtSecret:  cfg.RefreshJWT.Secret,
 		refreshJwtExpMins: cfg.RefreshJWT.Expiry,
 		validator:         validate,
@@ -47,15 +88,40 @@ func NewAuthService(
 }
 
 // RegisterUser creates a new user with hashed password.
-// This method is for new user registration.
-func (s *AuthService) RegisterUser(user *models.User) error {
+// The role, school and classroom are taken from the invitation code, whose usage is counted on success.
+func (s *AuthService) RegisterUser(user *models.User, code string) error {
+	invitation, err := s.invitationRepo.GetInvitationCodeByCode(code)
+	if err != nil || !invitation.IsUsable(time.Now()) {
+		return errors.New("invitation code is invalid or expired")
+	}
+
+	user.Role = invitation.Role
+	user.SchoolID = invitation.SchoolID
+	if invitation.Classroom != nil {
+		user.Classroom = invitation.Classroom
+	}
+
+	settings, err := s.settingsRepo.GetSettingsBySchoolID(user.SchoolID)
+	if err != nil {
+		return err
+	}
+
+	// The school may stop accepting a role after codes for it were generated
+	if !settings.CanRegister(user.Role) {
+		return fmt.Errorf("registration as %s is not allowed in this school", user.Role)
+	}
+
+	if user.Language == "" {
+		user.Language = settings.DefaultLanguage
+	}
+
 	// Validate input user data using the service's validator instance
 	if err := s.validator.StructExcept(user, "School"); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
 	// Check if user with this email already exists
-	_, err := s.userRepo.GetUserByEmail(user.Email)
+	_, err = s.userRepo.GetUserByEmail(user.Email)
 	if err == nil { // User found, so email already exists
 		return errors.New("user with this email already exists")
 	}
@@ -63,12 +129,16 @@ func (s *AuthService) RegisterUser(user *models.User) error {
 	// 	return fmt.Errorf("failed to check existing user: %w", err)
 	// }
 
+	if err := s.passwordPolicy.Check(user.Password); err != nil {
+		return err
+	}
+
 	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordHasher.Hash(user.Password)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
-	user.Password = string(hashedPassword) // Store hashed password
+	user.Password = hashedPassword // Store hashed password
 
 	// Set default values if not provided (e.g., IsActive)
 	// Note: ProfilePictureURL is a pointer, so check for nil
@@ -76,71 +146,152 @@ func (s *AuthService) RegisterUser(user *models.User) error {
 		defaultPictureURL := "" // Or a default placeholder URL
 		user.ProfilePictureURL = &defaultPictureURL
 	}
-	// Create the user
-	return s.userRepo.CreateUser(user)
+	// Create the user and use the invitation code together
+	if err := s.invitationRepo.RedeemInvitationCode(invitation.ID, user); err != nil {
+		return err
+	}
+
+	// The account exists at this point, a lost email can be sent again by the user
+	if err := sendEmailVerification(s.verificationRepo, s.mailerClient, user, user.Email); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", user.ID, err)
+	}
+	return nil
 }
 
 // Login authenticates a user and returns a JWT token if successful.
-// It receives email and plain-text password directly.
-func (s *AuthService) Login(email, password string) (string, string, error) {
+// It receives email and plain-text password directly, and opens a session with the device information.
+func (s *AuthService) Login(email, password string, session *models.Session) (string, string, error) {
 	// Basic validation for email and password format (if not done in handler)
 	// For example, if you had a LoginRequest struct passed here:
 	// if err := s.validator.Struct(loginReq); err != nil { return "", fmt.Errorf("validation failed: %w", err) }
 
+	ctx := context.TODO()
+	// Emails that don't exist are limited the same way, so a lockout doesn't tell whether an account exists
+	limitKey := "login:" + strings.ToLower(email)
+	lockedUntil, err := s.rateLimitStore.LockedUntil(ctx, limitKey)
+	if err != nil {
+		return "", "", err
+	}
+	if !lockedUntil.IsZero() {
+		return "", "", errors.New("too many attempts, please try again later")
+	}
+
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", "", errors.New("invalid credentials")
+		if err.Error() == fmt.Sprintf("user with email %s not found", email) {
+			s.passwordHasher.Verify(s.dummyPasswordHash, password)
+			return "", "", s.recordLoginFailure(ctx, limitKey)
 		}
 		return "", "", fmt.Errorf("failed to retrieve user for login: %w", err)
 	}
 
 	// Compare password (hashed password from DB vs. plain text password from input)
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	matched, err := s.passwordHasher.Verify(user.Password, password)
 	if err != nil {
-		return "", "", errors.New("invalid credentials") // Passwords do not match
+		return "", "", err
+	}
+	if !matched {
+		return "", "", s.recordLoginFailure(ctx, limitKey) // Passwords do not match
+	}
+
+	// Only told once the password matched, so the status of an account isn't disclosed to anyone
+	if user.IsSuspended() {
+		return "", "", errors.New("user account is deactivated")
 	}
 
-	newToken, newRefreshToken, err := s.generateNewToken(user)
+	// The plain password is only known here, so hashes of an older algorithm or cost are upgraded on login
+	if s.passwordHasher.NeedsRehash(user.Password) {
+		if hashedPassword, err := s.passwordHasher.Hash(password); err == nil {
+			if err := s.userRepo.UpdateUserPassword(user.ID, hashedPassword); err != nil {
+				log.Printf("failed to rehash password of user %d: %v", user.ID, err)
+			}
+		}
+	}
+
+	if err := s.rateLimitStore.Reset(ctx, limitKey); err != nil {
+		return "", "", err
+	}
+
+	return s.OpenSession(user, session)
+}
+
+// OpenSession issues an access and refresh token pair to an authenticated user, and records the session of the device.
+func (s *AuthService) OpenSession(user *models.User, session *models.Session) (string, string, error) {
+	// Every login starts a new token family
+	familyID := uuid.NewString()
+	newToken, newRefreshToken, err := s.generateNewToken(user, familyID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate both token: %w", err)
 	}
 
+	session.FamilyID = familyID
+	session.UserID = user.ID
+	session.LastUsedAt = time.Now()
+	if session.DeviceName == "" {
+		session.DeviceName = utils.DeviceNameFromUserAgent(session.UserAgent)
+	}
+	if err := s.sessionRepo.CreateSession(session); err != nil {
+		return "", "", err
+	}
+
 	return newToken, newRefreshToken, nil
 }
 
 // UpdatePassword updates a user's password.
 // This method should be used specifically for password changes.
 func (s *AuthService) UpdatePassword(userID uint, newPassword string) error {
-	// Password must contain only alphabet, number, or "_" only
-	// Regex: ^[a-zA-Z0-9_]+$
-	if !regexp.MustCompile(`^[a-zA-Z0-9_]+$`).MatchString(newPassword) {
-		return errors.New("password must contain only alphabets, numbers, or underscores")
+	if err := s.passwordPolicy.Check(newPassword); err != nil {
+		return err
 	}
-	// if len(newPassword) < 8 { // Example simple validation: min length
-	// 	return errors.New("password must be at least 8 characters long")
-	// }
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordHasher.Hash(newPassword)
 	if err != nil {
 		return fmt.Errorf("failed to hash new password: %w", err)
 	}
-	return s.userRepo.UpdateUserPassword(userID, string(hashedPassword))
+	if err := s.userRepo.UpdateUserPassword(userID, hashedPassword); err != nil {
+		return err
+	}
+
+	// Sessions opened with the old password must not survive the change
+	return s.refreshTokenRepo.RevokeUserTokens(userID)
 }
 
+// RequestOtp emails an OTP to reset the password of an account.
+// Nothing is sent for an unknown email, but no error is returned either, so the response doesn't tell whether it exists.
 func (s *AuthService) RequestOtp(email string) error {
 
+	window := time.Duration(s.rateLimit.WindowMinutes) * time.Minute
+	count, err := s.rateLimitStore.Increment(context.TODO(), "otp-request:"+strings.ToLower(email), window)
+	if err != nil {
+		return err
+	}
+	if count > s.rateLimit.OTPRequestLimit {
+		return errors.New("too many attempts, please try again later")
+	}
+
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
+		if err.Error() == fmt.Sprintf("user with email %s not found", email) {
+			return nil
+		}
 		return fmt.Errorf("failed to retrieve user: %w", err)
 	}
 
-	otp, err := s.otpRepo.CreateOTP(user.ID)
+	settings, err := s.settingsRepo.GetSettingsBySchoolID(user.SchoolID)
 	if err != nil {
 		return err
 	}
 
-	err = s.mailerClient.SendOTPEmail(context.TODO(), user.Firstname+" "+user.Lastname, user.Email, otp.Code)
+	code, err := s.otpRepo.CreateOTP(user.ID, time.Duration(settings.OTPLifetimeMinutes)*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailerClient.Send(context.TODO(), "otp", user.Language, map[string]any{
+		"Name":            user.Firstname + " " + user.Lastname,
+		"Code":            code,
+		"LifetimeMinutes": settings.OTPLifetimeMinutes,
+	}, user.Email)
 	if err != nil {
 		s.otpRepo.DeleteOTP(user.ID)
 		return fmt.Errorf("failed to send email: %w", err)
@@ -149,67 +300,232 @@ func (s *AuthService) RequestOtp(email string) error {
 	return nil
 }
 
-func (s *AuthService) VerifyOTP(email string, code string) (bool, error) {
+// VerifyOTP exchanges the OTP of an account for a single-use password reset token.
+// The OTP is consumed by a successful exchange. An unknown email is reported as an invalid OTP.
+func (s *AuthService) VerifyOTP(email string, code string) (string, *time.Time, error) {
 
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
-		return false, fmt.Errorf("failed to retrieve user: %w", err)
+		if err.Error() == fmt.Sprintf("user with email %s not found", email) {
+			return "", nil, errors.New("invalid or expired otp")
+		}
+		return "", nil, fmt.Errorf("failed to retrieve user: %w", err)
 	}
 
-	return s.otpRepo.VerifyOTP(user.ID, code)
-}
-
-func (s *AuthService) UpdateUserPassword(email string, newPassword string) error {
-
-	user, err := s.userRepo.GetUserByEmail(email)
+	token, err := utils.GenerateOpaqueToken(resetTokenBytes)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve user: %w", err)
+		return "", nil, fmt.Errorf("failed to generate reset token: %w", err)
 	}
-	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	resetToken := &models.PasswordResetToken{
+		TokenHash: utils.HashOpaqueToken(token),
+		ExpiresAt: time.Now().Add(resetTokenLifetime),
+	}
+
+	exchanged, err := s.otpRepo.ExchangeOTP(user.ID, code, s.rateLimit.OTPMaxAttempts, resetToken)
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		return "", nil, err
+	}
+	if !exchanged {
+		return "", nil, errors.New("invalid or expired otp")
 	}
 
-	return s.userRepo.UpdateUserPassword(user.ID, string(hashedPassword))
+	return token, &resetToken.ExpiresAt, nil
 }
 
-func (s *AuthService) DeleteOTP(email string) error {
-	user, err := s.userRepo.GetUserByEmail(email)
+// ResetPassword sets a new password with a reset token from VerifyOTP.
+// The token can only be used once, and every session of the user is logged out.
+func (s *AuthService) ResetPassword(token string, newPassword string) error {
+	// Check the password before the token is used up
+	if err := s.passwordPolicy.Check(newPassword); err != nil {
+		return err
+	}
+
+	userID, err := s.otpRepo.ConsumeResetToken(utils.HashOpaqueToken(token))
 	if err != nil {
-		return fmt.Errorf("failed to retrieve user: %w", err)
+		return err
 	}
 
-	return s.otpRepo.DeleteOTP(user.ID)
+	return s.UpdatePassword(userID, newPassword)
 }
 
-// Login authenticates a user and returns a JWT token if successful.
-// It receives email and plain-text password directly.
-func (s *AuthService) RefreshToken(refreshToken string) (string, string, error) {
+// RefreshToken exchanges a refresh token for a new pair of tokens.
+// A refresh token can only be used once; presenting it again revokes its whole family,
+// since it means the token was stolen by either the caller or whoever used it first.
+func (s *AuthService) RefreshToken(refreshToken, ipAddress string) (string, string, error) {
 
 	claims, err := utils.ValidateRefreshToken(refreshToken, s.refreshJwtSecret)
 	if err != nil {
-		return "", "", errors.New("Invalid or expired refresh token: " + err.Error())
+		return "", "", errors.New("invalid or expired refresh token")
+	}
+
+	storedToken, err := s.refreshTokenRepo.GetRefreshTokenByJTI(claims.Jti)
+	if err != nil {
+		return "", "", errors.New("invalid or expired refresh token")
+	}
+	if storedToken.RevokedAt != nil {
+		return "", "", errors.New("invalid or expired refresh token")
 	}
 
-	user, err := s.userRepo.GetUserByID(claims.UserID)
+	marked, err := s.refreshTokenRepo.MarkRefreshTokenUsed(storedToken.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if !marked {
+		if err := s.refreshTokenRepo.RevokeFamily(storedToken.FamilyID); err != nil {
+			return "", "", err
+		}
+		return "", "", errors.New("refresh token reuse detected")
+	}
+
+	user, err := s.userRepo.GetUserByID(repository.SystemScope(), claims.UserID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return "", "", errors.New("invalid credentials")
 		}
 		return "", "", fmt.Errorf("failed to retrieve user for refresh token: %w", err)
 	}
+	if user.IsSuspended() {
+		return "", "", errors.New("user account is deactivated")
+	}
 
-	newToken, newRefreshToken, err := s.generateNewToken(user)
+	newToken, newRefreshToken, err := s.generateNewToken(user, storedToken.FamilyID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate both token: %w", err)
 	}
 
+	if err := s.sessionRepo.TouchSession(storedToken.FamilyID, ipAddress); err != nil {
+		return "", "", err
+	}
+
 	return newToken, newRefreshToken, nil
 }
 
-// Generate new token and refresh token from user
-func (s *AuthService) generateNewToken(user *models.User) (string, string, error) {
+// Logout revokes the token family of a refresh token, ending the session it belongs to.
+func (s *AuthService) Logout(userID uint, refreshToken string) error {
+	claims, err := utils.ValidateRefreshToken(refreshToken, s.refreshJwtSecret)
+	if err != nil || claims.UserID != userID {
+		return errors.New("invalid or expired refresh token")
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(claims.FamilyID)
+}
+
+// LogoutAll revokes every refresh token of a user, ending their sessions on all devices.
+func (s *AuthService) LogoutAll(userID uint) error {
+	return s.refreshTokenRepo.RevokeUserTokens(userID)
+}
+
+// ResendEmailVerification emails a new verification code for the pending email of a user, or their current one.
+func (s *AuthService) ResendEmailVerification(userID uint) error {
+	window := time.Duration(s.rateLimit.WindowMinutes) * time.Minute
+	count, err := s.rateLimitStore.Increment(context.TODO(), fmt.Sprintf("email-verification:%d", userID), window)
+	if err != nil {
+		return err
+	}
+	if count > s.rateLimit.OTPRequestLimit {
+		return errors.New("too many attempts, please try again later")
+	}
+
+	user, err := s.userRepo.GetUserByID(repository.SystemScope(), userID)
+	if err != nil {
+		return err
+	}
+
+	email := user.Email
+	if user.PendingEmail != nil {
+		email = *user.PendingEmail
+	} else if user.IsEmailVerified() {
+		return errors.New("email is already verified")
+	}
+
+	return sendEmailVerification(s.verificationRepo, s.mailerClient, user, email)
+}
+
+// VerifyEmail confirms the code sent to a user, making the verified address their email.
+func (s *AuthService) VerifyEmail(userID uint, code string) error {
+	user, err := s.userRepo.GetUserByID(repository.SystemScope(), userID)
+	if err != nil {
+		return err
+	}
+
+	// The pending email may have been taken by another account since it was requested
+	if user.PendingEmail != nil {
+		if _, err := s.userRepo.GetUserByEmail(*user.PendingEmail); err == nil {
+			return errors.New("user with this email already exists")
+		}
+	}
+
+	confirmed, err := s.verificationRepo.ConfirmEmailVerification(userID, code, s.rateLimit.OTPMaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return errors.New("invalid or expired verification code")
+	}
+	return nil
+}
+
+// Impersonate mints a short-lived access token letting a SAMA crew member act as a user.
+// No refresh token is issued, and the start of the impersonation is recorded in the audit trail.
+func (s *AuthService) Impersonate(actorID, userID uint, ipAddress string) (string, *time.Time, error) {
+	if actorID == userID {
+		return "", nil, errors.New("cannot impersonate yourself")
+	}
+
+	user, err := s.userRepo.GetUserByID(repository.SystemScope(), userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if user.Role == "SAMA" {
+		return "", nil, errors.New("cannot impersonate a SAMA user")
+	}
+
+	token, err := utils.GenerateImpersonationToken(user.ID, user.SchoolID, user.Email, user.Role, actorID, s.jwtSecret, s.impersonationMins)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	expiresAt := time.Now().Add(time.Duration(s.impersonationMins) * time.Minute)
+
+	err = s.impersonationRepo.CreateImpersonationAudit(&models.ImpersonationAudit{
+		ActorID:   actorID,
+		UserID:    user.ID,
+		Action:    "START",
+		IPAddress: ipAddress,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, &expiresAt, nil
+}
+
+// recordLoginFailure counts a failed login and locks the account once too many failures happened.
+// Each further lockout lasts twice as long as the previous one, up to the configured maximum.
+func (s *AuthService) recordLoginFailure(ctx context.Context, limitKey string) error {
+	failures, err := s.rateLimitStore.Increment(ctx, limitKey, loginFailureWindow)
+	if err != nil {
+		return err
+	}
+
+	if maxAttempts := s.rateLimit.LoginMaxAttempts; maxAttempts > 0 && failures%maxAttempts == 0 {
+		lockout := time.Duration(s.rateLimit.LockoutMinutes) * time.Minute
+		maxLockout := time.Duration(s.rateLimit.MaxLockoutMinutes) * time.Minute
+		for i := 1; i < failures/maxAttempts && lockout < maxLockout; i++ {
+			lockout *= 2
+		}
+		if lockout > maxLockout {
+			lockout = maxLockout
+		}
+		if err := s.rateLimitStore.Lock(ctx, limitKey, time.Now().Add(lockout)); err != nil {
+			return err
+		}
+	}
+
+	return errors.New("invalid credentials")
+}
+
+// Generate new token and refresh token from user, the refresh token joins the given family
+func (s *AuthService) generateNewToken(user *models.User, familyID string) (string, string, error) {
 	// Generate JWT token
 	token, err := utils.GenerateToken(user.ID, user.SchoolID, user.Email, user.Role, s.jwtSecret, s.jwtExpMins)
 	if err != nil {
@@ -217,10 +533,22 @@ func (s *AuthService) generateNewToken(user *models.User) (string, string, error
 	}
 
 	// Generate JWT refresh token
-	refreshToken, err := utils.GenerateRefreshToken(user.ID, s.refreshJwtSecret, s.refreshJwtExpMins)
+	jti := uuid.NewString()
+	refreshToken, err := utils.GenerateRefreshToken(user.ID, familyID, jti, s.refreshJwtSecret, s.refreshJwtExpMins)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
+	// Store the refresh token so it can be rotated and revoked
+	err = s.refreshTokenRepo.CreateRefreshToken(&models.RefreshToken{
+		JTI:       jti,
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Duration(s.refreshJwtExpMins) * time.Minute),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
 	return token, refreshToken, nil
 }
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"slices"
+	"strings"
+
+	"sama/sama-backend-2025/src/config"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+
+	"github.com/google/uuid"
+)
+
+// avatarMaxSide is the longest side in pixels of the stored avatar, larger uploads are scaled down.
+const avatarMaxSide = 1024
+
+// AVATAR_CONTENT_TYPES are the image types accepted for avatar uploads.
+var AVATAR_CONTENT_TYPES = []string{"image/jpeg", "image/png", "image/webp"}
+
+// AvatarService handles the upload, processing and cleanup of profile pictures.
+type AvatarService struct {
+	blobStore pkg.BlobStore
+	userRepo  *repository.UserRepository
+	maxBytes  int64
+}
+
+// NewAvatarService creates a new instance of AvatarService.
+func NewAvatarService(cfg *config.Config, blobStore pkg.BlobStore) *AvatarService {
+	return &AvatarService{
+		blobStore: blobStore,
+		userRepo:  repository.NewUserRepository(),
+		maxBytes:  int64(cfg.Avatar.MaxSizeKB) * 1024,
+	}
+}
+
+// RequestAvatarUpload generates a presigned POST request for a user to upload a new avatar.
+// The upload lands under a pending key and only becomes the avatar once confirmed.
+func (s *AvatarService) RequestAvatarUpload(ctx context.Context, userID uint, contentType string) (*pkg.PresignedPost, error) {
+	contentType = strings.ToLower(contentType)
+	if !slices.Contains(AVATAR_CONTENT_TYPES, contentType) {
+		return nil, fmt.Errorf("content type %s is not allowed", contentType)
+	}
+
+	objectKey := fmt.Sprintf("%s%s", pendingAvatarPrefix(userID), uuid.New().String())
+	request, err := s.blobStore.PresignUpload(ctx, objectKey, pkg.PostPolicy{ContentType: contentType, MaxBytes: s.maxBytes})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get presigned URL from storage: %w", err)
+	}
+	return request, nil
+}
+
+// ConfirmAvatar processes an uploaded avatar and sets it as the profile picture of the user.
+// The image is re-encoded as JPEG with thumbnails in every standard size, then the upload and
+// the previous avatar are deleted.
+func (s *AvatarService) ConfirmAvatar(ctx context.Context, userID uint, objectKey string) (*models.User, error) {
+	pendingID, ok := strings.CutPrefix(objectKey, pendingAvatarPrefix(userID))
+	if !ok || uuid.Validate(pendingID) != nil {
+		return nil, errors.New("invalid avatar object key")
+	}
+
+	user, err := s.userRepo.GetUserByID(repository.SystemScope(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _, err := s.blobStore.GetObject(ctx, objectKey, s.maxBytes)
+	if err != nil {
+		return nil, errors.New("uploaded avatar not found or too large")
+	}
+	img, _, err := pkg.DecodeImage(data)
+	if err != nil {
+		if err := s.blobStore.DeleteObjects(ctx, objectKey); err != nil {
+			log.Printf("failed to delete uploaded avatar %s: %v", objectKey, err)
+		}
+		return nil, fmt.Errorf("invalid avatar: %w", err)
+	}
+
+	avatarKey := fmt.Sprintf("avatars/%d/%s.jpg", userID, pendingID)
+	if err := s.putJPEG(ctx, avatarKey, pkg.FitImage(img, avatarMaxSide)); err != nil {
+		return nil, err
+	}
+	for _, size := range models.AVATAR_THUMBNAIL_SIZES {
+		if err := s.putJPEG(ctx, models.AvatarThumbnailKey(avatarKey, size), pkg.SquareThumbnail(img, size)); err != nil {
+			s.deleteAvatarObjects(ctx, avatarKey)
+			return nil, err
+		}
+	}
+
+	if err := s.userRepo.UpdateUserProfilePicture(userID, avatarKey); err != nil {
+		s.deleteAvatarObjects(ctx, avatarKey)
+		return nil, fmt.Errorf("failed to update profile picture: %w", err)
+	}
+
+	// The avatar is saved at this point, leftovers only cost storage
+	if err := s.blobStore.DeleteObjects(ctx, objectKey); err != nil {
+		log.Printf("failed to delete uploaded avatar %s: %v", objectKey, err)
+	}
+	if user.ProfilePictureURL != nil {
+		s.deleteAvatarObjects(ctx, *user.ProfilePictureURL)
+	}
+
+	return s.userRepo.GetUserByID(repository.SystemScope(), userID)
+}
+
+// DeleteAvatar removes the profile picture of a user along with its thumbnails.
+func (s *AvatarService) DeleteAvatar(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetUserByID(repository.SystemScope(), userID)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.DeleteUserProfilePicture(userID); err != nil {
+		return fmt.Errorf("failed to delete profile picture: %w", err)
+	}
+	if user.ProfilePictureURL != nil {
+		s.deleteAvatarObjects(ctx, *user.ProfilePictureURL)
+	}
+	return nil
+}
+
+// putJPEG encodes an image as JPEG and uploads it under objectKey.
+func (s *AvatarService) putJPEG(ctx context.Context, objectKey string, img image.Image) error {
+	data, err := pkg.EncodeJPEG(img)
+	if err != nil {
+		return err
+	}
+	if err := s.blobStore.PutObject(ctx, objectKey, data, "image/jpeg"); err != nil {
+		return fmt.Errorf("failed to upload avatar: %w", err)
+	}
+	return nil
+}
+
+// deleteAvatarObjects deletes an avatar and its thumbnails from the bucket.
+// Keys that weren't produced by the avatar flow are left alone, they may point to any object.
+func (s *AvatarService) deleteAvatarObjects(ctx context.Context, avatarKey string) {
+	if !models.IsAvatarKey(avatarKey) {
+		return
+	}
+	keys := []string{avatarKey}
+	for _, size := range models.AVATAR_THUMBNAIL_SIZES {
+		keys = append(keys, models.AvatarThumbnailKey(avatarKey, size))
+	}
+	if err := s.blobStore.DeleteObjects(ctx, keys...); err != nil {
+		log.Printf("failed to delete avatar %s: %v", avatarKey, err)
+	}
+}
+
+// pendingAvatarPrefix returns the key prefix of the avatar uploads of a user that aren't confirmed yet.
+func pendingAvatarPrefix(userID uint) string {
+	return fmt.Sprintf("avatars/%d/pending/", userID)
+}
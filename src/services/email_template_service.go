@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+
+	"sama/sama-backend-2025/src/pkg"
+)
+
+// emailTemplateSamples is the data every email template is previewed with, matching what the senders pass.
+var emailTemplateSamples = map[string]map[string]any{
+	"otp": {
+		"Name":            "Somchai Jaidee",
+		"Code":            "123456",
+		"LifetimeMinutes": 5,
+	},
+	"email_verification": {
+		"Name":          "Somchai Jaidee",
+		"Code":          "123456",
+		"LifetimeHours": int(emailVerificationLifetime.Hours()),
+	},
+	"welcome": {
+		"Name":     "Somchai Jaidee",
+		"Password": "Initial-Password-1",
+	},
+	"invitation": {
+		"Code":              "AB12CD34EF",
+		"Role":              "STD",
+		"SchoolThaiName":    "โรงเรียนตัวอย่าง",
+		"SchoolEnglishName": "Example School",
+		"ExpiresAt":         "2025-08-31 23:59 UTC",
+	},
+	"activity_reminder": {
+		"Name":            "Somchai Jaidee",
+		"ActivityName":    "Community service",
+		"Deadline":        "2025-08-31",
+		"DaysLeft":        3,
+		"FinishedPercent": 40,
+	},
+	"record_sent": {
+		"Name":         "Somsri Rakrian",
+		"StudentName":  "Somchai Jaidee",
+		"ActivityName": "Community service",
+		"Amount":       3,
+	},
+	"record_approved": {
+		"Name":         "Somchai Jaidee",
+		"ActivityName": "Community service",
+		"TeacherName":  "Somsri Rakrian",
+		"Advice":       "Well done",
+	},
+	"record_rejected": {
+		"Name":         "Somchai Jaidee",
+		"ActivityName": "Community service",
+		"TeacherName":  "Somsri Rakrian",
+		"Advice":       "Please attach a photo of the activity",
+	},
+	"activity_published": {
+		"Name":         "Somchai Jaidee",
+		"ActivityName": "Community service",
+		"Required":     true,
+		"Deadline":     "2025-08-31",
+	},
+	"pending_records_digest": {
+		"Name":  "Somsri Rakrian",
+		"Total": 5,
+		"Activities": []map[string]any{
+			{"Name": "Community service", "Count": 3},
+			{"Name": "Reading", "Count": 2},
+		},
+	},
+}
+
+// EmailTemplateService lets administrators review the email templates.
+type EmailTemplateService struct {
+	mailerClient *pkg.MailerService
+}
+
+// NewEmailTemplateService creates a new instance of EmailTemplateService.
+func NewEmailTemplateService(mailerClient *pkg.MailerService) *EmailTemplateService {
+	return &EmailTemplateService{
+		mailerClient: mailerClient,
+	}
+}
+
+// GetEmailTemplates returns the languages every email template exists in, by template name.
+func (s *EmailTemplateService) GetEmailTemplates() map[string][]string {
+	return s.mailerClient.TemplateLocales()
+}
+
+// PreviewEmailTemplate renders a template with sample data in the given language.
+// The message tells which language was used when the template falls back to another one.
+func (s *EmailTemplateService) PreviewEmailTemplate(name, locale string) (*pkg.EmailMessage, error) {
+	if _, ok := s.mailerClient.TemplateLocales()[name]; !ok {
+		return nil, fmt.Errorf("email template %s not found", name)
+	}
+
+	// Templates added through the template directory have no sample, their fields render as "<no value>"
+	data := emailTemplateSamples[name]
+	if data == nil {
+		data = map[string]any{}
+	}
+	return s.mailerClient.Render(name, locale, data)
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+)
+
+// emailVerificationLifetime is how long an email verification code can be used after it was sent.
+const emailVerificationLifetime = 24 * time.Hour
+
+// sendEmailVerification emails a new verification code to the address, replacing the pending verification of the user.
+func sendEmailVerification(repo *repository.EmailVerificationRepository, mailer *pkg.MailerService, user *models.User, email string) error {
+	code, err := repo.CreateEmailVerification(user.ID, email, emailVerificationLifetime)
+	if err != nil {
+		return err
+	}
+
+	err = mailer.Send(context.TODO(), "email_verification", user.Language, map[string]any{
+		"Name":          user.Firstname + " " + user.Lastname,
+		"Code":          code,
+		"LifetimeHours": int(emailVerificationLifetime.Hours()),
+	}, email)
+	if err != nil {
+		repo.DeleteEmailVerification(user.ID)
+		return err
+	}
+	return nil
+}
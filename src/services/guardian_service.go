@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/repository"
+)
+
+// GuardianService handles business logic for the links between guardians and students.
+type GuardianService struct {
+	guardianRepo *repository.GuardianRepository
+	userRepo     *repository.UserRepository
+}
+
+// NewGuardianService creates a new instance of GuardianService.
+func NewGuardianService() *GuardianService {
+	return &GuardianService{
+		guardianRepo: repository.NewGuardianRepository(),
+		userRepo:     repository.NewUserRepository(),
+	}
+}
+
+// getGuardian retrieves a user within the tenant scope and makes sure it is a guardian.
+func (s *GuardianService) getGuardian(scope repository.TenantScope, guardianID uint) (*models.User, error) {
+	guardian, err := s.userRepo.GetUserByID(scope, guardianID)
+	if err != nil {
+		return nil, err
+	}
+	if guardian.Role != "GRD" {
+		return nil, errors.New("user is not a guardian")
+	}
+	return guardian, nil
+}
+
+// LinkStudent gives a guardian read access to a student of the same school.
+func (s *GuardianService) LinkStudent(scope repository.TenantScope, guardianID, studentID, createdByID uint) (*models.GuardianLink, error) {
+	guardian, err := s.getGuardian(scope, guardianID)
+	if err != nil {
+		return nil, err
+	}
+
+	student, err := s.userRepo.GetUserByID(scope, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if student.Role != "STD" {
+		return nil, errors.New("user is not a student")
+	}
+	if student.SchoolID != guardian.SchoolID {
+		return nil, errors.New("guardian and student must belong to the same school")
+	}
+
+	linked, err := s.guardianRepo.IsGuardianOf(guardianID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		return nil, fmt.Errorf("guardian is already linked to student %d", studentID)
+	}
+
+	link := &models.GuardianLink{
+		GuardianID:  guardianID,
+		StudentID:   studentID,
+		CreatedByID: createdByID,
+	}
+	if err := s.guardianRepo.CreateGuardianLink(link); err != nil {
+		return nil, err
+	}
+	link.Student = *student
+	return link, nil
+}
+
+// UnlinkStudent removes the access of a guardian to a student.
+func (s *GuardianService) UnlinkStudent(scope repository.TenantScope, guardianID, studentID uint) error {
+	if _, err := s.getGuardian(scope, guardianID); err != nil {
+		return err
+	}
+	return s.guardianRepo.DeleteGuardianLink(guardianID, studentID)
+}
+
+// GetLinkedStudents retrieves the students a guardian is linked to.
+func (s *GuardianService) GetLinkedStudents(scope repository.TenantScope, guardianID uint) ([]models.GuardianLink, error) {
+	if _, err := s.getGuardian(scope, guardianID); err != nil {
+		return nil, err
+	}
+	return s.guardianRepo.GetGuardianLinksByGuardianID(guardianID)
+}
@@ -5,37 +5,39 @@ import (
 	"errors"
 	"fmt"
 	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+	"strings"
 
-	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/google/uuid"
 )
 
 // ImageService handles business logic for image uploads.
 type ImageService struct {
-	s3Client *pkg.S3Client
+	blobStore    pkg.BlobStore
+	settingsRepo *repository.SchoolSettingsRepository
 }
 
 // NewImageService creates a new instance of ImageService.
 func NewImageService(
-	s3Client *pkg.S3Client,
+	blobStore pkg.BlobStore,
 ) *ImageService {
 	return &ImageService{
-		s3Client: s3Client,
+		blobStore:    blobStore,
+		settingsRepo: repository.NewSchoolSettingsRepository(),
 	}
 }
 
 // RequestDownloadPresignedURL generates a presigned URL for downloading an object.
-// The URL is valid for the duration configured in the S3 client.
-func (s *ImageService) RequestDownloadPresignedURL(ctx context.Context, objectKey string) (*v4.PresignedHTTPRequest, error) {
+// The URL is valid for the duration configured for the storage.
+func (s *ImageService) RequestDownloadPresignedURL(ctx context.Context, objectKey string) (*pkg.PresignedRequest, error) {
 	if objectKey == "" {
 		return nil, errors.New("objectKey cannot be empty")
 	}
 
-	// Call the S3 client to get the presigned download URL
-	request, err := s.s3Client.GetPresignedDownloadURL(ctx, objectKey)
+	// Ask the storage for the presigned download URL
+	request, err := s.blobStore.PresignDownload(ctx, objectKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get presigned download URL from S3 client: %w", err)
+		return nil, fmt.Errorf("failed to get presigned download URL from storage: %w", err)
 	}
 
 	return request, nil
@@ -43,7 +45,8 @@ func (s *ImageService) RequestDownloadPresignedURL(ctx context.Context, objectKe
 
 // RequestUploadPresignedURL generates a presigned POST URL for a user to upload an image.
 // The object key will be formatted as "user_id/uuid.extension".
-func (s *ImageService) RequestUploadPresignedURL(ctx context.Context, userID uint, fileExtension string) (*s3.PresignedPostRequest, error) {
+// Only file types allowed by the settings of the user's school are accepted.
+func (s *ImageService) RequestUploadPresignedURL(ctx context.Context, userID, schoolID uint, fileExtension string) (*pkg.PresignedPost, error) {
 	if userID == 0 {
 		return nil, errors.New("userID cannot be empty")
 	}
@@ -51,13 +54,23 @@ func (s *ImageService) RequestUploadPresignedURL(ctx context.Context, userID uin
 		return nil, errors.New("fileExtension cannot be empty")
 	}
 
+	settings, err := s.settingsRepo.GetSettingsBySchoolID(schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	fileExtension = strings.ToLower(fileExtension)
+	if !settings.IsAttachmentTypeAllowed(fileExtension) {
+		return nil, fmt.Errorf("file type %s is not allowed", fileExtension)
+	}
+
 	// Generate a unique filename using userID and a random UUID
 	filename := fmt.Sprintf("%d/%s.%s", userID, uuid.New().String(), fileExtension)
 
-	// Call the S3 client to get the presigned POST URL with a policy for images
-	request, err := s.s3Client.PresignPostObject(ctx, filename)
+	// Ask the storage for the presigned POST URL, the school settings already restrict the file type
+	request, err := s.blobStore.PresignUpload(ctx, filename, pkg.PostPolicy{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get presigned URL from S3 client: %w", err)
+		return nil, fmt.Errorf("failed to get presigned URL from storage: %w", err)
 	}
 
 	return request, nil
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+	"sama/sama-backend-2025/src/utils"
+)
+
+// invitationCodeLength is the length of generated invitation codes.
+const invitationCodeLength = 10
+
+// InvitationService handles business logic for invitation codes.
+type InvitationService struct {
+	invitationRepo *repository.InvitationRepository
+	schoolRepo     *repository.SchoolRepository
+	settingsRepo   *repository.SchoolSettingsRepository
+	mailerClient   *pkg.MailerService
+	validator      *validator.Validate
+}
+
+// NewInvitationService creates a new instance of InvitationService.
+func NewInvitationService(mailerClient *pkg.MailerService, validate *validator.Validate) *InvitationService {
+	return &InvitationService{
+		invitationRepo: repository.NewInvitationRepository(),
+		schoolRepo:     repository.NewSchoolRepository(),
+		settingsRepo:   repository.NewSchoolSettingsRepository(),
+		mailerClient:   mailerClient,
+		validator:      validate,
+	}
+}
+
+// CreateInvitationCode generates a new code for the school of the invitation.
+// The role must be allowed to register by the school settings, and the classroom must exist in the school.
+func (s *InvitationService) CreateInvitationCode(scope repository.TenantScope, invitation *models.InvitationCode) error {
+	if !scope.CanAccessSchool(invitation.SchoolID) {
+		return fmt.Errorf("school with ID %d not found", invitation.SchoolID)
+	}
+
+	if err := s.validator.Struct(invitation); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if invitation.ExpiresAt != nil && !invitation.ExpiresAt.After(time.Now()) {
+		return errors.New("validation failed: expires_at must be in the future")
+	}
+
+	school, err := s.schoolRepo.GetSchoolByID(invitation.SchoolID)
+	if err != nil {
+		return err
+	}
+	if invitation.Classroom != nil && !utils.Contains(school.Classrooms, *invitation.Classroom) {
+		return fmt.Errorf("validation failed: classroom %s does not exist in this school", *invitation.Classroom)
+	}
+
+	settings, err := s.settingsRepo.GetSettingsBySchoolID(invitation.SchoolID)
+	if err != nil {
+		return err
+	}
+	if !settings.CanRegister(invitation.Role) {
+		return fmt.Errorf("registration as %s is not allowed in this school", invitation.Role)
+	}
+
+	invitation.Code, err = utils.GenerateInvitationCode(invitationCodeLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate invitation code: %w", err)
+	}
+	invitation.UsedCount = 0
+	invitation.RevokedAt = nil
+
+	return s.invitationRepo.CreateInvitationCode(invitation)
+}
+
+// GetInvitationCodeByID retrieves an invitation code with the users who registered with it.
+func (s *InvitationService) GetInvitationCodeByID(scope repository.TenantScope, id uint) (*models.InvitationCode, error) {
+	return s.invitationRepo.GetInvitationCodeByID(scope, id)
+}
+
+// GetInvitationCodesBySchoolID retrieves the invitation codes of a school with pagination.
+func (s *InvitationService) GetInvitationCodesBySchoolID(scope repository.TenantScope, schoolID uint, limit, offset int) ([]models.InvitationCode, int, error) {
+	if !scope.CanAccessSchool(schoolID) {
+		return nil, 0, fmt.Errorf("school with ID %d not found", schoolID)
+	}
+	return s.invitationRepo.GetInvitationCodesBySchoolID(scope, schoolID, limit, offset)
+}
+
+// RevokeInvitationCode stops an invitation code from being used. Users who already registered are kept.
+func (s *InvitationService) RevokeInvitationCode(scope repository.TenantScope, id uint) error {
+	return s.invitationRepo.RevokeInvitationCode(scope, id)
+}
+
+// EmailInvitationCode sends an invitation code to the given addresses.
+// The recipients have no account yet, so the email is in the given language or the default language of the school.
+func (s *InvitationService) EmailInvitationCode(ctx context.Context, scope repository.TenantScope, id uint, emails []string, language string) error {
+	invitation, err := s.invitationRepo.GetInvitationCodeByID(scope, id)
+	if err != nil {
+		return err
+	}
+	if !invitation.IsUsable(time.Now()) {
+		return errors.New("invitation code is no longer usable")
+	}
+
+	school, err := s.schoolRepo.GetSchoolByID(invitation.SchoolID)
+	if err != nil {
+		return err
+	}
+	if language == "" {
+		settings, err := s.settingsRepo.GetSettingsBySchoolID(invitation.SchoolID)
+		if err != nil {
+			return err
+		}
+		language = settings.DefaultLanguage
+	}
+
+	expiresAt := ""
+	if invitation.ExpiresAt != nil {
+		expiresAt = invitation.ExpiresAt.UTC().Format("2006-01-02 15:04 MST")
+	}
+	return s.mailerClient.Send(ctx, "invitation", language, map[string]any{
+		"Code":              invitation.Code,
+		"Role":              invitation.Role,
+		"SchoolThaiName":    school.ThaiName,
+		"SchoolEnglishName": school.EnglishName,
+		"ExpiresAt":         expiresAt,
+	}, emails...)
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"sama/sama-backend-2025/src/config"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+)
+
+// notificationTemplates are the email templates the title and body of every event are rendered from.
+var notificationTemplates = map[string]string{
+	"RECORD_SENT":          "record_sent",
+	"RECORD_APPROVED":      "record_approved",
+	"RECORD_REJECTED":      "record_rejected",
+	"DEADLINE_APPROACHING": "activity_reminder",
+	"ACTIVITY_PUBLISHED":   "activity_published",
+}
+
+// NotificationService creates notifications for domain events and lets users read them.
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	userRepo         *repository.UserRepository
+	activityRepo     *repository.ActivityRepository
+	schoolRepo       *repository.SchoolRepository
+	mailerClient     *pkg.MailerService
+	retention        time.Duration // Notifications older than this are deleted
+}
+
+// NewNotificationService creates a new instance of NotificationService.
+func NewNotificationService(cfg *config.Config, mailerClient *pkg.MailerService) *NotificationService {
+	return &NotificationService{
+		notificationRepo: repository.NewNotificationRepository(),
+		userRepo:         repository.NewUserRepository(),
+		activityRepo:     repository.NewActivityRepository(),
+		schoolRepo:       repository.NewSchoolRepository(),
+		mailerClient:     mailerClient,
+		retention:        time.Duration(cfg.Notification.RetentionDays) * 24 * time.Hour,
+	}
+}
+
+// notificationTarget is a user to notify with the template data specific to them.
+type notificationTarget struct {
+	user models.User
+	data map[string]any
+}
+
+// notify renders the notification of an event in the language of every target and stores them.
+// Targets who want the event by email are also sent it, in the background so the request isn't held by the mailer.
+func (s *NotificationService) notify(notificationType string, activityID, recordID *uint, targets []notificationTarget) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	templateName := notificationTemplates[notificationType]
+
+	notifications := make([]models.Notification, 0, len(targets))
+	userIDs := make([]uint, 0, len(targets))
+	for _, target := range targets {
+		target.data["Name"] = target.user.Firstname + " " + target.user.Lastname
+		message, err := s.mailerClient.Render(templateName, target.user.Language, target.data)
+		if err != nil {
+			return fmt.Errorf("failed to render %s notification: %w", notificationType, err)
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:     target.user.ID,
+			Type:       notificationType,
+			Title:      message.Subject,
+			Body:       strings.TrimSpace(message.TextBody),
+			ActivityID: activityID,
+			RecordID:   recordID,
+		})
+		userIDs = append(userIDs, target.user.ID)
+	}
+
+	if err := s.notificationRepo.CreateNotifications(notifications); err != nil {
+		return err
+	}
+
+	preferences, err := s.notificationRepo.GetNotificationPreferencesByUserIDs(userIDs, notificationType)
+	if err != nil {
+		return err
+	}
+	emailTargets := make([]notificationTarget, 0)
+	for _, target := range targets {
+		email, ok := preferences[target.user.ID]
+		if !ok {
+			email = slices.Contains(models.NOTIFICATION_EMAIL_DEFAULTS, notificationType)
+		}
+		if email {
+			emailTargets = append(emailTargets, target)
+		}
+	}
+	if len(emailTargets) > 0 {
+		go s.sendEmails(templateName, emailTargets)
+	}
+
+	return nil
+}
+
+// sendEmails sends the email of a notification to every target, logging the failures since nobody waits for them.
+func (s *NotificationService) sendEmails(templateName string, targets []notificationTarget) {
+	for _, target := range targets {
+		if err := s.mailerClient.Send(context.Background(), templateName, target.user.Language, target.data, target.user.Email); err != nil {
+			log.Printf("failed to send %s notification email to user %d: %v", templateName, target.user.ID, err)
+		}
+	}
+}
+
+// NotifyRecordSent notifies the teacher a record was sent to.
+func (s *NotificationService) NotifyRecordSent(record *models.Record, activity *models.Activity) error {
+	if record.TeacherID == nil {
+		return nil
+	}
+	teacher, err := s.userRepo.GetUserByID(repository.SystemScope(), *record.TeacherID)
+	if err != nil {
+		return err
+	}
+	student, err := s.userRepo.GetUserByID(repository.SystemScope(), record.StudentID)
+	if err != nil {
+		return err
+	}
+
+	return s.notify("RECORD_SENT", &activity.ID, &record.ID, []notificationTarget{{
+		user: *teacher,
+		data: map[string]any{
+			"StudentName":  student.Firstname + " " + student.Lastname,
+			"ActivityName": activity.Name,
+			"Amount":       record.Amount,
+		},
+	}})
+}
+
+// NotifyRecordReviewed notifies the student owning a record it was approved or rejected, depending on its status.
+func (s *NotificationService) NotifyRecordReviewed(record *models.Record, activity *models.Activity, reviewerID uint) error {
+	if record.Status != "APPROVED" && record.Status != "REJECTED" {
+		return fmt.Errorf("record with status %s has no review notification", record.Status)
+	}
+	notificationType := "RECORD_" + record.Status
+
+	student, err := s.userRepo.GetUserByID(repository.SystemScope(), record.StudentID)
+	if err != nil {
+		return err
+	}
+	reviewer, err := s.userRepo.GetUserByID(repository.SystemScope(), reviewerID)
+	if err != nil {
+		return err
+	}
+	advice := ""
+	if record.Advise != nil {
+		advice = *record.Advise
+	}
+
+	return s.notify(notificationType, &activity.ID, &record.ID, []notificationTarget{{
+		user: *student,
+		data: map[string]any{
+			"ActivityName": activity.Name,
+			"TeacherName":  reviewer.Firstname + " " + reviewer.Lastname,
+			"Advice":       advice,
+		},
+	}})
+}
+
+// NotifyActivityPublished notifies the active students a new activity is assigned to.
+func (s *NotificationService) NotifyActivityPublished(activity *models.Activity) error {
+	students, err := s.activityRepo.GetAssignedStudentsByActivityID(activity.ID)
+	if err != nil {
+		return err
+	}
+
+	// Activities without a deadline of their own close with the default deadline of the school
+	deadline := activity.Deadline
+	if deadline == nil {
+		school, err := s.schoolRepo.GetSchoolByID(activity.SchoolID)
+		if err != nil {
+			return err
+		}
+		deadline = &school.DefaultActivityDeadline
+	}
+
+	targets := make([]notificationTarget, 0, len(students))
+	for _, student := range students {
+		targets = append(targets, notificationTarget{
+			user: student,
+			data: map[string]any{
+				"ActivityName": activity.Name,
+				"Required":     activity.IsRequired,
+				"Deadline":     deadline.Format("2006-01-02"),
+			},
+		})
+	}
+	return s.notify("ACTIVITY_PUBLISHED", &activity.ID, nil, targets)
+}
+
+// NotifyDeadlineApproaching reminds a student that an activity they haven't finished is due in daysLeft days.
+func (s *NotificationService) NotifyDeadlineApproaching(student *models.User, activity *models.ActivityWithStatistic, daysLeft int) error {
+	deadline := ""
+	if activity.Deadline != nil {
+		deadline = activity.Deadline.Format("2006-01-02")
+	}
+
+	return s.notify("DEADLINE_APPROACHING", &activity.ID, nil, []notificationTarget{{
+		user: *student,
+		data: map[string]any{
+			"ActivityName":    activity.Name,
+			"Deadline":        deadline,
+			"DaysLeft":        daysLeft,
+			"FinishedPercent": int(activity.FinishedPercentage),
+		},
+	}})
+}
+
+// GetNotifications retrieves the notifications of a user with pagination, newest first.
+func (s *NotificationService) GetNotifications(userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, int, error) {
+	return s.notificationRepo.GetNotificationsByUserID(userID, unreadOnly, limit, offset)
+}
+
+// CountUnreadNotifications counts the notifications a user hasn't read.
+func (s *NotificationService) CountUnreadNotifications(userID uint) (int, error) {
+	return s.notificationRepo.CountUnreadNotifications(userID)
+}
+
+// MarkNotificationRead marks a notification of a user as read.
+func (s *NotificationService) MarkNotificationRead(userID, id uint) (*models.Notification, error) {
+	return s.notificationRepo.MarkNotificationRead(userID, id)
+}
+
+// MarkAllNotificationsRead marks every notification of a user as read and returns how many were unread.
+func (s *NotificationService) MarkAllNotificationsRead(userID uint) (int, error) {
+	return s.notificationRepo.MarkAllNotificationsRead(userID)
+}
+
+// DeleteExpiredNotifications deletes the notifications of every user past the retention period.
+func (s *NotificationService) DeleteExpiredNotifications(ctx context.Context) error {
+	count, err := s.notificationRepo.DeleteNotificationsBefore(time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted %d expired notifications", count)
+	return nil
+}
+
+// GetNotificationPreferences returns whether a user receives every event by email,
+// the default applying to events they haven't set a preference for.
+func (s *NotificationService) GetNotificationPreferences(userID uint) ([]models.NotificationPreference, error) {
+	saved, err := s.notificationRepo.GetNotificationPreferencesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(models.NOTIFICATION_TYPE_ENUM))
+	for _, notificationType := range models.NOTIFICATION_TYPE_ENUM {
+		preference := models.NotificationPreference{
+			UserID: userID,
+			Type:   notificationType,
+			Email:  slices.Contains(models.NOTIFICATION_EMAIL_DEFAULTS, notificationType),
+		}
+		for _, savedPreference := range saved {
+			if savedPreference.Type == notificationType {
+				preference.Email = savedPreference.Email
+			}
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+// UpdateNotificationPreferences saves the preferences of a user for the given events, others are left unchanged.
+func (s *NotificationService) UpdateNotificationPreferences(userID uint, preferences []models.NotificationPreference) ([]models.NotificationPreference, error) {
+	for i := range preferences {
+		if !slices.Contains(models.NOTIFICATION_TYPE_ENUM, preferences[i].Type) {
+			return nil, fmt.Errorf("invalid notification type: %s", preferences[i].Type)
+		}
+		preferences[i].UserID = userID
+	}
+
+	if err := s.notificationRepo.SaveNotificationPreferences(preferences); err != nil {
+		return nil, err
+	}
+	return s.GetNotificationPreferences(userID)
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log"
+	"slices"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -18,16 +20,20 @@ type RecordService struct {
 	schoolRepo   *repository.SchoolRepository
 	userRepo     *repository.UserRepository // Assuming AccountRepository handles User model
 	activityRepo *repository.ActivityRepository
+	settingsRepo *repository.SchoolSettingsRepository
+	notifier     *NotificationService
 	validator    *validator.Validate
 }
 
 // NewRecordService creates a new instance of RecordService.
-func NewRecordService(validator *validator.Validate) *RecordService {
+func NewRecordService(notifier *NotificationService, validator *validator.Validate) *RecordService {
 	return &RecordService{
 		recordRepo:   repository.NewRecordRepository(),
 		schoolRepo:   repository.NewSchoolRepository(),
 		userRepo:     repository.NewUserRepository(),
 		activityRepo: repository.NewActivityRepository(),
+		settingsRepo: repository.NewSchoolSettingsRepository(),
+		notifier:     notifier,
 		validator:    validator,
 	}
 }
@@ -50,7 +56,7 @@ func (s *RecordService) validateRecordData(record *models.Record) error {
 	}
 
 	// Validate StudentID
-	_, err := s.userRepo.GetUserByID(record.StudentID)
+	_, err := s.userRepo.GetUserByID(repository.SystemScope(), record.StudentID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("student with ID %d not found", record.StudentID)
@@ -59,7 +65,7 @@ func (s *RecordService) validateRecordData(record *models.Record) error {
 	}
 
 	// Validate TeacherID
-	_, err = s.userRepo.GetUserByID(*record.TeacherID)
+	_, err = s.userRepo.GetUserByID(repository.SystemScope(), *record.TeacherID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("teacher with ID %d not found", record.TeacherID)
@@ -70,7 +76,7 @@ func (s *RecordService) validateRecordData(record *models.Record) error {
 	// Validate ActivityID (assuming ActivityID in Record is uint and refers to Activity.ID)
 	// If ActivityID in Record is string and refers to Activity.TypeID or Activity.Name,
 	// this validation logic would need to change (e.g., s.activityRepo.GetActivityByTypeID(record.ActivityID))
-	_, err = s.activityRepo.GetActivityByID(record.ActivityID) // Assuming ActivityID is uint
+	_, err = s.activityRepo.GetActivityByID(repository.SystemScope(), record.ActivityID) // Assuming ActivityID is uint
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("activity with ID %d not found", record.ActivityID)
@@ -82,9 +88,9 @@ func (s *RecordService) validateRecordData(record *models.Record) error {
 }
 
 // CreateRecord creates a new record after validation.
-func (s *RecordService) CreateRecord(record *models.Record, schoolID uint, userID uint) error {
+func (s *RecordService) CreateRecord(scope repository.TenantScope, record *models.Record, schoolID uint, userID uint) error {
 
-	activity, err := s.activityRepo.GetActivityByID(record.ActivityID)
+	activity, err := s.activityRepo.GetActivityByID(scope, record.ActivityID)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve activity with id %d: %w", schoolID, err)
 	}
@@ -93,6 +99,24 @@ func (s *RecordService) CreateRecord(record *models.Record, schoolID uint, userI
 		return fmt.Errorf("school id in activity and school id in your token mismatch")
 	}
 
+	// Students who graduated, left or are suspended keep their records but can't add new ones
+	student, err := s.userRepo.GetUserByID(repository.SystemScope(), userID)
+	if err != nil {
+		return err
+	}
+	if !student.IsActive() {
+		return fmt.Errorf("only active students can create records")
+	}
+
+	settings, err := s.settingsRepo.GetSettingsBySchoolID(activity.SchoolID)
+	if err != nil {
+		return err
+	}
+
+	if deadline := settings.SubmissionDeadline(&activity.Activity); deadline != nil && time.Now().After(*deadline) {
+		return fmt.Errorf("activity is no longer accepting records")
+	}
+
 	totalRecordAmountDone := s.recordRepo.GetRecordTotalAmount(activity.ID, userID)
 	if !activity.CanExceedLimit && totalRecordAmountDone+record.Amount > activity.FinishedAmount {
 		return fmt.Errorf("total amount from your records will exceed the limit")
@@ -117,28 +141,50 @@ func (s *RecordService) CreateRecord(record *models.Record, schoolID uint, userI
 	return s.recordRepo.CreateRecord(record)
 }
 
-// GetRecordByID retrieves a record by its ID.
-func (s *RecordService) GetRecordByID(id uint) (*models.Record, error) {
-	return s.recordRepo.GetRecordByID(id)
+// GetRecordByID retrieves a record by its ID within the tenant scope.
+func (s *RecordService) GetRecordByID(scope repository.TenantScope, id uint) (*models.Record, error) {
+	return s.recordRepo.GetRecordByID(scope, id)
 }
 
 // GetAllRecords retrieves all records with filtering and pagination.
 func (s *RecordService) GetAllRecords(
+	scope repository.TenantScope,
 	studentID, teacherID, activityID uint,
 	status string,
 	limit, offset int,
 ) ([]models.Record, int, error) {
-	return s.recordRepo.GetAllRecords(studentID, teacherID, activityID, status, limit, offset)
+	return s.recordRepo.GetAllRecords(scope, studentID, teacherID, activityID, status, limit, offset)
 }
 
 // UpdateRecord updates an existing record.
-func (s *RecordService) UpdateRecord(record *models.Record, updatedByUserID uint) error {
+func (s *RecordService) UpdateRecord(scope repository.TenantScope, record *models.Record, updatedByUserID uint) error {
 	// Fetch existing record to ensure it exists and to get its current state for status logging
-	existingRecord, err := s.recordRepo.GetRecordByID(record.ID)
+	existingRecord, err := s.recordRepo.GetRecordByID(scope, record.ID)
 	if err != nil {
 		return fmt.Errorf("record not found for update: %w", err)
 	}
 
+	// Students can only edit their records as far as the school allows
+	if scope.Role == "STD" {
+		if existingRecord.StudentID != updatedByUserID {
+			return fmt.Errorf("record can only be edited by its owner")
+		}
+
+		activity, err := s.activityRepo.GetActivityByID(scope, existingRecord.ActivityID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve activity of record: %w", err)
+		}
+
+		settings, err := s.settingsRepo.GetSettingsBySchoolID(activity.SchoolID)
+		if err != nil {
+			return err
+		}
+
+		if !settings.CanStudentEditRecord(existingRecord.Status) {
+			return fmt.Errorf("record with status %s cannot be edited", existingRecord.Status)
+		}
+	}
+
 	// Apply updates from the input `record` to `existingRecord`
 	// Only update fields that are explicitly provided or allowed to be changed.
 
@@ -149,6 +195,10 @@ func (s *RecordService) UpdateRecord(record *models.Record, updatedByUserID uint
 	existingRecord.Data = record.Data
 	existingRecord.Amount = record.Amount
 
+	// A guardian confirmed the previous content, the edited record has to be confirmed again
+	existingRecord.GuardianAcknowledgedAt = nil
+	existingRecord.GuardianAcknowledgedByID = nil
+
 	// StatusLogs is updated internally by service, not directly from DTO
 	// existingRecord.StatusLogs = record.StatusLogs // DO NOT directly assign from DTO
 
@@ -162,20 +212,53 @@ func (s *RecordService) UpdateRecord(record *models.Record, updatedByUserID uint
 	// 	return fmt.Errorf("updated record data validation failed: %w", err)
 	// }
 
-	return s.recordRepo.UpdateRecord(existingRecord)
+	return s.recordRepo.UpdateRecord(scope, existingRecord)
 }
 
-// DeleteRecord deletes a record by its ID.
-func (s *RecordService) DeleteRecord(id uint) error {
-	return s.recordRepo.DeleteRecord(id)
+// DeleteRecord deletes a record by its ID within the tenant scope.
+func (s *RecordService) DeleteRecord(scope repository.TenantScope, id uint) error {
+	return s.recordRepo.DeleteRecord(scope, id)
 }
 
-func (r *RecordService) SendRecord(id, teacherID, userID uint) error {
-	existingRecord, err := r.recordRepo.GetRecordByID(id)
+func (r *RecordService) SendRecord(scope repository.TenantScope, id, teacherID, userID uint) error {
+	existingRecord, err := r.recordRepo.GetRecordByID(scope, id)
 	if err != nil {
 		return fmt.Errorf("record not found for update: %w", err)
 	}
 
+	// Teacher must belong to the caller's tenant
+	if _, err := r.userRepo.GetUserByID(scope, teacherID); err != nil {
+		return fmt.Errorf("failed to retrieve teacher: %w", err)
+	}
+
+	activity, err := r.activityRepo.GetActivityByID(scope, existingRecord.ActivityID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve activity of record: %w", err)
+	}
+
+	settings, err := r.settingsRepo.GetSettingsBySchoolID(activity.SchoolID)
+	if err != nil {
+		return err
+	}
+
+	if deadline := settings.SubmissionDeadline(&activity.Activity); deadline != nil && time.Now().After(*deadline) {
+		return fmt.Errorf("activity is no longer accepting records")
+	}
+
+	if settings.RequireVerifiedEmail {
+		student, err := r.userRepo.GetUserByID(scope, existingRecord.StudentID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve student: %w", err)
+		}
+		if !student.IsEmailVerified() {
+			return fmt.Errorf("email must be verified before sending records")
+		}
+	}
+
+	if err := r.checkTeacherSelection(scope, settings, &activity.Activity, existingRecord.StudentID, teacherID); err != nil {
+		return err
+	}
+
 	existingRecord.Status = "SENDED"
 	existingRecord.TeacherID = &teacherID
 	existingRecord.StatusLogs = append(existingRecord.StatusLogs,
@@ -184,11 +267,38 @@ func (r *RecordService) SendRecord(id, teacherID, userID uint) error {
 			UpdateTime: time.Now(),
 		})
 
-	return r.recordRepo.UpdateRecord(existingRecord)
+	if err := r.recordRepo.UpdateRecord(scope, existingRecord); err != nil {
+		return err
+	}
+
+	// The record is sent already, a failed notification doesn't undo it
+	if err := r.notifier.NotifyRecordSent(existingRecord, &activity.Activity); err != nil {
+		log.Printf("failed to notify teacher %d of record %d: %v", teacherID, existingRecord.ID, err)
+	}
+	return nil
+}
+
+// checkTeacherSelection verifies the teacher can receive the record under the school's teacher selection policy.
+func (r *RecordService) checkTeacherSelection(scope repository.TenantScope, settings *models.SchoolSettings, activity *models.Activity, studentID, teacherID uint) error {
+	switch settings.TeacherSelection {
+	case "ACTIVITY_OWNER":
+		if activity.OwnerID != teacherID {
+			return fmt.Errorf("record can only be sent to the owner of the activity")
+		}
+	case "BOOKMARKED":
+		student, err := r.userRepo.GetUserByID(scope, studentID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve student: %w", err)
+		}
+		if !slices.Contains(student.BookmarkUserIDs, teacherID) {
+			return fmt.Errorf("record can only be sent to a bookmarked teacher")
+		}
+	}
+	return nil
 }
 
-func (r *RecordService) UnsendRecord(id, userID uint) error {
-	existingRecord, err := r.recordRepo.GetRecordByID(id)
+func (r *RecordService) UnsendRecord(scope repository.TenantScope, id, userID uint) error {
+	existingRecord, err := r.recordRepo.GetRecordByID(scope, id)
 	if err != nil {
 		return fmt.Errorf("record not found for update: %w", err)
 	}
@@ -201,11 +311,11 @@ func (r *RecordService) UnsendRecord(id, userID uint) error {
 			UpdateTime: time.Now(),
 		})
 
-	return r.recordRepo.UpdateRecord(existingRecord)
+	return r.recordRepo.UpdateRecord(scope, existingRecord)
 }
 
-func (r *RecordService) ApproveRecord(id uint, advice *string, userID uint) error {
-	existingRecord, err := r.recordRepo.GetRecordByID(id)
+func (r *RecordService) ApproveRecord(scope repository.TenantScope, id uint, advice *string, userID uint) error {
+	existingRecord, err := r.recordRepo.GetRecordByID(scope, id)
 	if err != nil {
 		return fmt.Errorf("record not found for update: %w", err)
 	}
@@ -218,11 +328,18 @@ func (r *RecordService) ApproveRecord(id uint, advice *string, userID uint) erro
 			UpdateTime: time.Now(),
 		})
 
-	return r.recordRepo.UpdateRecord(existingRecord)
+	if err := r.recordRepo.UpdateRecord(scope, existingRecord); err != nil {
+		return err
+	}
+
+	if err := r.notifier.NotifyRecordReviewed(existingRecord, &existingRecord.Activity, userID); err != nil {
+		log.Printf("failed to notify student %d of record %d: %v", existingRecord.StudentID, existingRecord.ID, err)
+	}
+	return nil
 }
 
-func (r *RecordService) RejectRecord(id uint, advice *string, userID uint) error {
-	existingRecord, err := r.recordRepo.GetRecordByID(id)
+func (r *RecordService) RejectRecord(scope repository.TenantScope, id uint, advice *string, userID uint) error {
+	existingRecord, err := r.recordRepo.GetRecordByID(scope, id)
 	if err != nil {
 		return fmt.Errorf("record not found for update: %w", err)
 	}
@@ -235,5 +352,37 @@ func (r *RecordService) RejectRecord(id uint, advice *string, userID uint) error
 			UpdateTime: time.Now(),
 		})
 
-	return r.recordRepo.UpdateRecord(existingRecord)
+	if err := r.recordRepo.UpdateRecord(scope, existingRecord); err != nil {
+		return err
+	}
+
+	if err := r.notifier.NotifyRecordReviewed(existingRecord, &existingRecord.Activity, userID); err != nil {
+		log.Printf("failed to notify student %d of record %d: %v", existingRecord.StudentID, existingRecord.ID, err)
+	}
+	return nil
+}
+
+// AcknowledgeRecord records that a guardian of the student confirmed a record.
+// Only records of activities requiring parental confirmation can be acknowledged.
+func (r *RecordService) AcknowledgeRecord(scope repository.TenantScope, id, guardianID uint) error {
+	existingRecord, err := r.recordRepo.GetRecordByID(scope, id)
+	if err != nil {
+		return fmt.Errorf("record not found for update: %w", err)
+	}
+
+	if !existingRecord.Activity.RequiresGuardianConfirmation {
+		return errors.New("activity does not require guardian confirmation")
+	}
+	if existingRecord.Status == "REJECTED" {
+		return fmt.Errorf("record with status %s cannot be acknowledged", existingRecord.Status)
+	}
+	if existingRecord.GuardianAcknowledgedAt != nil {
+		return errors.New("record is already acknowledged")
+	}
+
+	now := time.Now()
+	existingRecord.GuardianAcknowledgedAt = &now
+	existingRecord.GuardianAcknowledgedByID = &guardianID
+
+	return r.recordRepo.UpdateRecord(scope, existingRecord)
 }
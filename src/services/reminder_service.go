@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"sama/sama-backend-2025/src/config"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+)
+
+// ReminderService sends the periodic reminders run by the scheduler.
+type ReminderService struct {
+	activityService  *ActivityService
+	notifier         *NotificationService
+	schoolRepo       *repository.SchoolRepository
+	userRepo         *repository.UserRepository
+	recordRepo       *repository.RecordRepository
+	notificationRepo *repository.NotificationRepository
+	mailerClient     *pkg.MailerService
+	daysBefore       int            // Students are reminded of unfinished activities due within this many days
+	location         *time.Location // Deadlines are counted in days of this time zone
+}
+
+// NewReminderService creates a new instance of ReminderService.
+func NewReminderService(cfg *config.Config, location *time.Location, activityService *ActivityService, notifier *NotificationService, mailerClient *pkg.MailerService) *ReminderService {
+	return &ReminderService{
+		activityService:  activityService,
+		notifier:         notifier,
+		schoolRepo:       repository.NewSchoolRepository(),
+		userRepo:         repository.NewUserRepository(),
+		recordRepo:       repository.NewRecordRepository(),
+		notificationRepo: repository.NewNotificationRepository(),
+		mailerClient:     mailerClient,
+		daysBefore:       cfg.Scheduler.ReminderDaysBefore,
+		location:         location,
+	}
+}
+
+// SendDeadlineReminders notifies every active student of the activities of the current semester they haven't finished
+// and that are due within the configured number of days. A student is reminded of an activity once,
+// so a run missed by a day still reminds them and a repeated run doesn't remind them again.
+func (s *ReminderService) SendDeadlineReminders(ctx context.Context) error {
+	schools, _, err := s.schoolRepo.GetAllSchools(-1, -1)
+	if err != nil {
+		return err
+	}
+
+	today := s.date(time.Now())
+	reminded := 0
+	var errs []error
+	for _, school := range schools {
+		students, _, err := s.userRepo.GetUsersBySchoolID(repository.SystemScope(), school.ID, 0, 0, "", "STD", "", "ACTIVE", -1, -1)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to retrieve students of school %d: %w", school.ID, err))
+			continue
+		}
+
+		for _, student := range students {
+			if err := ctx.Err(); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+
+			count, err := s.remindStudent(&student, today)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to remind student %d: %w", student.ID, err))
+			}
+			reminded += count
+		}
+	}
+
+	log.Printf("Sent %d deadline reminders", reminded)
+	return errors.Join(errs...)
+}
+
+// remindStudent notifies a student of the unfinished activities due soon they weren't reminded of yet
+// and returns how many there were.
+func (s *ReminderService) remindStudent(student *models.User, today time.Time) (int, error) {
+	activities, err := s.activityService.GetAssignedActivitiesByUserID(repository.SystemScope(), student.ID, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var notifiedActivityIDs []uint
+	reminded := 0
+	for i := range activities {
+		activity := &activities[i]
+		// The deadline is already coalesced with the default deadline of the school by the query
+		if !activity.IsActive || activity.Deadline == nil || activity.FinishedPercentage >= 100 {
+			continue
+		}
+		daysLeft := int(s.date(*activity.Deadline).Sub(today).Hours() / 24)
+		if daysLeft < 0 || daysLeft > s.daysBefore {
+			continue
+		}
+
+		// Only looked up once the student has an activity due
+		if notifiedActivityIDs == nil {
+			notifiedActivityIDs, err = s.notificationRepo.GetNotifiedActivityIDs(student.ID, "DEADLINE_APPROACHING")
+			if err != nil {
+				return reminded, err
+			}
+		}
+		if slices.Contains(notifiedActivityIDs, activity.ID) {
+			continue
+		}
+
+		if err := s.notifier.NotifyDeadlineApproaching(student, activity, daysLeft); err != nil {
+			return reminded, err
+		}
+		reminded++
+	}
+	return reminded, nil
+}
+
+// date returns the midnight starting the day of t in the time zone of the reminders.
+func (s *ReminderService) date(t time.Time) time.Time {
+	t = t.In(s.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// SendPendingRecordDigests emails every active teacher the number of records sent to them that are still waiting for review, by activity.
+func (s *ReminderService) SendPendingRecordDigests(ctx context.Context) error {
+	counts, err := s.recordRepo.GetPendingRecordCounts()
+	if err != nil {
+		return err
+	}
+
+	// Counts are ordered by teacher
+	countsByTeacher := make(map[uint][]models.PendingRecordCount)
+	teacherIDs := make([]uint, 0)
+	for _, count := range counts {
+		if _, ok := countsByTeacher[count.TeacherID]; !ok {
+			teacherIDs = append(teacherIDs, count.TeacherID)
+		}
+		countsByTeacher[count.TeacherID] = append(countsByTeacher[count.TeacherID], count)
+	}
+
+	sent := 0
+	var errs []error
+	for _, teacherID := range teacherIDs {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		teacher, err := s.userRepo.GetUserByID(repository.SystemScope(), teacherID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to retrieve teacher %d: %w", teacherID, err))
+			continue
+		}
+		if !teacher.IsActive() {
+			continue
+		}
+
+		total := 0
+		activities := make([]map[string]any, 0, len(countsByTeacher[teacherID]))
+		for _, count := range countsByTeacher[teacherID] {
+			total += count.Count
+			activities = append(activities, map[string]any{"Name": count.ActivityName, "Count": count.Count})
+		}
+
+		err = s.mailerClient.Send(ctx, "pending_records_digest", teacher.Language, map[string]any{
+			"Name":       teacher.Firstname + " " + teacher.Lastname,
+			"Total":      total,
+			"Activities": activities,
+		}, teacher.Email)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+
+	log.Printf("Sent %d pending record digests", sent)
+	return errors.Join(errs...)
+}
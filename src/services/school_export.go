@@ -0,0 +1,158 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// uploadedObjectKeyPattern matches the object keys generated by ImageService ("user_id/uuid.extension").
+var uploadedObjectKeyPattern = regexp.MustCompile(`^\d+/[0-9a-fA-F-]{36}\.[a-zA-Z0-9]+$`)
+
+// collectAttachments builds the manifest of every bucket object referenced by the exported data.
+func collectAttachments(export *models.SchoolExport) []models.AttachmentObject {
+	var attachments []models.AttachmentObject
+
+	if export.School.SchoolLogoUrl != nil && *export.School.SchoolLogoUrl != "" {
+		attachments = append(attachments, models.AttachmentObject{OwnerType: "SCHOOL", OwnerID: export.School.ID, ObjectKey: *export.School.SchoolLogoUrl})
+	}
+
+	for _, user := range export.Users {
+		if user.ProfilePictureURL != nil && *user.ProfilePictureURL != "" {
+			attachments = append(attachments, models.AttachmentObject{OwnerType: "USER", OwnerID: user.ID, ObjectKey: *user.ProfilePictureURL})
+		}
+	}
+
+	for _, activity := range export.Activities {
+		if activity.CoverImageUrl != nil && *activity.CoverImageUrl != "" {
+			attachments = append(attachments, models.AttachmentObject{OwnerType: "ACTIVITY", OwnerID: activity.ID, ObjectKey: *activity.CoverImageUrl})
+		}
+	}
+
+	for _, record := range export.Records {
+		// Sort keys so the manifest is stable between exports
+		keys := make([]string, 0, len(record.Data))
+		for key := range record.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value, ok := record.Data[key].(string)
+			if ok && uploadedObjectKeyPattern.MatchString(value) {
+				attachments = append(attachments, models.AttachmentObject{OwnerType: "RECORD", OwnerID: record.ID, ObjectKey: value})
+			}
+		}
+	}
+
+	return attachments
+}
+
+// buildSchoolExportWorkbook writes the exported data as an excel workbook with one sheet per table.
+func buildSchoolExportWorkbook(export *models.SchoolExport) ([]byte, error) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	userRows := [][]interface{}{{"ID", "Role", "Student ID", "Email", "Firstname", "Lastname", "Phone", "Classroom", "Number", "Language", "Created At"}}
+	for _, user := range export.Users {
+		userRows = append(userRows, []interface{}{
+			user.ID, user.Role, derefString(user.StudentUniqueID), user.Email, user.Firstname, user.Lastname,
+			user.Phone, derefString(user.Classroom), derefUint(user.Number), user.Language, user.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	activityRows := [][]interface{}{{"ID", "Name", "Owner ID", "Required", "Junior", "Senior", "Exclusive Classrooms", "Exclusive Students", "Finished Unit", "Finished Amount", "Semester", "School Year", "Deadline"}}
+	for _, activity := range export.Activities {
+		deadline := ""
+		if activity.Deadline != nil {
+			deadline = activity.Deadline.Format(time.RFC3339)
+		}
+		studentIDs := make([]string, len(activity.ExclusiveStudentIDs))
+		for i, id := range activity.ExclusiveStudentIDs {
+			studentIDs[i] = fmt.Sprint(id)
+		}
+		activityRows = append(activityRows, []interface{}{
+			activity.ID, activity.Name, activity.OwnerID, activity.IsRequired, activity.IsForJunior, activity.IsForSenior,
+			strings.Join(activity.ExclusiveClassrooms, ", "), strings.Join(studentIDs, ", "),
+			activity.FinishedUnit, activity.FinishedAmount, activity.Semester, activity.SchoolYear, deadline,
+		})
+	}
+
+	recordRows := [][]interface{}{{"ID", "Activity ID", "Student ID", "Teacher ID", "Amount", "Status", "Advise", "Created At"}}
+	for _, record := range export.Records {
+		recordRows = append(recordRows, []interface{}{
+			record.ID, record.ActivityID, record.StudentID, derefUint(record.TeacherID), record.Amount, record.Status,
+			derefString(record.Advise), record.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	attachmentRows := [][]interface{}{{"Owner Type", "Owner ID", "Object Key"}}
+	for _, attachment := range export.Attachments {
+		attachmentRows = append(attachmentRows, []interface{}{attachment.OwnerType, attachment.OwnerID, attachment.ObjectKey})
+	}
+
+	sheets := []struct {
+		name string
+		rows [][]interface{}
+	}{
+		{"Users", userRows},
+		{"Activities", activityRows},
+		{"Records", recordRows},
+		{"Attachments", attachmentRows},
+	}
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			// Rename the default sheet instead of leaving an empty one behind
+			if err := file.SetSheetName("Sheet1", sheet.name); err != nil {
+				return nil, fmt.Errorf("failed to create sheet %s: %w", sheet.name, err)
+			}
+		} else if _, err := file.NewSheet(sheet.name); err != nil {
+			return nil, fmt.Errorf("failed to create sheet %s: %w", sheet.name, err)
+		}
+
+		if err := writeSheetRows(file, sheet.name, sheet.rows); err != nil {
+			return nil, err
+		}
+	}
+
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// writeSheetRows writes rows starting at A1 of the given sheet.
+func writeSheetRows(file *excelize.File, sheet string, rows [][]interface{}) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return fmt.Errorf("failed to locate cell of row %d: %w", i+1, err)
+		}
+		if err := file.SetSheetRow(sheet, cell, &row); err != nil {
+			return fmt.Errorf("failed to write row %d of sheet %s: %w", i+1, sheet, err)
+		}
+	}
+	return nil
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func derefUint(value *uint) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(*value)
+}
@@ -2,38 +2,48 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 
+	"sama/sama-backend-2025/src/config"
 	"sama/sama-backend-2025/src/models"
 	"sama/sama-backend-2025/src/pkg"
 	"sama/sama-backend-2025/src/repository"
 	"sama/sama-backend-2025/src/utils"
 
-	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 // SchoolService handles business logic for schools.
 type SchoolService struct {
-	schoolRepo   *repository.SchoolRepository
-	userRepo     *repository.UserRepository
-	activityRepo *repository.ActivityRepository
-	s3Client     *pkg.S3Client
-	validator    *validator.Validate
+	schoolRepo       *repository.SchoolRepository
+	userRepo         *repository.UserRepository
+	activityRepo     *repository.ActivityRepository
+	archiveRepo      *repository.SchoolArchiveRepository
+	settingsRepo     *repository.SchoolSettingsRepository
+	blobStore        pkg.BlobStore
+	validator        *validator.Validate
+	archiveRetention time.Duration // How long an archived school can be restored
 }
 
 // NewSchoolService creates a new instance of SchoolService.
-func NewSchoolService(s3Client *pkg.S3Client, validate *validator.Validate) *SchoolService {
+func NewSchoolService(cfg *config.Config, blobStore pkg.BlobStore, validate *validator.Validate) *SchoolService {
 	return &SchoolService{
-		schoolRepo:   repository.NewSchoolRepository(),
-		userRepo:     repository.NewUserRepository(),
-		activityRepo: repository.NewActivityRepository(),
-		s3Client:     s3Client,
-		validator:    validate,
+		schoolRepo:       repository.NewSchoolRepository(),
+		userRepo:         repository.NewUserRepository(),
+		activityRepo:     repository.NewActivityRepository(),
+		archiveRepo:      repository.NewSchoolArchiveRepository(),
+		settingsRepo:     repository.NewSchoolSettingsRepository(),
+		blobStore:        blobStore,
+		validator:        validate,
+		archiveRetention: time.Duration(cfg.School.ArchiveRetentionDays) * 24 * time.Hour,
 	}
 }
 
@@ -138,39 +148,113 @@ func (s *SchoolService) UpdateSchool(school *models.School) error {
 	return s.schoolRepo.UpdateSchool(school)
 }
 
-// // UpdateSchool updates an existing school's information.
-func (s *SchoolService) GetSchoolStatisticByID(id uint, classroom string, activityIDs []uint, semester, schoolYear uint) ([]models.UserWithFinishedPercent, int, int, error) {
+// GetSchoolStatisticByID computes the completion of every student of a school over the given activities.
+func (s *SchoolService) GetSchoolStatisticByID(scope repository.TenantScope, id, groupID uint, classroom string, activityIDs []uint, semester, schoolYear uint) ([]models.UserWithFinishedPercent, int, int, error) {
+	statistics, _, err := s.getStudentStatistics(scope, id, groupID, classroom, activityIDs, semester, schoolYear)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var finishedAmount int
+	usersWithStat := make([]models.UserWithFinishedPercent, len(statistics))
+	for i, statistic := range statistics {
+		usersWithStat[i] = statistic.UserWithFinishedPercent
+		if statistic.Finished {
+			finishedAmount++
+		}
+	}
+
+	return usersWithStat, finishedAmount, len(usersWithStat) - finishedAmount, nil
+}
+
+// GetSchoolStatisticFileByID generates the statistic workbook of a school and returns a download URL.
+// The workbook has a summary sheet and one sheet per classroom, honoring the same filters as GetSchoolStatisticByID.
+func (s *SchoolService) GetSchoolStatisticFileByID(ctx context.Context, scope repository.TenantScope, id, groupID uint, classroom string, activityIDs []uint, semester, schoolYear uint) (*pkg.PresignedRequest, error) {
+
+	statistics, school, err := s.getStudentStatistics(scope, id, groupID, classroom, activityIDs, semester, schoolYear)
+	if err != nil {
+		return nil, err
+	}
+
+	// if either semester of school year is invalid, the statistic is of the current semester and year
+	if semester == 0 || schoolYear == 0 {
+		semester = school.Semester
+		schoolYear = school.SchoolYear
+	}
+
+	file, err := buildSchoolStatisticWorkbook(statistics, activityIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate statistic workbook: %w", err)
+	}
+
+	// Every request gets its own file, so concurrent exports never overwrite each other
+	filepath := fmt.Sprintf("statistics/%s/%d_%d/%s_summary_%s.xlsx", school.ShortName, schoolYear, semester, school.ShortName, uuid.New().String())
+
+	if err := s.blobStore.PutObject(ctx, filepath, file, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"); err != nil {
+		return nil, fmt.Errorf("failed to upload statistic workbook: %w", err)
+	}
+
+	request, err := s.blobStore.PresignDownload(ctx, filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get presigned download URL from storage: %w", err)
+	}
+
+	return request, nil
+}
+
+// studentStatistic is the completion of a student over the filtered activities.
+type studentStatistic struct {
+	models.UserWithFinishedPercent
+	Activities []models.ActivityWithStatistic // Filtered activities, sorted by id ascending
+	Finished   bool                           // Completion reached the threshold of the school
+}
+
+// getStudentStatistics computes the completion of every student of a school over the given activities.
+// Students without any of the activities are left out, and only members of the student group are counted when one is given.
+func (s *SchoolService) getStudentStatistics(scope repository.TenantScope, id, groupID uint, classroom string, activityIDs []uint, semester, schoolYear uint) ([]studentStatistic, *models.School, error) {
+
+	if !scope.CanAccessSchool(id) {
+		return nil, nil, fmt.Errorf("school with ID %d not found", id)
+	}
+
+	school, err := s.schoolRepo.GetSchoolByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// if either semester of school year is invalid, get current semester and year
 	if semester == 0 || schoolYear == 0 {
-		var err error
-		semester, schoolYear, err = s.schoolRepo.GetSchoolSemesterAndSchoolYearByID(id)
-		if err != nil {
-			return nil, 0, 0, err
-		}
+		semester = school.Semester
+		schoolYear = school.SchoolYear
+	}
+
+	settings, err := s.settingsRepo.GetSettingsBySchoolID(id)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// -1 on offset and limit to cancle pagination
-	users, _, err := s.userRepo.GetUsersBySchoolID(id, 0, "", "STD", classroom, -1, -1)
+	// -1 on offset and limit to cancle pagination, students who left the school are not counted
+	users, _, err := s.userRepo.GetUsersBySchoolID(scope, id, 0, groupID, "", "STD", classroom, "ACTIVE", -1, -1)
 	if err != nil {
-		return nil, 0, 0, fmt.Errorf("failed to get users: %w", err)
+		return nil, nil, fmt.Errorf("failed to get users: %w", err)
 	}
 
-	var fisnishedAmount int
+	// The filter below walks the activities and the requested IDs side by side, both sorted by id ascending
+	activityIDs = slices.Clone(activityIDs)
+	slices.Sort(activityIDs)
 
-	// New array to store user with their stats and filter out who doesn't belong
-	var userWithStatPos int
-	usersWithStat := make([]models.UserWithFinishedPercent, len(users))
+	statistics := make([]studentStatistic, 0, len(users))
 
 	for _, user := range users {
 		// activity will sorted by it's id assending
 		activities, err := s.activityRepo.GetAssignedActivitiesByUserID(user.ID, id, semester, schoolYear, false)
 		if err != nil {
-			return nil, 0, 0, fmt.Errorf("failed to retrieve statistic of user with id %d: %w", user.ID, err)
+			return nil, nil, fmt.Errorf("failed to retrieve statistic of user with id %d: %w", user.ID, err)
 		}
 
 		var pos int
-		var sum, filterCount float32
+		var sum float32
+		var filteredActivities []models.ActivityWithStatistic
 
 		// since activityIDs and activity is sorted by id ascending
 		// the filter algorithm apply here will be O(1)
@@ -188,55 +272,171 @@ func (s *SchoolService) GetSchoolStatisticByID(id uint, classroom string, activi
 
 			// If the activityIDs existed in the filter, apply summation
 			if activityIDs[pos] == activity.ID {
+				activity.FinishedPercentage = utils.NormallizePercent(activity.FinishedPercentage)
 				sum += activity.FinishedPercentage
-				filterCount += 1
+				filteredActivities = append(filteredActivities, activity)
 			}
 		}
 
 		// Only apply this user if at least one activity is presented
-		if filterCount > 0 {
-			usersWithStat[userWithStatPos].User = user
-			usersWithStat[userWithStatPos].FinishedPercent = utils.NormallizePercent(sum / filterCount)
-			if usersWithStat[userWithStatPos].FinishedPercent == 100 {
-				fisnishedAmount++
-			}
-
-			userWithStatPos++
+		if len(filteredActivities) > 0 {
+			finishedPercent := utils.NormallizePercent(sum / float32(len(filteredActivities)))
+			statistics = append(statistics, studentStatistic{
+				UserWithFinishedPercent: models.UserWithFinishedPercent{
+					User:            user,
+					FinishedPercent: finishedPercent,
+				},
+				Activities: filteredActivities,
+				Finished:   finishedPercent >= float32(settings.CompletionThreshold),
+			})
 		}
 	}
 
-	return usersWithStat[:userWithStatPos], fisnishedAmount, userWithStatPos - fisnishedAmount, nil
+	return statistics, school, nil
 }
 
-// GetSchoolByShortName retrieves a school by its short name.
-func (s *SchoolService) GetSchoolStatisticFileByID(ctx context.Context, id uint, classroom string, activityIDs []uint, semester, schoolYear uint) (*v4.PresignedHTTPRequest, error) {
+// GetSchoolSettings retrieves the settings of a school.
+func (s *SchoolService) GetSchoolSettings(scope repository.TenantScope, id uint) (*models.SchoolSettings, error) {
+	if !scope.CanAccessSchool(id) {
+		return nil, fmt.Errorf("school with ID %d not found", id)
+	}
 
+	if _, err := s.schoolRepo.GetSchoolByID(id); err != nil {
+		return nil, err
+	}
+
+	return s.settingsRepo.GetSettingsBySchoolID(id)
+}
+
+// UpdateSchoolSettings validates and saves the settings of a school.
+func (s *SchoolService) UpdateSchoolSettings(scope repository.TenantScope, settings *models.SchoolSettings) error {
+	if !scope.CanAccessSchool(settings.SchoolID) {
+		return fmt.Errorf("school with ID %d not found", settings.SchoolID)
+	}
+
+	if _, err := s.schoolRepo.GetSchoolByID(settings.SchoolID); err != nil {
+		return err
+	}
+
+	if err := s.validator.Struct(settings); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	existingSettings, err := s.settingsRepo.GetSettingsBySchoolID(settings.SchoolID)
+	if err != nil {
+		return err
+	}
+	settings.ID = existingSettings.ID
+	settings.CreatedAt = existingSettings.CreatedAt
+
+	return s.settingsRepo.SaveSettings(settings)
+}
+
+// DeleteSchool offboards a school by its ID.
+// A full export (JSON and XLSX) is uploaded first, then the school and everything under it is archived.
+// The school can be restored with RestoreSchool until the retention window ends.
+func (s *SchoolService) DeleteSchool(ctx context.Context, id uint, archivedByID uint) (*models.SchoolArchive, error) {
 	school, err := s.schoolRepo.GetSchoolByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve school with id %d: %w", id, err)
+		return nil, err
 	}
 
-	// if either semester of school year is invalid, get current semester and year
-	if semester == 0 || schoolYear == 0 {
-		semester = school.Semester
-		schoolYear = school.SchoolYear
+	users, activities, records, err := s.archiveRepo.GetSchoolExportData(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve school data for export: %w", err)
 	}
 
-	filepath := school.ShortName + "_summary.xlsx"
+	// Postgres keeps microseconds, truncate so the timestamp can be matched again on restore
+	archivedAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	export := &models.SchoolExport{
+		ExportedAt: archivedAt,
+		School:     *school,
+		Users:      users,
+		Activities: activities,
+		Records:    records,
+	}
+	export.Attachments = collectAttachments(export)
 
-	// TODO: generate excel file to filepath
+	jsonExport, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode school export: %w", err)
+	}
 
-	request, err := s.s3Client.GetPresignedDownloadURL(ctx, filepath)
+	xlsxExport, err := buildSchoolExportWorkbook(export)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get presigned download URL from S3 client: %w", err)
+		return nil, fmt.Errorf("failed to generate school export workbook: %w", err)
 	}
 
-	return request, nil
+	prefix := fmt.Sprintf("archives/%s/%s", school.ShortName, archivedAt.Format("20060102T150405Z"))
+	archive := &models.SchoolArchive{
+		SchoolID:        id,
+		ArchivedByID:    archivedByID,
+		JSONExportKey:   prefix + "/export.json",
+		XLSXExportKey:   prefix + "/export.xlsx",
+		TotalUsers:      len(users),
+		TotalActivities: len(activities),
+		TotalRecords:    len(records),
+		ArchivedAt:      archivedAt,
+		RestoreDeadline: archivedAt.Add(s.archiveRetention),
+	}
+
+	// Export must be stored before anything is archived
+	if err := s.blobStore.PutObject(ctx, archive.JSONExportKey, jsonExport, "application/json"); err != nil {
+		return nil, fmt.Errorf("failed to upload school export: %w", err)
+	}
+	if err := s.blobStore.PutObject(ctx, archive.XLSXExportKey, xlsxExport, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"); err != nil {
+		return nil, fmt.Errorf("failed to upload school export workbook: %w", err)
+	}
+
+	if err := s.archiveRepo.ArchiveSchool(archive); err != nil {
+		return nil, err
+	}
+
+	return archive, nil
 }
 
-// DeleteSchool deletes a school by its ID.
-func (s *SchoolService) DeleteSchool(id uint) error {
-	return s.schoolRepo.DeleteSchool(id)
+// GetSchoolArchive retrieves the latest archive of a school with download URLs of its export.
+func (s *SchoolService) GetSchoolArchive(ctx context.Context, id uint) (*models.SchoolArchive, *pkg.PresignedRequest, *pkg.PresignedRequest, error) {
+	archive, err := s.archiveRepo.GetLatestArchiveBySchoolID(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	jsonRequest, err := s.blobStore.PresignDownload(ctx, archive.JSONExportKey)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get presigned download URL from storage: %w", err)
+	}
+
+	xlsxRequest, err := s.blobStore.PresignDownload(ctx, archive.XLSXExportKey)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get presigned download URL from storage: %w", err)
+	}
+
+	return archive, jsonRequest, xlsxRequest, nil
+}
+
+// RestoreSchool restores an archived school and everything archived with it.
+func (s *SchoolService) RestoreSchool(id uint) (*models.SchoolArchive, error) {
+	archive, err := s.archiveRepo.GetLatestArchiveBySchoolID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if archive.RestoredAt != nil {
+		return nil, errors.New("school is not archived")
+	}
+
+	now := time.Now().UTC()
+	if now.After(archive.RestoreDeadline) {
+		return nil, errors.New("retention window of this archive has ended")
+	}
+
+	if err := s.archiveRepo.RestoreSchool(archive, now); err != nil {
+		return nil, err
+	}
+
+	return archive, nil
 }
 
 // CountSchools returns the total number of schools.
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// buildSchoolStatisticWorkbook writes a summary sheet and one sheet per classroom with a row per student.
+func buildSchoolStatisticWorkbook(statistics []studentStatistic, activityIDs []uint) ([]byte, error) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	// Only activities assigned to at least one student get a column, in the order of the filter
+	activityNames := make(map[uint]string)
+	for _, statistic := range statistics {
+		for _, activity := range statistic.Activities {
+			activityNames[activity.ID] = activity.Name
+		}
+	}
+	var columns []uint
+	for _, id := range activityIDs {
+		if _, ok := activityNames[id]; ok {
+			columns = append(columns, id)
+		}
+	}
+
+	// Group students by classroom, sorted by their number
+	classrooms := make(map[string][]studentStatistic)
+	var finishedAmount int
+	for _, statistic := range statistics {
+		classroom := derefString(statistic.Classroom)
+		classrooms[classroom] = append(classrooms[classroom], statistic)
+		if statistic.Finished {
+			finishedAmount++
+		}
+	}
+	classroomNames := make([]string, 0, len(classrooms))
+	for classroom, students := range classrooms {
+		classroomNames = append(classroomNames, classroom)
+		sort.SliceStable(students, func(i, j int) bool {
+			return derefNumber(students[i].Number) < derefNumber(students[j].Number)
+		})
+	}
+	sort.Strings(classroomNames)
+
+	summaryRows := [][]interface{}{
+		{"Total Students", len(statistics)},
+		{"Total Finished", finishedAmount},
+		{"Total Unfinished", len(statistics) - finishedAmount},
+		{},
+		{"Classroom", "Number", "Student ID", "Firstname", "Lastname", "Finished Percent", "Finished"},
+	}
+	for _, classroom := range classroomNames {
+		for _, statistic := range classrooms[classroom] {
+			summaryRows = append(summaryRows, []interface{}{
+				classroom, derefUint(statistic.Number), derefString(statistic.StudentUniqueID),
+				statistic.Firstname, statistic.Lastname, statistic.FinishedPercent, statistic.Finished,
+			})
+		}
+	}
+
+	if err := file.SetSheetName("Sheet1", "Summary"); err != nil {
+		return nil, fmt.Errorf("failed to create sheet Summary: %w", err)
+	}
+	if err := writeSheetRows(file, "Summary", summaryRows); err != nil {
+		return nil, err
+	}
+
+	header := []interface{}{"Number", "Student ID", "Firstname", "Lastname"}
+	for _, id := range columns {
+		header = append(header, activityNames[id]+" (Approved)", activityNames[id]+" (%)")
+	}
+	header = append(header, "Finished Percent")
+
+	for _, classroom := range classroomNames {
+		rows := [][]interface{}{header}
+		for _, statistic := range classrooms[classroom] {
+			activities := make(map[uint]models.ActivityWithStatistic, len(statistic.Activities))
+			for _, activity := range statistic.Activities {
+				activities[activity.ID] = activity
+			}
+
+			row := []interface{}{derefUint(statistic.Number), derefString(statistic.StudentUniqueID), statistic.Firstname, statistic.Lastname}
+			for _, id := range columns {
+				if activity, ok := activities[id]; ok {
+					row = append(row, activity.TotalApprovedRecords, activity.FinishedPercentage)
+				} else {
+					row = append(row, "", "")
+				}
+			}
+			row = append(row, statistic.FinishedPercent)
+			rows = append(rows, row)
+		}
+
+		sheet := classroomSheetName(classroom)
+		if _, err := file.NewSheet(sheet); err != nil {
+			return nil, fmt.Errorf("failed to create sheet %s: %w", sheet, err)
+		}
+		if err := writeSheetRows(file, sheet, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// classroomSheetName converts a classroom into a valid sheet name, since "/" is not allowed in sheet names.
+func classroomSheetName(classroom string) string {
+	if classroom == "" {
+		return "No Classroom"
+	}
+	return "Classroom " + strings.ReplaceAll(classroom, "/", "-")
+}
+
+func derefNumber(value *uint) uint {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"time"
+
+	"sama/sama-backend-2025/src/config"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/repository"
+)
+
+// SessionService handles business logic for login sessions.
+type SessionService struct {
+	sessionRepo       *repository.SessionRepository
+	refreshTokenRepo  *repository.RefreshTokenRepository
+	userRepo          *repository.UserRepository
+	refreshJwtExpMins int // A session unused for longer than this can no longer be refreshed
+}
+
+// NewSessionService creates a new instance of SessionService.
+func NewSessionService(cfg *config.Config) *SessionService {
+	return &SessionService{
+		sessionRepo:       repository.NewSessionRepository(),
+		refreshTokenRepo:  repository.NewRefreshTokenRepository(),
+		userRepo:          repository.NewUserRepository(),
+		refreshJwtExpMins: cfg.RefreshJWT.Expiry,
+	}
+}
+
+// GetSessions retrieves the active sessions of a user within the tenant scope.
+func (s *SessionService) GetSessions(scope repository.TenantScope, userID uint) ([]models.Session, error) {
+	// Make sure the user is visible in the scope
+	if _, err := s.userRepo.GetUserByID(scope, userID); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-time.Duration(s.refreshJwtExpMins) * time.Minute)
+	return s.sessionRepo.GetActiveSessionsByUserID(userID, since)
+}
+
+// RevokeSession ends a session of a user by revoking its refresh token family.
+func (s *SessionService) RevokeSession(userID, sessionID uint) error {
+	session, err := s.sessionRepo.GetSessionByID(userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(session.FamilyID)
+}
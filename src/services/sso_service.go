@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sama/sama-backend-2025/src/config"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+	"sama/sama-backend-2025/src/utils"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ssoStateLifetime is how long a user has to sign in at the identity provider.
+const ssoStateLifetime = 10 * time.Minute
+
+// ssoSecretBytes is the amount of random bytes in the state, nonce and PKCE verifier of a sign-in.
+const ssoSecretBytes = 32
+
+// SSOService handles the identity providers of schools and signing in with them.
+type SSOService struct {
+	providerRepo   *repository.IdentityProviderRepository
+	userRepo       *repository.UserRepository
+	schoolRepo     *repository.SchoolRepository
+	settingsRepo   *repository.SchoolSettingsRepository
+	authService    *AuthService
+	oidcClient     *pkg.OIDCClient
+	passwordHasher pkg.PasswordHasher
+	passwordPolicy *pkg.PasswordPolicy
+	validator      *validator.Validate
+	redirectURL    string // Page of the frontend the identity providers send users back to
+}
+
+// NewSSOService creates a new instance of SSOService.
+func NewSSOService(
+	cfg *config.Config,
+	authService *AuthService,
+	oidcClient *pkg.OIDCClient,
+	passwordHasher pkg.PasswordHasher,
+	passwordPolicy *pkg.PasswordPolicy,
+	validate *validator.Validate,
+) *SSOService {
+	return &SSOService{
+		providerRepo:   repository.NewIdentityProviderRepository(),
+		userRepo:       repository.NewUserRepository(),
+		schoolRepo:     repository.NewSchoolRepository(),
+		settingsRepo:   repository.NewSchoolSettingsRepository(),
+		authService:    authService,
+		oidcClient:     oidcClient,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		validator:      validate,
+		redirectURL:    cfg.SSO.RedirectURL,
+	}
+}
+
+// GetIdentityProviders retrieves the identity providers of a school within the tenant scope.
+func (s *SSOService) GetIdentityProviders(scope repository.TenantScope, schoolID uint) ([]models.IdentityProvider, error) {
+	if !scope.CanAccessSchool(schoolID) {
+		return nil, fmt.Errorf("school with ID %d not found", schoolID)
+	}
+	if _, err := s.schoolRepo.GetSchoolByID(schoolID); err != nil {
+		return nil, err
+	}
+	return s.providerRepo.GetIdentityProvidersBySchoolID(schoolID)
+}
+
+// GetIdentityProviderByID retrieves an identity provider within the tenant scope.
+func (s *SSOService) GetIdentityProviderByID(scope repository.TenantScope, id uint) (*models.IdentityProvider, error) {
+	provider, err := s.providerRepo.GetIdentityProviderByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.CanAccessSchool(provider.SchoolID) {
+		return nil, fmt.Errorf("identity provider with ID %d not found", id)
+	}
+	return provider, nil
+}
+
+// CreateIdentityProvider validates and creates an identity provider for a school.
+func (s *SSOService) CreateIdentityProvider(scope repository.TenantScope, provider *models.IdentityProvider) error {
+	if !scope.CanAccessSchool(provider.SchoolID) {
+		return fmt.Errorf("school with ID %d not found", provider.SchoolID)
+	}
+	if _, err := s.schoolRepo.GetSchoolByID(provider.SchoolID); err != nil {
+		return err
+	}
+
+	provider.IssuerURL = strings.TrimSuffix(provider.IssuerURL, "/")
+	if err := s.validator.Struct(provider); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return s.providerRepo.CreateIdentityProvider(provider)
+}
+
+// UpdateIdentityProvider validates and saves an identity provider.
+func (s *SSOService) UpdateIdentityProvider(scope repository.TenantScope, provider *models.IdentityProvider) error {
+	if !scope.CanAccessSchool(provider.SchoolID) {
+		return fmt.Errorf("identity provider with ID %d not found", provider.ID)
+	}
+
+	provider.IssuerURL = strings.TrimSuffix(provider.IssuerURL, "/")
+	if err := s.validator.Struct(provider); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return s.providerRepo.UpdateIdentityProvider(provider)
+}
+
+// DeleteIdentityProvider deletes an identity provider within the tenant scope.
+func (s *SSOService) DeleteIdentityProvider(scope repository.TenantScope, id uint) error {
+	if _, err := s.GetIdentityProviderByID(scope, id); err != nil {
+		return err
+	}
+	return s.providerRepo.DeleteIdentityProvider(id)
+}
+
+// FindIdentityProviders returns the enabled identity providers configured for the domain of an email,
+// so the sign-in page can offer them once the user typed their email.
+func (s *SSOService) FindIdentityProviders(email string) ([]models.IdentityProvider, error) {
+	providers, err := s.providerRepo.GetEnabledIdentityProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers accepting any domain are not offered, they would show up for every email
+	matching := []models.IdentityProvider{}
+	for _, provider := range providers {
+		if len(provider.AllowedDomains) > 0 && provider.IsDomainAllowed(email) {
+			matching = append(matching, provider)
+		}
+	}
+	return matching, nil
+}
+
+// StartLogin starts a sign-in at an identity provider and returns the URL the user is sent to.
+func (s *SSOService) StartLogin(providerID uint) (string, error) {
+	if s.redirectURL == "" {
+		return "", errors.New("single sign-on is not configured")
+	}
+
+	provider, err := s.providerRepo.GetIdentityProviderByID(providerID)
+	if err != nil {
+		return "", err
+	}
+	if !provider.Enabled {
+		return "", fmt.Errorf("identity provider with ID %d not found", providerID)
+	}
+
+	secrets := make([]string, 3)
+	for i := range secrets {
+		if secrets[i], err = utils.GenerateOpaqueToken(ssoSecretBytes); err != nil {
+			return "", fmt.Errorf("failed to generate sso state: %w", err)
+		}
+	}
+	state, nonce, codeVerifier := secrets[0], secrets[1], secrets[2]
+
+	err = s.providerRepo.CreateLoginState(&models.SSOLoginState{
+		StateHash:    utils.HashOpaqueToken(state),
+		ProviderID:   provider.ID,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(ssoStateLifetime),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return s.oidcClient.AuthorizationURL(context.TODO(), s.oidcProvider(provider), state, nonce, codeVerifier)
+}
+
+// FinishLogin completes a sign-in with the code returned by the identity provider, and issues the same
+// access and refresh token pair as a password login. The email of the identity maps to an existing user of
+// the provider's school; unknown users are created with the default role of the provider when it allows it.
+func (s *SSOService) FinishLogin(state, code string, session *models.Session) (string, string, error) {
+	loginState, err := s.providerRepo.ConsumeLoginState(utils.HashOpaqueToken(state))
+	if err != nil {
+		return "", "", err
+	}
+
+	provider, err := s.providerRepo.GetIdentityProviderByID(loginState.ProviderID)
+	if err != nil || !provider.Enabled {
+		return "", "", errors.New("invalid or expired sso state")
+	}
+
+	identity, err := s.oidcClient.Exchange(context.TODO(), s.oidcProvider(provider), code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		return "", "", fmt.Errorf("sign-in at the identity provider failed: %w", err)
+	}
+
+	if identity.Email == "" {
+		return "", "", errors.New("identity provider did not share the email")
+	}
+	if !provider.IsDomainAllowed(identity.Email) {
+		return "", "", errors.New("email domain is not allowed by the identity provider")
+	}
+	// Without the email_verified claim, the email is only trusted when the provider is restricted to domains of the school
+	if identity.EmailVerified != nil && !*identity.EmailVerified ||
+		identity.EmailVerified == nil && len(provider.AllowedDomains) == 0 {
+		return "", "", errors.New("email is not verified by the identity provider")
+	}
+
+	user, err := s.userRepo.GetUserByEmail(identity.Email)
+	if err != nil {
+		if err.Error() != fmt.Sprintf("user with email %s not found", identity.Email) {
+			return "", "", fmt.Errorf("failed to retrieve user for login: %w", err)
+		}
+		if !provider.JITProvisioning {
+			return "", "", errors.New("no account exists for this email")
+		}
+		if user, err = s.provisionUser(provider, identity); err != nil {
+			return "", "", err
+		}
+	}
+
+	if user.SchoolID != provider.SchoolID || user.Role == "SAMA" {
+		return "", "", errors.New("account cannot sign in with this identity provider")
+	}
+	if user.IsSuspended() {
+		return "", "", errors.New("user account is deactivated")
+	}
+
+	return s.authService.OpenSession(user, session)
+}
+
+// provisionUser creates the user of an identity signing in for the first time.
+// The password is random and never shared, the user can set one with the password reset.
+func (s *SSOService) provisionUser(provider *models.IdentityProvider, identity *pkg.OIDCIdentity) (*models.User, error) {
+	settings, err := s.settingsRepo.GetSettingsBySchoolID(provider.SchoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := s.passwordPolicy.GeneratePassword(initialPasswordLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	firstname := identity.GivenName
+	if firstname == "" {
+		firstname = strings.Split(identity.Email, "@")[0]
+	}
+
+	now := time.Now()
+	defaultPictureURL := ""
+	user := &models.User{
+		Role:              provider.DefaultRole,
+		Email:             identity.Email,
+		Password:          hashedPassword,
+		Firstname:         firstname,
+		Lastname:          identity.FamilyName,
+		ProfilePictureURL: &defaultPictureURL,
+		Language:          settings.DefaultLanguage,
+		SchoolID:          provider.SchoolID,
+		EmailVerifiedAt:   &now,
+	}
+	if err := s.userRepo.CreateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// oidcProvider returns the client configuration of an identity provider.
+func (s *SSOService) oidcProvider(provider *models.IdentityProvider) pkg.OIDCProvider {
+	return pkg.OIDCProvider{
+		IssuerURL:    provider.IssuerURL,
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  s.redirectURL,
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// StudentGroupService handles business logic for student groups.
+type StudentGroupService struct {
+	groupRepo  *repository.StudentGroupRepository
+	schoolRepo *repository.SchoolRepository
+	validator  *validator.Validate
+}
+
+// NewStudentGroupService creates a new instance of StudentGroupService.
+func NewStudentGroupService(validate *validator.Validate) *StudentGroupService {
+	return &StudentGroupService{
+		groupRepo:  repository.NewStudentGroupRepository(),
+		schoolRepo: repository.NewSchoolRepository(),
+		validator:  validate,
+	}
+}
+
+// GetStudentGroups retrieves the student groups of a school within the tenant scope.
+// When visibleTo is set, only the groups owned by that user or shared with the school are returned.
+func (s *StudentGroupService) GetStudentGroups(scope repository.TenantScope, schoolID, visibleTo uint) ([]models.StudentGroup, error) {
+	if !scope.CanAccessSchool(schoolID) {
+		return nil, fmt.Errorf("school with ID %d not found", schoolID)
+	}
+	if _, err := s.schoolRepo.GetSchoolByID(schoolID); err != nil {
+		return nil, err
+	}
+	return s.groupRepo.GetStudentGroupsBySchoolID(schoolID, visibleTo)
+}
+
+// GetStudentGroupByID retrieves a student group within the tenant scope.
+func (s *StudentGroupService) GetStudentGroupByID(scope repository.TenantScope, id uint) (*models.StudentGroup, error) {
+	group, err := s.groupRepo.GetStudentGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.CanAccessSchool(group.SchoolID) {
+		return nil, fmt.Errorf("student group with ID %d not found", id)
+	}
+	return group, nil
+}
+
+// CreateStudentGroup validates and creates a student group for a school.
+func (s *StudentGroupService) CreateStudentGroup(scope repository.TenantScope, group *models.StudentGroup) error {
+	if !scope.CanAccessSchool(group.SchoolID) {
+		return fmt.Errorf("school with ID %d not found", group.SchoolID)
+	}
+	if _, err := s.schoolRepo.GetSchoolByID(group.SchoolID); err != nil {
+		return err
+	}
+
+	if err := s.validator.Struct(group); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return s.groupRepo.CreateStudentGroup(group)
+}
+
+// UpdateStudentGroup validates and saves a student group with its members.
+func (s *StudentGroupService) UpdateStudentGroup(scope repository.TenantScope, group *models.StudentGroup) error {
+	if !scope.CanAccessSchool(group.SchoolID) {
+		return fmt.Errorf("student group with ID %d not found", group.ID)
+	}
+
+	if err := s.validator.Struct(group); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return s.groupRepo.UpdateStudentGroup(group)
+}
+
+// DeleteStudentGroup deletes a student group within the tenant scope.
+func (s *StudentGroupService) DeleteStudentGroup(scope repository.TenantScope, id uint) error {
+	if _, err := s.GetStudentGroupByID(scope, id); err != nil {
+		return err
+	}
+	return s.groupRepo.DeleteStudentGroup(id)
+}
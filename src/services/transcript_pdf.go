@@ -0,0 +1,93 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-pdf/fpdf"
+
+	"sama/sama-backend-2025/src/models"
+)
+
+// buildTranscriptPDF renders an issued transcript with its verification code and signature.
+// Without a UTF-8 font only latin text can be printed, so fontPath should be set for Thai schools.
+func buildTranscriptPDF(issue *models.TranscriptIssue, fontPath string) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+
+	family := "Helvetica"
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	if fontPath != "" {
+		family = "transcript"
+		pdf.AddUTF8Font(family, "", fontPath)
+		translate = func(text string) string { return text }
+	}
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+
+	transcript := issue.Snapshot
+
+	// Verification data is printed on every page
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(family, "", 7)
+		pdf.CellFormat(0, 4, translate("Verification code: "+issue.Code), "", 1, "L", false, 0, "")
+		pdf.CellFormat(0, 4, translate("Signature: "+issue.Signature), "", 0, "L", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont(family, "", 16)
+	pdf.CellFormat(0, 10, translate(transcript.SchoolName), "", 1, "C", false, 0, "")
+	pdf.SetFont(family, "", 13)
+	pdf.CellFormat(0, 8, translate("Activity Transcript"), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont(family, "", 10)
+	pdf.CellFormat(0, 6, translate(fmt.Sprintf("Name: %s %s", transcript.Firstname, transcript.Lastname)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, translate("Student ID: "+derefString(transcript.StudentUniqueID)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, translate("Classroom: "+derefString(transcript.Classroom)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, translate("Issued at: "+issue.IssuedAt.Format("2006-01-02 15:04 MST")), "", 1, "L", false, 0, "")
+
+	widths := []float64{80, 20, 30, 30, 20}
+	header := []string{"Activity", "Required", "Approved", "Completion", "Result"}
+
+	for _, term := range transcript.Terms {
+		pdf.Ln(4)
+		pdf.SetFont(family, "", 12)
+		pdf.CellFormat(0, 8, translate(fmt.Sprintf("School year %d, semester %d", term.SchoolYear, term.Semester)), "", 1, "L", false, 0, "")
+
+		pdf.SetFont(family, "", 9)
+		for i, title := range header {
+			pdf.CellFormat(widths[i], 6, translate(title), "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+
+		for _, activity := range term.Activities {
+			required := ""
+			if activity.IsRequired {
+				required = "Yes"
+			}
+			result := "Not passed"
+			if activity.Passed {
+				result = "Passed"
+			}
+
+			pdf.CellFormat(widths[0], 6, translate(activity.Name), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[1], 6, translate(required), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(widths[2], 6, translate(fmt.Sprintf("%d/%d %s", activity.ApprovedAmount, activity.FinishedAmount, activity.FinishedUnit)), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(widths[3], 6, fmt.Sprintf("%.0f%%", activity.FinishedPercentage), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(widths[4], 6, translate(result), "1", 1, "C", false, 0, "")
+		}
+
+		pdf.CellFormat(0, 6, translate(fmt.Sprintf("Completion %.0f%%, required activities passed %d/%d",
+			term.CompletionPercent, term.RequiredPassed, term.RequiredTotal)), "", 1, "L", false, 0, "")
+	}
+
+	var buffer bytes.Buffer
+	if err := pdf.Output(&buffer); err != nil {
+		return nil, fmt.Errorf("failed to write pdf: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sama/sama-backend-2025/src/config"
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+	"sama/sama-backend-2025/src/utils"
+)
+
+// TranscriptService handles business logic for multi-semester student transcripts.
+type TranscriptService struct {
+	userRepo       *repository.UserRepository
+	activityRepo   *repository.ActivityRepository
+	settingsRepo   *repository.SchoolSettingsRepository
+	transcriptRepo *repository.TranscriptRepository
+	blobStore      pkg.BlobStore
+	signingSecret  string // HMAC secret used to sign issued transcripts
+	fontPath       string // UTF-8 font used when rendering the PDF
+}
+
+// NewTranscriptService creates a new instance of TranscriptService.
+func NewTranscriptService(cfg *config.Config, blobStore pkg.BlobStore) *TranscriptService {
+	return &TranscriptService{
+		userRepo:       repository.NewUserRepository(),
+		activityRepo:   repository.NewActivityRepository(),
+		settingsRepo:   repository.NewSchoolSettingsRepository(),
+		transcriptRepo: repository.NewTranscriptRepository(),
+		blobStore:      blobStore,
+		signingSecret:  cfg.Transcript.SigningSecret,
+		fontPath:       cfg.Transcript.FontPath,
+	}
+}
+
+// GetTranscript builds the transcript of a student over every term in the school's semester list.
+func (s *TranscriptService) GetTranscript(scope repository.TenantScope, userID uint) (*models.Transcript, error) {
+	user, err := s.userRepo.GetUserByID(scope, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role != "STD" {
+		return nil, fmt.Errorf("user with ID %d is not a student", userID)
+	}
+
+	settings, err := s.settingsRepo.GetSettingsBySchoolID(user.SchoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := parseSemesterYearList(user.School.AvaliableSemesterList)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript := &models.Transcript{
+		StudentID:       user.ID,
+		StudentUniqueID: user.StudentUniqueID,
+		Firstname:       user.Firstname,
+		Lastname:        user.Lastname,
+		Classroom:       user.Classroom,
+		SchoolID:        user.SchoolID,
+		SchoolName:      user.School.ThaiName,
+		Terms:           make([]models.TranscriptTerm, 0, len(terms)),
+		GeneratedAt:     time.Now().UTC(),
+	}
+
+	for _, term := range terms {
+		activities, err := s.activityRepo.GetAssignedActivitiesByUserID(user.ID, user.SchoolID, term.Semester, term.SchoolYear, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve activities of %d/%d: %w", term.SchoolYear, term.Semester, err)
+		}
+
+		var sum float32
+		term.Activities = make([]models.TranscriptActivity, len(activities))
+		for i, activity := range activities {
+			percentage := utils.NormallizePercent(activity.FinishedPercentage)
+			passed := percentage >= float32(settings.CompletionThreshold)
+
+			term.Activities[i] = models.TranscriptActivity{
+				ActivityID:         activity.ID,
+				Name:               activity.Name,
+				IsRequired:         activity.IsRequired,
+				FinishedUnit:       activity.FinishedUnit,
+				FinishedAmount:     activity.FinishedAmount,
+				ApprovedAmount:     activity.TotalApprovedRecords,
+				FinishedPercentage: percentage,
+				Passed:             passed,
+			}
+
+			sum += percentage
+			if activity.IsRequired {
+				term.RequiredTotal++
+				if passed {
+					term.RequiredPassed++
+				}
+			}
+		}
+
+		if len(activities) > 0 {
+			term.CompletionPercent = utils.NormallizePercent(sum / float32(len(activities)))
+		}
+		term.PassedAllRequired = term.RequiredPassed == term.RequiredTotal
+
+		transcript.Terms = append(transcript.Terms, term)
+	}
+
+	return transcript, nil
+}
+
+// IssueTranscriptFile renders the transcript of a student as a signed PDF and returns a download URL.
+// Each issue is stored with a verification code so the document can be checked with VerifyTranscript.
+func (s *TranscriptService) IssueTranscriptFile(ctx context.Context, scope repository.TenantScope, userID, issuedByID uint) (*models.TranscriptIssue, *pkg.PresignedRequest, error) {
+	if s.signingSecret == "" {
+		return nil, nil, errors.New("transcript signing is not configured")
+	}
+
+	transcript, err := s.GetTranscript(scope, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	issue := &models.TranscriptIssue{
+		Code:       uuid.New().String(),
+		StudentID:  transcript.StudentID,
+		SchoolID:   transcript.SchoolID,
+		IssuedByID: issuedByID,
+		Snapshot:   *transcript,
+		IssuedAt:   transcript.GeneratedAt.Truncate(time.Second),
+	}
+	issue.ObjectKey = fmt.Sprintf("transcripts/%d/%s.pdf", issue.StudentID, issue.Code)
+
+	issue.Signature, err = s.sign(issue)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := buildTranscriptPDF(issue, s.fontPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate transcript file: %w", err)
+	}
+
+	if err := s.blobStore.PutObject(ctx, issue.ObjectKey, file, "application/pdf"); err != nil {
+		return nil, nil, fmt.Errorf("failed to upload transcript file: %w", err)
+	}
+
+	if err := s.transcriptRepo.CreateTranscriptIssue(issue); err != nil {
+		return nil, nil, err
+	}
+
+	request, err := s.blobStore.PresignDownload(ctx, issue.ObjectKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get presigned download URL from storage: %w", err)
+	}
+
+	return issue, request, nil
+}
+
+// VerifyTranscript checks the signature printed on a transcript and returns the issued content.
+func (s *TranscriptService) VerifyTranscript(code, signature string) (*models.TranscriptIssue, error) {
+	issue, err := s.transcriptRepo.GetTranscriptIssueByCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := s.sign(issue)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both the stored and the presented signature must match the content
+	if !hmac.Equal([]byte(expected), []byte(issue.Signature)) || !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return nil, errors.New("invalid transcript signature")
+	}
+
+	return issue, nil
+}
+
+// sign computes the HMAC of the issue code, student, issue time and transcript content.
+func (s *TranscriptService) sign(issue *models.TranscriptIssue) (string, error) {
+	snapshot, err := json.Marshal(issue.Snapshot)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode transcript: %w", err)
+	}
+	digest := sha256.Sum256(snapshot)
+
+	mac := hmac.New(sha256.New, []byte(s.signingSecret))
+	fmt.Fprintf(mac, "%s|%d|%s|%x", issue.Code, issue.StudentID, issue.IssuedAt.UTC().Format(time.RFC3339), digest)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// parseSemesterYearList converts "year/semester" entries into terms sorted chronologically.
+func parseSemesterYearList(list models.SemesterYearList) ([]models.TranscriptTerm, error) {
+	terms := make([]models.TranscriptTerm, 0, len(list))
+	for _, entry := range list {
+		parts := strings.Split(entry, "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid semester entry: %s", entry)
+		}
+		schoolYear, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid school year in semester entry %s: %w", entry, err)
+		}
+		semester, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semester in semester entry %s: %w", entry, err)
+		}
+		terms = append(terms, models.TranscriptTerm{SchoolYear: uint(schoolYear), Semester: uint(semester)})
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].SchoolYear != terms[j].SchoolYear {
+			return terms[i].SchoolYear < terms[j].SchoolYear
+		}
+		return terms[i].Semester < terms[j].Semester
+	})
+
+	return terms, nil
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/xuri/excelize/v2"
+
+	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/pkg"
+	"sama/sama-backend-2025/src/repository"
+	"sama/sama-backend-2025/src/utils"
+)
+
+// maxImportRows limits the size of one roster file.
+const maxImportRows = 5000
+
+// initialPasswordLength is the length of generated passwords of imported users.
+const initialPasswordLength = 12
+
+// rosterColumns are the columns read from a roster file, matched against the header.
+var rosterColumns = []string{"student_id", "firstname", "lastname", "email", "classroom", "number", "role"}
+
+// UserImportService handles bulk creation of users from roster files.
+type UserImportService struct {
+	userRepo       *repository.UserRepository
+	schoolRepo     *repository.SchoolRepository
+	settingsRepo   *repository.SchoolSettingsRepository
+	mailerClient   *pkg.MailerService
+	passwordHasher pkg.PasswordHasher
+	passwordPolicy *pkg.PasswordPolicy
+	validator      *validator.Validate
+}
+
+// NewUserImportService creates a new instance of UserImportService.
+func NewUserImportService(mailerClient *pkg.MailerService, passwordHasher pkg.PasswordHasher, passwordPolicy *pkg.PasswordPolicy, validate *validator.Validate) *UserImportService {
+	return &UserImportService{
+		userRepo:       repository.NewUserRepository(),
+		schoolRepo:     repository.NewSchoolRepository(),
+		settingsRepo:   repository.NewSchoolSettingsRepository(),
+		mailerClient:   mailerClient,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		validator:      validate,
+	}
+}
+
+// ImportUsers validates a roster file (CSV or XLSX) and creates its users in the school.
+// Nothing is created when any row is invalid or when dryRun is set; the report lists every rejected row.
+// Created users receive a generated initial password by email.
+func (s *UserImportService) ImportUsers(ctx context.Context, scope repository.TenantScope, schoolID uint, filename string, file io.Reader, dryRun bool) (*models.UserImportReport, error) {
+	if !scope.CanAccessSchool(schoolID) {
+		return nil, fmt.Errorf("school with ID %d not found", schoolID)
+	}
+
+	school, err := s.schoolRepo.GetSchoolByID(schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, parseErrors, err := parseRosterFile(filename, file)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.UserImportReport{
+		DryRun:        dryRun,
+		TotalRows:     len(rows),
+		Errors:        parseErrors,
+		EmailFailures: []string{},
+	}
+
+	if err := s.validateRows(school, rows, report); err != nil {
+		return nil, err
+	}
+	report.ValidRows = report.TotalRows - countRejectedRows(report.Errors)
+
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	settings, err := s.settingsRepo.GetSettingsBySchoolID(schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]models.User, len(rows))
+	passwords := make([]string, len(rows))
+	for i, row := range rows {
+		passwords[i], err = s.passwordPolicy.GeneratePassword(initialPasswordLength)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate password: %w", err)
+		}
+
+		hashedPassword, err := s.passwordHasher.Hash(passwords[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		defaultPictureURL := ""
+		users[i] = models.User{
+			StudentUniqueID:   row.StudentID,
+			Role:              row.Role,
+			Email:             row.Email,
+			Password:          hashedPassword,
+			Firstname:         row.Firstname,
+			Lastname:          row.Lastname,
+			ProfilePictureURL: &defaultPictureURL,
+			Language:          settings.DefaultLanguage,
+			Classroom:         row.Classroom,
+			Number:            row.Number,
+		}
+	}
+
+	if err := s.userRepo.CreateUsers(schoolID, users); err != nil {
+		return nil, err
+	}
+	report.Created = len(users)
+
+	// Users are already created, a failed email is reported instead of failing the import
+	for i, user := range users {
+		data := map[string]any{"Name": user.Firstname + " " + user.Lastname, "Password": passwords[i]}
+		if err := s.mailerClient.Send(ctx, "welcome", user.Language, data, user.Email); err != nil {
+			report.EmailFailures = append(report.EmailFailures, user.Email)
+		}
+	}
+
+	return report, nil
+}
+
+// validateRows checks every row and adds the problems to the report.
+func (s *UserImportService) validateRows(school *models.School, rows []models.UserImportRow, report *models.UserImportReport) error {
+	classrooms := make(map[string]bool, len(school.Classrooms))
+	for _, classroom := range school.Classrooms {
+		classrooms[classroom] = true
+	}
+
+	emails := make([]string, 0, len(rows))
+	firstRowOfEmail := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		addError := func(field, message string) {
+			report.Errors = append(report.Errors, models.UserImportError{Row: row.Row, Field: field, Message: message})
+		}
+
+		if row.Firstname == "" {
+			addError("firstname", "firstname is required")
+		}
+		if row.Lastname == "" {
+			addError("lastname", "lastname is required")
+		}
+		if !utils.Contains([]string{"STD", "TCH", "ADMIN"}, row.Role) {
+			addError("role", "role must be one of STD, TCH or ADMIN")
+		}
+
+		if err := s.validator.Var(row.Email, "required,email"); err != nil {
+			addError("email", "email is invalid")
+		} else if firstRow, ok := firstRowOfEmail[row.Email]; ok {
+			addError("email", fmt.Sprintf("email is duplicated with row %d", firstRow))
+		} else {
+			firstRowOfEmail[row.Email] = row.Row
+			emails = append(emails, row.Email)
+		}
+
+		if row.Classroom != nil {
+			if err := s.validator.Var(*row.Classroom, "classroomregex"); err != nil {
+				addError("classroom", "classroom must be in the format 'X/Y'")
+			} else if !classrooms[*row.Classroom] {
+				addError("classroom", fmt.Sprintf("classroom %s does not exist in this school", *row.Classroom))
+			}
+		} else if row.Role == "STD" {
+			addError("classroom", "classroom is required for students")
+		}
+	}
+
+	existingEmails, err := s.userRepo.GetExistingEmails(emails)
+	if err != nil {
+		return err
+	}
+	for _, email := range existingEmails {
+		report.Errors = append(report.Errors, models.UserImportError{Row: firstRowOfEmail[email], Field: "email", Message: "user with this email already exists"})
+	}
+
+	return nil
+}
+
+// parseRosterFile reads the rows of a CSV or XLSX roster file. The first row must be the header.
+// Values that cannot be read are returned as row errors, an error is only returned for an unreadable file.
+func parseRosterFile(filename string, file io.Reader) ([]models.UserImportRow, []models.UserImportError, error) {
+	var records [][]string
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		var err error
+		if records, err = reader.ReadAll(); err != nil {
+			return nil, nil, fmt.Errorf("invalid roster file: %w", err)
+		}
+	case ".xlsx":
+		workbook, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid roster file: %w", err)
+		}
+		defer workbook.Close()
+		if records, err = workbook.GetRows(workbook.GetSheetName(0)); err != nil {
+			return nil, nil, fmt.Errorf("invalid roster file: %w", err)
+		}
+	default:
+		return nil, nil, errors.New("invalid roster file: only .csv and .xlsx are supported")
+	}
+
+	if len(records) < 2 {
+		return nil, nil, errors.New("invalid roster file: no user found")
+	}
+	if len(records)-1 > maxImportRows {
+		return nil, nil, fmt.Errorf("invalid roster file: at most %d users can be imported at once", maxImportRows)
+	}
+
+	// Map columns by their header name, so the order in the file doesn't matter
+	positions := make(map[string]int)
+	for i, name := range records[0] {
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+		name = strings.TrimPrefix(name, "\ufeff") // Spreadsheet programs often save CSV with a BOM
+		positions[name] = i
+	}
+	for _, column := range rosterColumns {
+		if _, ok := positions[column]; !ok {
+			return nil, nil, fmt.Errorf("invalid roster file: missing column %s", column)
+		}
+	}
+
+	rows := make([]models.UserImportRow, 0, len(records)-1)
+	parseErrors := []models.UserImportError{}
+	for i, record := range records[1:] {
+		value := func(column string) string {
+			if position := positions[column]; position < len(record) {
+				return strings.TrimSpace(record[position])
+			}
+			return ""
+		}
+
+		// Skip blank lines, which spreadsheets often leave at the end
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := models.UserImportRow{
+			Row:       i + 2,
+			Firstname: value("firstname"),
+			Lastname:  value("lastname"),
+			Email:     strings.ToLower(value("email")),
+			Role:      strings.ToUpper(value("role")),
+		}
+		if studentID := value("student_id"); studentID != "" {
+			row.StudentID = &studentID
+		}
+		if classroom := value("classroom"); classroom != "" {
+			row.Classroom = &classroom
+		}
+		if number := value("number"); number != "" {
+			parsed, err := strconv.ParseUint(number, 10, 64)
+			if err != nil || parsed == 0 {
+				parseErrors = append(parseErrors, models.UserImportError{Row: row.Row, Field: "number", Message: "number must be a positive integer"})
+			} else {
+				parsedNumber := uint(parsed)
+				row.Number = &parsedNumber
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, errors.New("invalid roster file: no user found")
+	}
+
+	return rows, parseErrors, nil
+}
+
+// countRejectedRows counts rows with at least one error.
+func countRejectedRows(importErrors []models.UserImportError) int {
+	rejected := make(map[int]bool)
+	for _, importError := range importErrors {
+		rejected[importError.Row] = true
+	}
+	return len(rejected)
+}
@@ -1,8 +1,13 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"time"
+
 	"sama/sama-backend-2025/src/models"
+	"sama/sama-backend-2025/src/pkg"
 	"sama/sama-backend-2025/src/repository"
 	"sama/sama-backend-2025/src/utils"
 
@@ -11,27 +16,33 @@ import (
 
 // userService handles business logic for user accounts.
 type UserService struct {
-	userRepo     *repository.UserRepository
-	schoolRepo   *repository.SchoolRepository
-	activityRepo *repository.ActivityRepository
-	validator    *validator.Validate
-	jwtSecret    string // JWT secret for token generation
-	jwtExpMins   int    // JWT expiration in minutes
+	userRepo         *repository.UserRepository
+	schoolRepo       *repository.SchoolRepository
+	activityRepo     *repository.ActivityRepository
+	tokenRepo        *repository.RefreshTokenRepository
+	verificationRepo *repository.EmailVerificationRepository
+	mailerClient     *pkg.MailerService
+	validator        *validator.Validate
+	jwtSecret        string // JWT secret for token generation
+	jwtExpMins       int    // JWT expiration in minutes
 }
 
 // NewuserService creates a new instance of userService.
-func NewUserService(validate *validator.Validate) *UserService {
+func NewUserService(mailerClient *pkg.MailerService, validate *validator.Validate) *UserService {
 	return &UserService{
-		userRepo:     repository.NewUserRepository(),
-		schoolRepo:   repository.NewSchoolRepository(),
-		activityRepo: repository.NewActivityRepository(),
-		validator:    validate,
+		userRepo:         repository.NewUserRepository(),
+		schoolRepo:       repository.NewSchoolRepository(),
+		activityRepo:     repository.NewActivityRepository(),
+		tokenRepo:        repository.NewRefreshTokenRepository(),
+		verificationRepo: repository.NewEmailVerificationRepository(),
+		mailerClient:     mailerClient,
+		validator:        validate,
 	}
 }
 
-// GetUserByID retrieves a user by ID.
-func (s *UserService) GetUserByID(id uint) (*models.User, error) {
-	return s.userRepo.GetUserByID(id)
+// GetUserByID retrieves a user by ID within the tenant scope.
+func (s *UserService) GetUserByID(scope repository.TenantScope, id uint) (*models.User, error) {
+	return s.userRepo.GetUserByID(scope, id)
 }
 
 // GetUserByEmail retrieves a user by email.
@@ -47,13 +58,15 @@ func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
 
 // GetUsersBySchoolID retrieves users for a specific school.
 // This is for ADMINs to access users within their school.
-func (s *UserService) GetUsersBySchoolID(schoolID, userID uint, name, role, classroom string, limit, offset int) ([]models.User, int, error) {
-	return s.userRepo.GetUsersBySchoolID(schoolID, userID, name, role, classroom, limit, offset)
+func (s *UserService) GetUsersBySchoolID(scope repository.TenantScope, schoolID, userID, groupID uint, name, role, classroom, status string, limit, offset int) ([]models.User, int, error) {
+	return s.userRepo.GetUsersBySchoolID(scope, schoolID, userID, groupID, name, role, classroom, status, limit, offset)
 }
 
 // UpdateUserProfile updates a user's profile information.
 // This method handles general profile updates, not password changes.
-func (s *UserService) UpdateUserProfile(user *models.User) error {
+// A new email is only stored as pending and a verification code is sent to it, the current email is kept until it is confirmed.
+// The user is updated in place with the stored profile.
+func (s *UserService) UpdateUserProfile(scope repository.TenantScope, user *models.User) error {
 	// Crucial: Prevent password from being overwritten by an empty string
 	// The password field in models.User should have `json:"-"` and `gorm:"column:password"`
 	// to avoid it being marshaled/unmarshaled from JSON and to store the hashed value.
@@ -61,7 +74,7 @@ func (s *UserService) UpdateUserProfile(user *models.User) error {
 	user.Password = ""
 
 	// Fetch existing user to ensure we're updating a valid record
-	existingUser, err := s.userRepo.GetUserByID(user.ID)
+	existingUser, err := s.userRepo.GetUserByID(scope, user.ID)
 	if err != nil {
 		return fmt.Errorf("user not found for update: %w", err)
 	}
@@ -69,11 +82,16 @@ func (s *UserService) UpdateUserProfile(user *models.User) error {
 	// Manually update fields that are allowed to be updated from the `user` input
 	// This prevents overwriting fields not intended for update or sensitive fields.
 	// You might want to make this more granular based on what fields are allowed to be changed.
-	existingUser.Email = user.Email // Email might be updated, but usually has unique constraint
+	emailChanged := user.Email != existingUser.Email
+	if emailChanged {
+		if _, err := s.userRepo.GetUserByEmail(user.Email); err == nil {
+			return errors.New("user with this email already exists")
+		}
+		existingUser.PendingEmail = &user.Email
+	}
 	existingUser.Phone = user.Phone
 	existingUser.Firstname = user.Firstname
 	existingUser.Lastname = user.Lastname
-	existingUser.ProfilePictureURL = user.ProfilePictureURL
 	existingUser.Classroom = user.Classroom
 	existingUser.Number = user.Number
 	existingUser.Language = user.Language
@@ -85,7 +103,18 @@ func (s *UserService) UpdateUserProfile(user *models.User) error {
 	// 	return fmt.Errorf("validation failed for updated user: %w", err)
 	// }
 
-	return s.userRepo.UpdateUser(existingUser)
+	if err := s.userRepo.UpdateUser(scope, existingUser); err != nil {
+		return err
+	}
+	*user = *existingUser
+
+	// The change is saved at this point, a lost email can be sent again by the user
+	if emailChanged {
+		if err := sendEmailVerification(s.verificationRepo, s.mailerClient, existingUser, *existingUser.PendingEmail); err != nil {
+			log.Printf("failed to send verification email to user %d: %v", existingUser.ID, err)
+		}
+	}
+	return nil
 }
 
 // // UpdateProfilePicture updates a user's profile picture URL.
@@ -104,7 +133,7 @@ func (s *UserService) UpdateUserProfile(user *models.User) error {
 // 	return postRequest.URL, postRequest.Values, nil
 // }
 
-func (r *UserService) GetUserStatistic(userID, schoolID uint, activityIDs []uint, semester, schoolYear uint) (
+func (r *UserService) GetUserStatistic(scope repository.TenantScope, userID uint, activityIDs []uint, semester, schoolYear uint) (
 	activities []models.ActivityWithStatistic,
 	totalNonCreated,
 	totalCreated,
@@ -114,6 +143,13 @@ func (r *UserService) GetUserStatistic(userID, schoolID uint, activityIDs []uint
 	err error,
 ) {
 
+	// The user must be visible in the tenant scope, activities are taken from the user's school
+	user, err := r.userRepo.GetUserByID(scope, userID)
+	if err != nil {
+		return
+	}
+	schoolID := user.SchoolID
+
 	// if either semester of school year is invalid, get current semester and year
 	if semester == 0 || schoolYear == 0 {
 		semester, schoolYear, err = r.schoolRepo.GetSchoolSemesterAndSchoolYearByID(schoolID)
@@ -185,10 +221,117 @@ func (s *UserService) DeleteProfilePicture(userID uint) error {
 	return s.userRepo.DeleteUserProfilePicture(userID)
 }
 
-// DeleteUser deletes a user by ID.
+// DeleteUser deletes a user by ID within the tenant scope.
 // This method needs to include authorization logic in a real app (e.g., check if user has permission to delete this ID).
-func (s *UserService) DeleteUser(id uint) error {
-	return s.userRepo.DeleteUser(id)
+func (s *UserService) DeleteUser(scope repository.TenantScope, id uint) error {
+	if err := s.userRepo.DeleteUser(scope, id); err != nil {
+		return err
+	}
+
+	// A deleted user must not be able to refresh their way back in
+	return s.tokenRepo.RevokeUserTokens(id)
+}
+
+// UpdateUserStatus changes the lifecycle status of a user from the given date, which defaults to now.
+// Suspended users are logged out of every device. The user is updated in place with the stored profile.
+func (s *UserService) UpdateUserStatus(user *models.User, status string, effectiveAt *time.Time, reason string, changedByID uint) error {
+	if user.ID == changedByID {
+		return errors.New("cannot change your own status")
+	}
+	if user.Status == status {
+		return fmt.Errorf("user already has status %s", status)
+	}
+
+	effective, err := lifecycleEffectiveDate(effectiveAt)
+	if err != nil {
+		return err
+	}
+
+	err = s.userRepo.UpdateUserStatus(&models.UserStatusLog{
+		UserID:      user.ID,
+		Status:      status,
+		SchoolID:    user.SchoolID,
+		Reason:      reason,
+		EffectiveAt: effective,
+		ChangedByID: changedByID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if status == "SUSPENDED" {
+		if err := s.tokenRepo.RevokeUserTokens(user.ID); err != nil {
+			return err
+		}
+	}
+	return s.reloadUser(user)
+}
+
+// TransferUser moves a student to another school, where they are ACTIVE from the given date.
+// Their records move along since they belong to the student, and they are logged out so new tokens carry the new school.
+// The user is updated in place with the stored profile.
+func (s *UserService) TransferUser(user *models.User, toSchoolID uint, effectiveAt *time.Time, reason string, changedByID uint) error {
+	if user.Role != "STD" {
+		return errors.New("only students can be transferred")
+	}
+	if user.SchoolID == toSchoolID {
+		return errors.New("user already belongs to this school")
+	}
+	if _, err := s.schoolRepo.GetSchoolByID(toSchoolID); err != nil {
+		return err
+	}
+
+	effective, err := lifecycleEffectiveDate(effectiveAt)
+	if err != nil {
+		return err
+	}
+
+	fromSchoolID := user.SchoolID
+	err = s.userRepo.TransferUser(&models.UserStatusLog{
+		UserID:       user.ID,
+		Status:       "TRANSFERRED",
+		SchoolID:     toSchoolID,
+		FromSchoolID: &fromSchoolID,
+		Reason:       reason,
+		EffectiveAt:  effective,
+		ChangedByID:  changedByID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.RevokeUserTokens(user.ID); err != nil {
+		return err
+	}
+	return s.reloadUser(user)
+}
+
+// GetUserStatusLogs retrieves the lifecycle history of a user.
+func (s *UserService) GetUserStatusLogs(userID uint) ([]models.UserStatusLog, error) {
+	return s.userRepo.GetUserStatusLogs(userID)
+}
+
+// reloadUser overwrites a user with its stored state, which may have left the tenant scope of the caller.
+func (s *UserService) reloadUser(user *models.User) error {
+	stored, err := s.userRepo.GetUserByID(repository.SystemScope(), user.ID)
+	if err != nil {
+		return err
+	}
+	*user = *stored
+	return nil
+}
+
+// lifecycleEffectiveDate returns the date a status change takes effect, now when none is given.
+// Changes are recorded once they happened, so the date can be backdated but not in the future.
+func lifecycleEffectiveDate(effectiveAt *time.Time) (time.Time, error) {
+	now := time.Now()
+	if effectiveAt == nil {
+		return now, nil
+	}
+	if effectiveAt.After(now) {
+		return time.Time{}, errors.New("effective date cannot be in the future")
+	}
+	return *effectiveAt, nil
 }
 
 // GetUserCount returns the total number of users.
@@ -0,0 +1,46 @@
+package utils
+
+import "strings"
+
+// DeviceNameFromUserAgent returns a readable name of a device such as "Chrome on Windows".
+// It only recognizes common browsers and systems, which is enough to tell sessions apart.
+func DeviceNameFromUserAgent(userAgent string) string {
+	browser := ""
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	}
+
+	system := ""
+	switch {
+	case strings.Contains(userAgent, "Windows"):
+		system = "Windows"
+	case strings.Contains(userAgent, "Android"):
+		system = "Android"
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		system = "iOS"
+	case strings.Contains(userAgent, "Mac OS X"):
+		system = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		system = "Linux"
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "Unknown device"
+	}
+}
@@ -15,9 +15,16 @@ type Claims struct {
 	SchoolID uint   `json:"school_id"`
 	Email    string `json:"email"`
 	Role     string `json:"role"`
+	// Set when a SAMA crew member acts as the user, to the ID of that crew member
+	ActorID uint `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// IsImpersonated reports whether the token was issued to a SAMA crew member acting as the user.
+func (c *Claims) IsImpersonated() bool {
+	return c.ActorID != 0
+}
+
 // Claims defines the JWT claims structure.
 // You can add more custom claims as needed (e.g., user role, school ID).
 type RefreshClaims struct {
@@ -29,17 +36,25 @@ type RefreshClaims struct {
 
 	// Crucial for identifying the specific refresh token instance
 	// and enabling server-side revocation and rotation.
-	// Jti string `json:"jti"` // JWT ID - unique identifier for the token
+	Jti string `json:"jti"` // JWT ID - unique identifier for the token
+	// Tokens rotated from the same login share a family, revoked together when a token is reused.
+	FamilyID string `json:"fid"`
 }
 
 // GenerateToken generates a new JWT token for a given user.
 func GenerateToken(userID uint, schoolID uint, email, role, jwtSecret string, expirationMinutes int) (string, error) {
+	return GenerateImpersonationToken(userID, schoolID, email, role, 0, jwtSecret, expirationMinutes)
+}
+
+// GenerateImpersonationToken generates a JWT token for a given user, used by the SAMA crew member actorID.
+func GenerateImpersonationToken(userID uint, schoolID uint, email, role string, actorID uint, jwtSecret string, expirationMinutes int) (string, error) {
 	expirationTime := time.Now().Add(time.Duration(expirationMinutes) * time.Minute)
 	claims := &Claims{
 		UserID:   userID,
 		Email:    email,
 		Role:     role,
 		SchoolID: schoolID,
+		ActorID:  actorID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -77,11 +92,13 @@ func ValidateToken(tokenString, jwtSecret string) (*Claims, error) {
 	return claims, nil
 }
 
-// GenerateToken generates a new JWT token for a given user.
-func GenerateRefreshToken(userID uint, jwtSecret string, expirationMinutes int) (string, error) {
+// GenerateRefreshToken generates a new refresh token of a token family for a given user.
+func GenerateRefreshToken(userID uint, familyID, jti, jwtSecret string, expirationMinutes int) (string, error) {
 	expirationTime := time.Now().Add(time.Duration(expirationMinutes) * time.Minute)
 	claims := &RefreshClaims{
-		UserID: userID,
+		UserID:   userID,
+		Jti:      jti,
+		FamilyID: familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -108,8 +125,6 @@ func ValidateRefreshToken(tokenString, jwtSecret string) (*RefreshClaims, error)
 		return []byte(jwtSecret), nil
 	})
 
-	// TODO: make refresh a one-time token
-
 	if err != nil {
 		return nil, fmt.Errorf("token validation failed: %w", err)
 	}
@@ -118,5 +133,10 @@ func ValidateRefreshToken(tokenString, jwtSecret string) (*RefreshClaims, error)
 		return nil, errors.New("invalid token")
 	}
 
+	// Whether the token was already used is checked against the token store by the caller
+	if claims.Jti == "" || claims.FamilyID == "" {
+		return nil, errors.New("refresh token is missing its identifier")
+	}
+
 	return claims, nil
 }
@@ -1,13 +1,17 @@
 package utils
 
 import (
-	"math/rand/v2"
+	"crypto/rand"
+	"math/big"
 	"strconv"
-	// Use jwt/v5
 )
 
-func GenerateOTPCode() string {
-	// From 10000 to 99999
-	code := rand.Int32N(900000) + 100000
-	return strconv.Itoa(int(code))
+// GenerateOTPCode returns a random six-digit code using a cryptographically secure source.
+func GenerateOTPCode() (string, error) {
+	// From 100000 to 999999
+	code, err := rand.Int(rand.Reader, big.NewInt(900000))
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(code.Int64()+100000, 10), nil
 }
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// passwordCharacterClasses are the characters of generated passwords, one of each class being required by the strictest password policy.
+// Characters that are easy to mix up when read from an email are left out.
+var passwordCharacterClasses = []string{
+	"abcdefghijkmnopqrstuvwxyz",
+	"ABCDEFGHJKLMNPQRSTUVWXYZ",
+	"23456789",
+	"!#$%&*+-=?@_",
+}
+
+// GeneratePassword returns a random password of the given length, used as an initial password.
+// It contains a lowercase letter, an uppercase letter, a digit and a symbol, so the length must be at least 4.
+func GeneratePassword(length int) (string, error) {
+	if length < len(passwordCharacterClasses) {
+		return "", fmt.Errorf("password length must be at least %d", len(passwordCharacterClasses))
+	}
+
+	alphabet := ""
+	for _, class := range passwordCharacterClasses {
+		alphabet += class
+	}
+
+	// One character of every class first, then any character, and the positions are shuffled
+	password := make([]byte, length)
+	for i := range password {
+		characters := alphabet
+		if i < len(passwordCharacterClasses) {
+			characters = passwordCharacterClasses[i]
+		}
+		n, err := randomInt(len(characters))
+		if err != nil {
+			return "", err
+		}
+		password[i] = characters[n]
+	}
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// randomInt returns a uniform random number in [0, max).
+func randomInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, err
+	}
+	return int(n.Int64()), nil
+}
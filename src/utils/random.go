@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// codeAlphabet leaves out characters that are easy to mix up when typed by hand.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateInvitationCode returns a random code of the given length to be shared with new users.
+func GenerateInvitationCode(length int) (string, error) {
+	code := make([]byte, length)
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}